@@ -0,0 +1,54 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"time"
+
+	"github.com/enterprise/opencode-enterprise-shield/pkg/audit"
+	"github.com/enterprise/opencode-enterprise-shield/pkg/config"
+)
+
+// runAuditCommand dispatches the "audit" subcommands.
+func runAuditCommand(args []string) error {
+	if len(args) < 1 {
+		return fmt.Errorf("usage: enterprise-shield audit report [arguments]")
+	}
+
+	switch args[0] {
+	case "report":
+		return runAuditReport(args[1:])
+	default:
+		return fmt.Errorf("unknown audit command %q", args[0])
+	}
+}
+
+// runAuditReport prints audited findings grouped by compliance framework
+// and control.
+func runAuditReport(args []string) error {
+	flags := flag.NewFlagSet("audit report", flag.ContinueOnError)
+	auditRange := flags.String("audit-range", "", "entries to report on: FROM..TO dates (YYYY-MM-DD, TO inclusive, either may be omitted) or a period such as 30d (default: all)")
+	auditDir := flags.String("audit-dir", "", "audit log directory (default: the configured one)")
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+	if flags.NArg() != 0 {
+		return fmt.Errorf("usage: enterprise-shield audit report [--audit-range FROM..TO|30d] [--audit-dir dir]")
+	}
+
+	from, to, err := parseAuditRange(*auditRange, time.Now())
+	if err != nil {
+		return err
+	}
+	if *auditDir == "" {
+		*auditDir = config.LoadOrDefault(configPath).Audit.LogPath
+	}
+
+	entries, err := audit.ReadEntries(*auditDir, from, to)
+	if err != nil {
+		return err
+	}
+
+	printJSON(audit.ReportByFramework(entries))
+	return nil
+}
@@ -5,7 +5,9 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"strings"
 
+	"github.com/enterprise/opencode-enterprise-shield/pkg/compliance"
 	"github.com/enterprise/opencode-enterprise-shield/pkg/config"
 	"github.com/enterprise/opencode-enterprise-shield/pkg/hooks"
 	"github.com/enterprise/opencode-enterprise-shield/pkg/types"
@@ -48,6 +50,17 @@ func (p *Plugin) ProcessRequest(userID, content, provider string) types.Response
 	return p.hook.OnRequest(userID, content, provider)
 }
 
+// ProcessScopedRequest handles outgoing requests from a specific
+// conversation or workspace.
+func (p *Plugin) ProcessScopedRequest(userID, scope, content, provider string) types.Response {
+	return p.hook.OnScopedRequest(userID, scope, content, provider)
+}
+
+// ProcessModelRequest handles outgoing requests to a named model.
+func (p *Plugin) ProcessModelRequest(userID, scope, content, provider, model string) types.Response {
+	return p.hook.OnModelRequest(userID, scope, content, provider, model)
+}
+
 // ProcessResponse handles incoming responses from LLM.
 func (p *Plugin) ProcessResponse(content, sessionID string) types.DesanitizationResult {
 	return p.hook.OnResponse(content, sessionID)
@@ -74,6 +87,7 @@ func main() {
 	switch command {
 	case "version":
 		fmt.Printf("Enterprise Shield Plugin v%s\n", version)
+		fmt.Printf("Credential detectors: %s\n", compliance.CredentialPackVersion)
 		if buildNumber != "0" {
 			fmt.Printf("Build: #%s\n", buildNumber)
 		}
@@ -111,12 +125,17 @@ func main() {
 	case "process":
 		// Process a request (for testing)
 		if len(os.Args) < 5 {
-			fmt.Fprintln(os.Stderr, "Usage: enterprise-shield process <userID> <content> <provider>")
+			fmt.Fprintln(os.Stderr, "Usage: enterprise-shield process <userID> <content> <provider> [scope]")
 			os.Exit(1)
 		}
 		userID := os.Args[2]
 		content := os.Args[3]
-		provider := os.Args[4]
+		// The provider may name a model, as in openai/gpt-4o
+		provider, model, _ := strings.Cut(os.Args[4], "/")
+		scope := ""
+		if len(os.Args) > 5 {
+			scope = os.Args[5]
+		}
 
 		plugin, err := NewPlugin()
 		if err != nil {
@@ -125,9 +144,63 @@ func main() {
 		}
 		defer plugin.Close()
 
-		result := plugin.ProcessRequest(userID, content, provider)
+		result := plugin.hook.Shield().ProcessRequest(types.Request{
+			UserID:   userID,
+			Scope:    scope,
+			Content:  content,
+			Provider: provider,
+			Model:    model,
+			Override: os.Getenv(overrideEnv),
+		})
 		printJSON(result)
 
+	case "desanitize":
+		// Restore original values in an LLM response or shared transcript
+		if len(os.Args) < 4 {
+			fmt.Fprintln(os.Stderr, "Usage: enterprise-shield desanitize <sessionID> <content>")
+			os.Exit(1)
+		}
+
+		plugin, err := NewPlugin()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		defer plugin.Close()
+
+		result := plugin.ProcessResponse(os.Args[3], os.Args[2])
+		printJSON(result)
+
+	case "session":
+		if err := runSessionCommand(os.Args[2:]); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+
+	case "policy":
+		if err := runPolicyCommand(os.Args[2:]); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+
+	case "override":
+		if err := runOverrideCommand(os.Args[2:]); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+
+	case "audit":
+		if err := runAuditCommand(os.Args[2:]); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+
+	case "usage":
+		if err := runUsageCommand(os.Args[2:]); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+
 	case "serve":
 		// Run as a service (for OpenCode integration)
 		fmt.Println("Enterprise Shield Plugin v" + version)
@@ -164,8 +237,38 @@ Commands:
   version              Show version information
   init                 Initialize default configuration
   scan <content>       Scan content for compliance violations
-  process <user> <content> <provider>
-                       Process a request (sanitize and check policy)
+  process <user> <content> <provider> [scope]
+                       Process a request (sanitize and check policy);
+                       provider may name a model, as in openai/gpt-4o;
+                       scope is an optional conversation ID or workspace path;
+                       a break-glass token is read from $ENTERPRISE_SHIELD_OVERRIDE
+  desanitize <sessionID> <content>
+                       Restore original values in content using a session
+  session list [--user <userID>]
+                       List live sessions
+  session show <sessionID> [--reveal]
+                       Show a session's aliases (originals redacted unless --reveal)
+  session forget <sessionID> <alias>
+                       Revoke a single alias from a session
+  session stats        Show session statistics
+  session export <sessionID> [--out file] [--passphrase p]
+                       Export a session as an encrypted, signed bundle
+  session import <file> [--passphrase p] [--trust key]
+                       Import a teammate's bundle as a new session
+  policy validate <file>
+                       Validate a policy file and print the resolved policies
+  policy simulate --policy <file> [--baseline file] [--audit-range FROM..TO|7d]
+                       Replay audited requests and report allow/block changes
+  override issue --user <userID> --scope <scope|"*"> --justification <text> [--ttl 1h]
+                       Issue a break-glass override signed with policy.overrideSigningKey
+  override revoke <overrideID> [--reason text]
+                       Revoke a break-glass override before it expires
+  override revoked     List revoked overrides
+  audit report [--audit-range FROM..TO|30d]
+                       Count audited findings by compliance framework and control
+  usage show           Show token usage for the current day and month
+  usage report <sessionID> <outputTokens>
+                       Count output tokens reported by the provider
   serve                Run in server mode for OpenCode integration
 
 Examples:
@@ -173,6 +276,7 @@ Examples:
   enterprise-shield init
   enterprise-shield scan "My SSN is 123-45-6789"
   enterprise-shield process user@example.com "Query ServerDB01" openai
+  enterprise-shield process user@example.com "Query ServerDB01" openai/gpt-4o
 
 Configuration:
   Default config path: ~/.opencode/config/enterprise-shield.yaml
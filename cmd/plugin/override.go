@@ -0,0 +1,121 @@
+package main
+
+import (
+	"encoding/base64"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/enterprise/opencode-enterprise-shield/pkg/config"
+	"github.com/enterprise/opencode-enterprise-shield/pkg/policy"
+	"github.com/enterprise/opencode-enterprise-shield/pkg/types"
+)
+
+// overrideEnv is the environment variable the process command reads a
+// break-glass override token from.
+const overrideEnv = "ENTERPRISE_SHIELD_OVERRIDE"
+
+// runOverrideCommand dispatches the "override" subcommands.
+func runOverrideCommand(args []string) error {
+	if len(args) < 1 {
+		return fmt.Errorf("usage: enterprise-shield override <issue|revoke|revoked> [arguments]")
+	}
+
+	switch args[0] {
+	case "issue":
+		return runOverrideIssue(args[1:])
+	case "revoke":
+		return runOverrideRevoke(args[1:])
+	case "revoked":
+		return runOverrideRevoked(args[1:])
+	default:
+		return fmt.Errorf("unknown override command %q", args[0])
+	}
+}
+
+// runOverrideIssue signs a time-limited override for a user and scope.
+func runOverrideIssue(args []string) error {
+	flags := flag.NewFlagSet("override issue", flag.ContinueOnError)
+	userID := flags.String("user", "", "user the override is for (required)")
+	scope := flags.String("scope", "", `conversation ID or workspace path, or "*" for any (required)`)
+	justification := flags.String("justification", "", "why the override is needed (required)")
+	ttl := flags.Duration("ttl", time.Hour, "how long the override lasts (at most 24h)")
+	level := flags.String("level", string(types.AccessUnrestricted), "access level granted: unrestricted or sanitized_only")
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+	if flags.NArg() != 0 || *userID == "" || *scope == "" || strings.TrimSpace(*justification) == "" {
+		return fmt.Errorf(`usage: enterprise-shield override issue --user <userID> --scope <scope|"*"> --justification <text> [--ttl 1h] [--level unrestricted]`)
+	}
+
+	plugin, err := NewPlugin()
+	if err != nil {
+		return err
+	}
+	defer plugin.Close()
+
+	issued, token, issuerKey, err := plugin.hook.Shield().IssueOverride(types.Override{
+		UserID:        *userID,
+		Scope:         *scope,
+		AccessLevel:   types.AccessLevel(*level),
+		Justification: *justification,
+	}, *ttl)
+	if err != nil {
+		return err
+	}
+
+	printJSON(map[string]interface{}{
+		"override":  issued,
+		"token":     token,
+		"issuerKey": base64.StdEncoding.EncodeToString(issuerKey),
+	})
+	fmt.Fprintln(os.Stderr, "Shields honor the token only if issuerKey is listed under policy.overrideTrustedKeys")
+	fmt.Fprintf(os.Stderr, "Present the token with requests, e.g. via $%s\n", overrideEnv)
+	return nil
+}
+
+// runOverrideRevoke revokes an override before it expires.
+func runOverrideRevoke(args []string) error {
+	flags := flag.NewFlagSet("override revoke", flag.ContinueOnError)
+	reason := flags.String("reason", "", "why the override is revoked")
+	revokedBy := flags.String("by", os.Getenv("USER"), "identity recorded as the revoker")
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 || *revokedBy == "" {
+		return fmt.Errorf("usage: enterprise-shield override revoke <overrideID> [--reason text] [--by name]")
+	}
+
+	plugin, err := NewPlugin()
+	if err != nil {
+		return err
+	}
+	defer plugin.Close()
+
+	if err := plugin.hook.Shield().RevokeOverride(flags.Arg(0), *revokedBy, *reason); err != nil {
+		return err
+	}
+	fmt.Printf("Revoked override %s\n", flags.Arg(0))
+	return nil
+}
+
+// runOverrideRevoked lists revoked overrides.
+func runOverrideRevoked(args []string) error {
+	if len(args) != 0 {
+		return fmt.Errorf("usage: enterprise-shield override revoked")
+	}
+
+	cfg := config.LoadOrDefault(configPath)
+	revocations, err := policy.NewRevocationList(cfg.ToHooksConfig().OverrideRevocations)
+	if err != nil {
+		return err
+	}
+	list, err := revocations.List()
+	if err != nil {
+		return err
+	}
+	printJSON(list)
+	return nil
+}
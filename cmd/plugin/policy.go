@@ -0,0 +1,230 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/enterprise/opencode-enterprise-shield/pkg/audit"
+	"github.com/enterprise/opencode-enterprise-shield/pkg/config"
+	"github.com/enterprise/opencode-enterprise-shield/pkg/policy"
+	"github.com/enterprise/opencode-enterprise-shield/pkg/sanitizer"
+	"github.com/enterprise/opencode-enterprise-shield/pkg/types"
+)
+
+// runPolicyCommand dispatches the "policy" subcommands.
+func runPolicyCommand(args []string) error {
+	if len(args) < 1 {
+		return fmt.Errorf("usage: enterprise-shield policy <validate|simulate> [arguments]")
+	}
+
+	switch args[0] {
+	case "validate":
+		return runPolicyValidate(args[1:])
+	case "simulate":
+		return runPolicySimulate(args[1:])
+	default:
+		return fmt.Errorf("unknown policy command %q", args[0])
+	}
+}
+
+// runPolicyValidate checks a policy file and prints the resolved policies.
+func runPolicyValidate(args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("usage: enterprise-shield policy validate <file>")
+	}
+
+	cfg := config.LoadOrDefault(configPath)
+	set, err := loadPolicySet(args[0], cfg)
+	if err != nil {
+		return err
+	}
+
+	printJSON(set)
+	return nil
+}
+
+// loadPolicySet loads and fully validates a policy file against the
+// configured default access level and rule profiles.
+func loadPolicySet(path string, cfg *config.FullConfig) (*policy.PolicySet, error) {
+	base := policy.DefaultPolicy()
+	if cfg.Policy.DefaultAccessLevel != "" {
+		base.AccessLevel = types.AccessLevel(cfg.Policy.DefaultAccessLevel)
+	}
+
+	set, err := policy.LoadFile(path, base)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkRuleSelections(set, cfg); err != nil {
+		return nil, err
+	}
+	return set, nil
+}
+
+// runPolicySimulate replays audited requests through a candidate policy
+// file and reports decisions that would change between allowed and blocked.
+func runPolicySimulate(args []string) error {
+	flags := flag.NewFlagSet("policy simulate", flag.ContinueOnError)
+	candidatePath := flags.String("policy", "", "candidate policy file (required)")
+	baselinePath := flags.String("baseline", "", "policy file to compare against (default: the configured policy file)")
+	auditRange := flags.String("audit-range", "", "entries to replay: FROM..TO dates (YYYY-MM-DD, TO inclusive, either may be omitted) or a period such as 7d or 12h (default: all)")
+	auditDir := flags.String("audit-dir", "", "audit log directory (default: the configured one)")
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+	if flags.NArg() != 0 || *candidatePath == "" {
+		return fmt.Errorf("usage: enterprise-shield policy simulate --policy <file> [--baseline file] [--audit-range FROM..TO|7d] [--audit-dir dir]")
+	}
+
+	from, to, err := parseAuditRange(*auditRange, time.Now())
+	if err != nil {
+		return err
+	}
+
+	cfg := config.LoadOrDefault(configPath)
+	if *baselinePath == "" {
+		*baselinePath = cfg.Policy.File
+	}
+	if *auditDir == "" {
+		*auditDir = cfg.Audit.LogPath
+	}
+
+	baseline, err := simulationEngine(*baselinePath, cfg)
+	if err != nil {
+		return fmt.Errorf("baseline: %w", err)
+	}
+	candidate, err := simulationEngine(*candidatePath, cfg)
+	if err != nil {
+		return fmt.Errorf("candidate: %w", err)
+	}
+
+	entries, err := audit.ReadEntries(*auditDir, from, to)
+	if err != nil {
+		return err
+	}
+
+	printJSON(policy.Simulate(baseline, candidate, entries))
+	return nil
+}
+
+// simulationEngine builds a policy engine from a policy file, or from the
+// configured defaults alone if path is empty.
+func simulationEngine(path string, cfg *config.FullConfig) (*policy.Engine, error) {
+	engine := policy.NewEngine()
+	if cfg.Policy.DefaultAccessLevel != "" {
+		if err := engine.SetDefaultAccessLevel(types.AccessLevel(cfg.Policy.DefaultAccessLevel)); err != nil {
+			return nil, err
+		}
+	}
+	if path == "" {
+		return engine, nil
+	}
+
+	set, err := loadPolicySet(path, cfg)
+	if err != nil {
+		return nil, err
+	}
+	engine.Load(set)
+	return engine, nil
+}
+
+// parseAuditRange parses an audit range: "FROM..TO" with dates or RFC 3339
+// times, where a TO date includes that whole day, or a period before now
+// such as "7d" or "12h". An empty range selects every entry.
+func parseAuditRange(value string, now time.Time) (time.Time, time.Time, error) {
+	if value == "" {
+		return time.Time{}, time.Time{}, nil
+	}
+
+	start, end, isRange := strings.Cut(value, "..")
+	if !isRange {
+		if days, ok := strings.CutSuffix(value, "d"); ok {
+			n, err := strconv.Atoi(days)
+			if err != nil || n <= 0 {
+				return time.Time{}, time.Time{}, fmt.Errorf("invalid audit range %q", value)
+			}
+			return now.AddDate(0, 0, -n), time.Time{}, nil
+		}
+		period, err := time.ParseDuration(value)
+		if err != nil || period <= 0 {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid audit range %q (use FROM..TO or a period such as 7d)", value)
+		}
+		return now.Add(-period), time.Time{}, nil
+	}
+
+	var from, to time.Time
+	var err error
+	if start != "" {
+		if from, err = parseRangeTime(start, false); err != nil {
+			return time.Time{}, time.Time{}, err
+		}
+	}
+	if end != "" {
+		if to, err = parseRangeTime(end, true); err != nil {
+			return time.Time{}, time.Time{}, err
+		}
+	}
+	if !from.IsZero() && !to.IsZero() && !from.Before(to) {
+		return time.Time{}, time.Time{}, fmt.Errorf("invalid audit range %q: start is not before end", value)
+	}
+	return from, to, nil
+}
+
+// parseRangeTime parses one end of an audit range. A date at the end of a
+// range stands for the end of that day.
+func parseRangeTime(value string, end bool) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	day, err := time.ParseInLocation("2006-01-02", value, time.Local)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid audit range time %q (use YYYY-MM-DD or RFC 3339)", value)
+	}
+	if end {
+		day = day.AddDate(0, 0, 1)
+	}
+	return day, nil
+}
+
+// checkRuleSelections verifies that every rule and rule profile a policy
+// requires exists, since requests under such a policy would be blocked.
+func checkRuleSelections(set *policy.PolicySet, cfg *config.FullConfig) error {
+	engine := sanitizer.NewEngine(sanitizer.DefaultRules())
+	for name, ruleIDs := range cfg.RuleProfiles {
+		if err := engine.SetProfile(name, ruleIDs); err != nil {
+			return fmt.Errorf("invalid rule profile in configuration: %w", err)
+		}
+	}
+
+	policies := []*types.UserPolicy{set.Default}
+	for _, group := range []map[string]*types.UserPolicy{set.Departments, set.Users, set.Roles} {
+		keys := make([]string, 0, len(group))
+		for key := range group {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			policies = append(policies, group[key])
+		}
+	}
+
+	var problems []string
+	for _, p := range policies {
+		selection := sanitizer.RuleSelection{Rules: p.RequiredRules}
+		if p.RuleProfile != "" {
+			selection.Profiles = []string{p.RuleProfile}
+		}
+		if err := engine.CheckSelection(selection); err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %v", p.PolicyID, err))
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("invalid policy file:\n  %s", strings.Join(problems, "\n  "))
+	}
+	return nil
+}
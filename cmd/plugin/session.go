@@ -0,0 +1,311 @@
+package main
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"os/user"
+	"time"
+
+	"github.com/enterprise/opencode-enterprise-shield/pkg/sanitizer"
+	"github.com/enterprise/opencode-enterprise-shield/pkg/session"
+	"github.com/enterprise/opencode-enterprise-shield/pkg/types"
+)
+
+// passphraseEnv is the environment variable consulted when no --passphrase
+// flag is given, so passphrases need not appear in shell history.
+const passphraseEnv = "ENTERPRISE_SHIELD_PASSPHRASE"
+
+// runSessionCommand dispatches the "session" subcommands.
+func runSessionCommand(args []string) error {
+	if len(args) < 1 {
+		return fmt.Errorf("usage: enterprise-shield session <list|show|forget|stats|export|import> [arguments]")
+	}
+
+	switch args[0] {
+	case "list":
+		return runSessionList(args[1:])
+	case "show":
+		return runSessionShow(args[1:])
+	case "forget":
+		return runSessionForget(args[1:])
+	case "stats":
+		return runSessionStats(args[1:])
+	case "export":
+		return runSessionExport(args[1:])
+	case "import":
+		return runSessionImport(args[1:])
+	default:
+		return fmt.Errorf("unknown session command %q", args[0])
+	}
+}
+
+// sessionSummary is the list view of a session.
+type sessionSummary struct {
+	SessionID      string    `json:"sessionId"`
+	UserID         string    `json:"userId"`
+	Scope          string    `json:"scope,omitempty"`
+	Department     string    `json:"department,omitempty"`
+	CreatedAt      time.Time `json:"createdAt"`
+	ExpiresAt      time.Time `json:"expiresAt"`
+	LastAccessedAt time.Time `json:"lastAccessedAt"`
+	RequestCount   int       `json:"requestCount"`
+	Mappings       int       `json:"mappings"`
+}
+
+// sessionDetail is the show view of a session.
+type sessionDetail struct {
+	sessionSummary
+	Revealed bool              `json:"revealed"`
+	Aliases  map[string]string `json:"aliases"` // Alias -> original (redacted unless revealed)
+}
+
+// summarize builds the list view of a session.
+func summarize(sess *types.Session) sessionSummary {
+	snapshot := sess.Clone()
+	return sessionSummary{
+		SessionID:      snapshot.SessionID,
+		UserID:         snapshot.UserID,
+		Scope:          snapshot.Scope,
+		Department:     snapshot.Department,
+		CreatedAt:      snapshot.CreatedAt,
+		ExpiresAt:      snapshot.ExpiresAt,
+		LastAccessedAt: snapshot.LastAccessedAt,
+		RequestCount:   snapshot.RequestCount,
+		Mappings:       len(snapshot.Mappings),
+	}
+}
+
+// runSessionList lists live sessions, optionally for a single user.
+func runSessionList(args []string) error {
+	flags := flag.NewFlagSet("session list", flag.ContinueOnError)
+	userID := flags.String("user", "", "only list sessions for this user")
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+
+	plugin, err := NewPlugin()
+	if err != nil {
+		return err
+	}
+	defer plugin.Close()
+
+	summaries := make([]sessionSummary, 0)
+	for _, sess := range plugin.hook.Shield().ListSessions() {
+		if *userID != "" && sess.UserID != *userID {
+			continue
+		}
+		summaries = append(summaries, summarize(sess))
+	}
+
+	printJSON(summaries)
+	return nil
+}
+
+// runSessionShow prints a session's aliases. Originals are redacted unless
+// --reveal is given.
+func runSessionShow(args []string) error {
+	flags := flag.NewFlagSet("session show", flag.ContinueOnError)
+	reveal := flags.Bool("reveal", false, "show full original values instead of redacted ones")
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return fmt.Errorf("usage: enterprise-shield session show <sessionID> [--reveal]")
+	}
+
+	plugin, err := NewPlugin()
+	if err != nil {
+		return err
+	}
+	defer plugin.Close()
+
+	sess, ok := plugin.hook.Shield().GetSession(flags.Arg(0))
+	if !ok {
+		return fmt.Errorf("session %s not found or expired", flags.Arg(0))
+	}
+
+	detail := sessionDetail{
+		sessionSummary: summarize(sess),
+		Revealed:       *reveal,
+		Aliases:        sess.ReverseMappingsSnapshot(),
+	}
+	if !*reveal {
+		for alias, original := range detail.Aliases {
+			detail.Aliases[alias] = sanitizer.RedactValue(original)
+		}
+	}
+
+	printJSON(detail)
+	return nil
+}
+
+// runSessionForget revokes a single alias from a session.
+func runSessionForget(args []string) error {
+	if len(args) != 2 {
+		return fmt.Errorf("usage: enterprise-shield session forget <sessionID> <alias>")
+	}
+
+	plugin, err := NewPlugin()
+	if err != nil {
+		return err
+	}
+	defer plugin.Close()
+
+	if !plugin.hook.Shield().ForgetMapping(args[0], args[1]) {
+		return fmt.Errorf("alias %s not found in session %s", args[1], args[0])
+	}
+
+	fmt.Printf("Forgot %s in session %s\n", args[1], args[0])
+	return nil
+}
+
+// runSessionStats prints session statistics.
+func runSessionStats(args []string) error {
+	if len(args) != 0 {
+		return fmt.Errorf("usage: enterprise-shield session stats")
+	}
+
+	plugin, err := NewPlugin()
+	if err != nil {
+		return err
+	}
+	defer plugin.Close()
+
+	printJSON(plugin.hook.Shield().GetStats())
+	return nil
+}
+
+// runSessionExport writes an encrypted, signed bundle of a session.
+func runSessionExport(args []string) error {
+	flags := flag.NewFlagSet("session export", flag.ContinueOnError)
+	out := flags.String("out", "", "write the bundle to this file instead of stdout")
+	passphrase := flags.String("passphrase", "", "passphrase to encrypt the bundle (or $"+passphraseEnv+")")
+	exportedBy := flags.String("user", os.Getenv("USER"), "identity recorded as the exporter")
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return fmt.Errorf("usage: enterprise-shield session export <sessionID> [--out file] [--passphrase p]")
+	}
+
+	plugin, err := NewPlugin()
+	if err != nil {
+		return err
+	}
+	defer plugin.Close()
+
+	bundle, err := plugin.hook.Shield().ExportSession(flags.Arg(0), *exportedBy, passphraseOrEnv(*passphrase))
+	if err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(bundle, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if *out == "" {
+		fmt.Println(string(data))
+	} else if err := os.WriteFile(*out, data, 0600); err != nil {
+		return fmt.Errorf("failed to write bundle: %w", err)
+	}
+
+	fmt.Fprintf(os.Stderr, "Signed with key %s\n", base64.StdEncoding.EncodeToString(bundle.SignerKey))
+	return nil
+}
+
+// runSessionImport loads a bundle as a new session for the importing user.
+func runSessionImport(args []string) error {
+	flags := flag.NewFlagSet("session import", flag.ContinueOnError)
+	passphrase := flags.String("passphrase", "", "passphrase to decrypt the bundle (or $"+passphraseEnv+")")
+	trust := flags.String("trust", "", "base64 public key the bundle must be signed with, if none are configured")
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return fmt.Errorf("usage: enterprise-shield session import <file> [--passphrase p] [--trust key]")
+	}
+	userID, err := currentUser()
+	if err != nil {
+		return err
+	}
+
+	data, err := os.ReadFile(flags.Arg(0))
+	if err != nil {
+		return fmt.Errorf("failed to read bundle: %w", err)
+	}
+	var bundle session.Bundle
+	if err := json.Unmarshal(data, &bundle); err != nil {
+		return fmt.Errorf("failed to parse bundle: %w", err)
+	}
+
+	var trusted []ed25519.PublicKey
+	if *trust != "" {
+		key, err := base64.StdEncoding.DecodeString(*trust)
+		if err != nil || len(key) != ed25519.PublicKeySize {
+			return fmt.Errorf("invalid --trust key")
+		}
+		trusted = append(trusted, ed25519.PublicKey(key))
+	}
+
+	plugin, err := NewPlugin()
+	if err != nil {
+		return err
+	}
+	defer plugin.Close()
+
+	sess, err := plugin.hook.Shield().ImportSession(&bundle, passphraseOrEnv(*passphrase), userID, trusted...)
+	if err != nil {
+		return err
+	}
+
+	printJSON(map[string]interface{}{
+		"sessionId":  sess.SessionID,
+		"importedBy": sess.UserID,
+		"sourceId":   bundle.SessionID,
+		"exportedBy": bundle.ExportedBy,
+		"mappings":   sess.MappingCount(),
+	})
+	return nil
+}
+
+// parseFlags parses flags that may appear before or after positional
+// arguments, which the standard flag package does not allow. Positional
+// arguments are then available through flags.Args as usual.
+func parseFlags(flags *flag.FlagSet, args []string) error {
+	var positional []string
+	for {
+		if err := flags.Parse(args); err != nil {
+			return err
+		}
+		args = flags.Args()
+		if len(args) == 0 {
+			break
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+	return flags.Parse(append([]string{"--"}, positional...))
+}
+
+// passphraseOrEnv returns the flag value, falling back to the environment.
+func passphraseOrEnv(passphrase string) string {
+	if passphrase != "" {
+		return passphrase
+	}
+	return os.Getenv(passphraseEnv)
+}
+
+// currentUser returns the name of the account running the command, which
+// commands that check access use as the caller's identity.
+func currentUser() (string, error) {
+	account, err := user.Current()
+	if err != nil || account.Username == "" {
+		return "", fmt.Errorf("failed to determine the current user: %v", err)
+	}
+	return account.Username, nil
+}
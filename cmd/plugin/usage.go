@@ -0,0 +1,57 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// runUsageCommand dispatches the "usage" subcommands.
+func runUsageCommand(args []string) error {
+	if len(args) < 1 {
+		return fmt.Errorf("usage: enterprise-shield usage <show|report> [arguments]")
+	}
+
+	switch args[0] {
+	case "show":
+		return runUsageShow(args[1:])
+	case "report":
+		return runUsageReport(args[1:])
+	default:
+		return fmt.Errorf("unknown usage command %q", args[0])
+	}
+}
+
+// runUsageShow prints token usage for the current day and month.
+func runUsageShow(args []string) error {
+	if len(args) != 0 {
+		return fmt.Errorf("usage: enterprise-shield usage show")
+	}
+
+	plugin, err := NewPlugin()
+	if err != nil {
+		return err
+	}
+	defer plugin.Close()
+
+	printJSON(plugin.hook.Shield().GetStats().TokenUsage)
+	return nil
+}
+
+// runUsageReport counts the output tokens a provider reported for a response.
+func runUsageReport(args []string) error {
+	if len(args) != 2 {
+		return fmt.Errorf("usage: enterprise-shield usage report <sessionID> <outputTokens>")
+	}
+	tokens, err := strconv.ParseInt(args[1], 10, 64)
+	if err != nil || tokens < 0 {
+		return fmt.Errorf("invalid token count %q", args[1])
+	}
+
+	plugin, err := NewPlugin()
+	if err != nil {
+		return err
+	}
+	defer plugin.Close()
+
+	return plugin.hook.OnUsage(args[0], tokens)
+}
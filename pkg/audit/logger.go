@@ -11,6 +11,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/enterprise/opencode-enterprise-shield/pkg/paths"
 	"github.com/enterprise/opencode-enterprise-shield/pkg/types"
 	"github.com/google/uuid"
 )
@@ -28,13 +29,9 @@ type Logger struct {
 
 // NewLogger creates a new audit logger.
 func NewLogger(logPath string, signEntries bool, retentionDays int) (*Logger, error) {
-	// Expand home directory
-	if logPath[0] == '~' {
-		home, err := os.UserHomeDir()
-		if err != nil {
-			return nil, fmt.Errorf("failed to get home directory: %w", err)
-		}
-		logPath = filepath.Join(home, logPath[1:])
+	logPath, err := paths.ExpandHome(logPath)
+	if err != nil {
+		return nil, err
 	}
 
 	// Create log directory
@@ -135,6 +132,7 @@ func (l *Logger) CreateEntry(
 	return types.AuditEntry{
 		EntryID:          "audit_" + uuid.New().String()[:12],
 		Timestamp:        time.Now().UTC(),
+		Event:            types.AuditEventRequest,
 		UserID:           userID,
 		SessionID:        sessionID,
 		Department:       department,
@@ -146,6 +144,18 @@ func (l *Logger) CreateEntry(
 	}
 }
 
+// CreateSessionEntry creates an audit entry for a session lifecycle event.
+func (l *Logger) CreateSessionEntry(event types.AuditEvent, session *types.Session) types.AuditEntry {
+	return types.AuditEntry{
+		EntryID:    "audit_" + uuid.New().String()[:12],
+		Timestamp:  time.Now().UTC(),
+		Event:      event,
+		UserID:     session.UserID,
+		SessionID:  session.SessionID,
+		Department: session.Department,
+	}
+}
+
 // RotateFile rotates the log file (creates new file for new day).
 func (l *Logger) RotateFile() error {
 	l.mu.Lock()
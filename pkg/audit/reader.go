@@ -0,0 +1,89 @@
+// Package audit provides reading of audit log files.
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/enterprise/opencode-enterprise-shield/pkg/paths"
+	"github.com/enterprise/opencode-enterprise-shield/pkg/types"
+)
+
+// maxEntrySize bounds the length of one audit log line.
+const maxEntrySize = 4 * 1024 * 1024
+
+// ReadEntries reads the audit entries logged in [from, to) from the daily
+// log files in logPath, in file order. A zero from or to leaves that end of
+// the range open.
+func ReadEntries(logPath string, from, to time.Time) ([]types.AuditEntry, error) {
+	logPath, err := paths.ExpandHome(logPath)
+	if err != nil {
+		return nil, err
+	}
+
+	files, err := filepath.Glob(filepath.Join(logPath, "audit_*.jsonl"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list audit logs: %w", err)
+	}
+	sort.Strings(files)
+
+	var entries []types.AuditEntry
+	for _, file := range files {
+		// Skip files for days wholly outside the range
+		day, err := time.ParseInLocation("2006-01-02", strings.TrimSuffix(strings.TrimPrefix(filepath.Base(file), "audit_"), ".jsonl"), time.Local)
+		if err == nil {
+			if !to.IsZero() && !day.Before(to) {
+				continue
+			}
+			if !from.IsZero() && !day.AddDate(0, 0, 1).After(from) {
+				continue
+			}
+		}
+
+		fileEntries, err := readEntryFile(file, from, to)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, fileEntries...)
+	}
+	return entries, nil
+}
+
+// readEntryFile reads the entries in [from, to) from one log file.
+func readEntryFile(path string, from, to time.Time) ([]types.AuditEntry, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit log: %w", err)
+	}
+	defer file.Close()
+
+	var entries []types.AuditEntry
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), maxEntrySize)
+	for line := 1; scanner.Scan(); line++ {
+		if len(strings.TrimSpace(scanner.Text())) == 0 {
+			continue
+		}
+		var entry types.AuditEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return nil, fmt.Errorf("failed to parse %s line %d: %w", filepath.Base(path), line, err)
+		}
+		if !from.IsZero() && entry.Timestamp.Before(from) {
+			continue
+		}
+		if !to.IsZero() && !entry.Timestamp.Before(to) {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read audit log: %w", err)
+	}
+	return entries, nil
+}
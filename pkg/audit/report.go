@@ -0,0 +1,93 @@
+// Package audit provides compliance reports over audit log entries.
+package audit
+
+import (
+	"sort"
+
+	"github.com/enterprise/opencode-enterprise-shield/pkg/types"
+)
+
+// FrameworkReport counts audited findings by compliance framework and
+// control.
+type FrameworkReport struct {
+	Entries    int                `json:"entries"`  // Entries with findings
+	Findings   int                `json:"findings"` // Every finding, tagged or not
+	Untagged   int                `json:"untagged"` // Findings no framework control relates to
+	Frameworks []FrameworkSummary `json:"frameworks,omitempty"`
+}
+
+// FrameworkSummary counts findings related to one framework's controls.
+type FrameworkSummary struct {
+	Framework string           `json:"framework"`
+	Findings  int              `json:"findings"`
+	Blocked   int              `json:"blocked"` // Findings in blocked requests
+	Controls  []ControlSummary `json:"controls"`
+}
+
+// ControlSummary counts findings related to one control.
+type ControlSummary struct {
+	Control  string         `json:"control"`
+	Findings int            `json:"findings"`
+	Blocked  int            `json:"blocked"`
+	Types    map[string]int `json:"types"` // Findings by finding type
+}
+
+// ReportByFramework groups the findings recorded in audit entries by the
+// framework controls they were tagged with when the request was processed.
+// A finding related to several frameworks counts toward each of them.
+func ReportByFramework(entries []types.AuditEntry) *FrameworkReport {
+	report := &FrameworkReport{}
+	frameworks := make(map[string]*FrameworkSummary)
+	controls := make(map[types.ComplianceControl]*ControlSummary)
+
+	for _, entry := range entries {
+		if len(entry.Violations) == 0 {
+			continue
+		}
+		report.Entries++
+		blocked := entry.Action == types.ActionBlock
+
+		for _, violation := range entry.Violations {
+			report.Findings++
+			if len(violation.Controls) == 0 {
+				report.Untagged++
+				continue
+			}
+			for _, tag := range violation.Controls {
+				framework := frameworks[tag.Framework]
+				if framework == nil {
+					framework = &FrameworkSummary{Framework: tag.Framework}
+					frameworks[tag.Framework] = framework
+				}
+				control := controls[tag]
+				if control == nil {
+					control = &ControlSummary{Control: tag.Control, Types: make(map[string]int)}
+					controls[tag] = control
+				}
+
+				framework.Findings++
+				control.Findings++
+				control.Types[violation.Type]++
+				if blocked {
+					framework.Blocked++
+					control.Blocked++
+				}
+			}
+		}
+	}
+
+	for tag, control := range controls {
+		framework := frameworks[tag.Framework]
+		framework.Controls = append(framework.Controls, *control)
+	}
+	for _, framework := range frameworks {
+		sort.Slice(framework.Controls, func(i, j int) bool {
+			return framework.Controls[i].Control < framework.Controls[j].Control
+		})
+		report.Frameworks = append(report.Frameworks, *framework)
+	}
+	sort.Slice(report.Frameworks, func(i, j int) bool {
+		return report.Frameworks[i].Framework < report.Frameworks[j].Framework
+	})
+	return report
+}
@@ -0,0 +1,44 @@
+package audit
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/enterprise/opencode-enterprise-shield/pkg/types"
+)
+
+func TestReportByFramework(t *testing.T) {
+	pan := types.ComplianceControl{Framework: "PCI-DSS", Control: "3.4"}
+	credentials := types.ComplianceControl{Framework: "PCI-DSS", Control: "8.2.1"}
+	access := types.ComplianceControl{Framework: "SOC 2", Control: "CC6.1"}
+
+	entries := []types.AuditEntry{
+		{Action: types.ActionBlock, Violations: []types.Violation{
+			{Type: "CREDIT_CARD", Controls: []types.ComplianceControl{pan}},
+			{Type: "API_KEY", Controls: []types.ComplianceControl{credentials, access}},
+		}},
+		{Action: types.ActionAllowWithWarning, Violations: []types.Violation{
+			{Type: "PASSWORD", Controls: []types.ComplianceControl{credentials, access}},
+			{Type: "SERVER"},
+		}},
+		{Action: types.ActionAllow},
+	}
+
+	want := &FrameworkReport{
+		Entries:  2,
+		Findings: 4,
+		Untagged: 1,
+		Frameworks: []FrameworkSummary{
+			{Framework: "PCI-DSS", Findings: 3, Blocked: 2, Controls: []ControlSummary{
+				{Control: "3.4", Findings: 1, Blocked: 1, Types: map[string]int{"CREDIT_CARD": 1}},
+				{Control: "8.2.1", Findings: 2, Blocked: 1, Types: map[string]int{"API_KEY": 1, "PASSWORD": 1}},
+			}},
+			{Framework: "SOC 2", Findings: 2, Blocked: 1, Controls: []ControlSummary{
+				{Control: "CC6.1", Findings: 2, Blocked: 1, Types: map[string]int{"API_KEY": 1, "PASSWORD": 1}},
+			}},
+		},
+	}
+	if got := ReportByFramework(entries); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %+v, got %+v", want, got)
+	}
+}
@@ -0,0 +1,126 @@
+// Package compliance provides card brand identification and test card data.
+package compliance
+
+import (
+	"strconv"
+
+	"github.com/enterprise/opencode-enterprise-shield/pkg/types"
+)
+
+// cardBrand is a card brand's issuer identification number (IIN) ranges
+// and the number lengths it issues.
+type cardBrand struct {
+	name    string
+	ranges  []iinRange
+	lengths []int
+}
+
+// iinRange is an inclusive range of number prefixes of one length, such as
+// 2221-2720.
+type iinRange struct {
+	low, high int
+}
+
+// cardBrands are checked in order; brands with longer prefixes come before
+// those whose shorter prefixes would also match.
+var cardBrands = []cardBrand{
+	{"maestro", []iinRange{{5018, 5018}, {5020, 5020}, {5038, 5038}, {5893, 5893}, {6304, 6304}, {6759, 6759}, {6761, 6763}}, []int{12, 13, 14, 15, 16, 17, 18, 19}},
+	{"discover", []iinRange{{6011, 6011}, {644, 649}, {65, 65}}, []int{16, 17, 18, 19}},
+	{"unionpay", []iinRange{{62, 62}}, []int{16, 17, 18, 19}},
+	{"jcb", []iinRange{{3528, 3589}}, []int{16, 17, 18, 19}},
+	{"diners", []iinRange{{3095, 3095}, {300, 305}, {36, 36}, {38, 39}}, []int{14, 15, 16, 17, 18, 19}},
+	{"amex", []iinRange{{34, 34}, {37, 37}}, []int{15}},
+	{"mastercard", []iinRange{{2221, 2720}, {51, 55}}, []int{16}},
+	{"visa", []iinRange{{4, 4}}, []int{13, 16, 19}},
+}
+
+// matches reports whether digits has one of the brand's prefixes and
+// lengths.
+func (b cardBrand) matches(digits string) bool {
+	lengthOK := false
+	for _, length := range b.lengths {
+		if len(digits) == length {
+			lengthOK = true
+			break
+		}
+	}
+	if !lengthOK {
+		return false
+	}
+
+	for _, r := range b.ranges {
+		width := len(strconv.Itoa(r.low))
+		prefix, err := strconv.Atoi(digits[:width])
+		if err == nil && prefix >= r.low && prefix <= r.high {
+			return true
+		}
+	}
+	return false
+}
+
+// testCardNumbers are card numbers published by card networks and payment
+// gateways for testing. They pass every check but belong to no one.
+var testCardNumbers = map[string]bool{
+	"4111111111111111":    true,
+	"4242424242424242":    true,
+	"4012888888881881":    true,
+	"4222222222222":       true,
+	"4000056655665556":    true,
+	"4000002760003184":    true,
+	"4000000000000002":    true,
+	"4917610000000000003": true,
+	"5555555555554444":    true,
+	"5105105105105100":    true,
+	"5200828282828210":    true,
+	"5500000000000004":    true,
+	"2223003122003222":    true,
+	"378282246310005":     true,
+	"371449635398431":     true,
+	"378734493671000":     true,
+	"340000000000009":     true,
+	"6011111111111117":    true,
+	"6011000990139424":    true,
+	"3530111333300000":    true,
+	"3566002020360505":    true,
+	"30569309025904":      true,
+	"38520000023237":      true,
+	"36227206271667":      true,
+	"6200000000000005":    true,
+	"6205500000000000004": true,
+	"6759649826438453":    true,
+}
+
+// GetCardType returns the card brand of a number: visa, mastercard, amex,
+// discover, jcb, diners, unionpay or maestro. A number whose length the
+// brand does not issue is "unknown", like one with an unknown prefix.
+func GetCardType(number string) string {
+	cleaned := digitsOnly(number)
+	if len(cleaned) < 12 {
+		return "unknown"
+	}
+
+	for _, brand := range cardBrands {
+		if brand.matches(cleaned) {
+			return brand.name
+		}
+	}
+	return "unknown"
+}
+
+// IsTestCard reports whether a number is a well-known test card number.
+func IsTestCard(number string) bool {
+	return testCardNumbers[digitsOnly(number)]
+}
+
+// ValidateCreditCard validates a card number: it must pass the Luhn check
+// and have the prefix and length of a known brand.
+func ValidateCreditCard(number string) bool {
+	return ValidateLuhn(number) && GetCardType(number) != "unknown"
+}
+
+// annotateCard adds a card's brand and whether it is a test card to a
+// credit card finding.
+func annotateCard(value string, violation *types.Violation) {
+	violation.CardBrand = GetCardType(value)
+	violation.TestData = IsTestCard(value)
+}
@@ -0,0 +1,58 @@
+package compliance
+
+import (
+	"testing"
+
+	"github.com/enterprise/opencode-enterprise-shield/pkg/types"
+)
+
+func TestDetector_CardBrands(t *testing.T) {
+	detector := NewDetector(true)
+
+	// The order and ref numbers pass the Luhn check but match no brand's length
+	content := "test 4111 1111 1111 1111, real 4532015112830366, amex 3782 822463 10005, " +
+		"order 453201511283034, ref 123456789015"
+	result := detector.Scan(content)
+
+	type card struct {
+		value    string
+		brand    string
+		testData bool
+	}
+	want := []card{
+		{"4111 1111 1111 1111", "visa", true},
+		{"4532015112830366", "visa", false},
+		{"3782 822463 10005", "amex", true},
+	}
+	if len(result.Violations) != len(want) {
+		t.Fatalf("Expected %d card findings, got %+v", len(want), result.Violations)
+	}
+	for i, v := range result.Violations {
+		got := card{content[v.Position : v.Position+v.Length], v.CardBrand, v.TestData}
+		if got != want[i] {
+			t.Errorf("Finding %d: expected %+v, got %+v", i, want[i], got)
+		}
+		if v.Action != types.FindingBlock {
+			t.Errorf("Expected card numbers to block by default, got %s for %s", v.Action, got.value)
+		}
+	}
+
+	// Test cards can be redacted while real ones still block
+	if err := detector.SetTestAction("credit_card", types.FindingRedact); err != nil {
+		t.Fatalf("SetTestAction failed: %v", err)
+	}
+	result = detector.Scan(content)
+	actions := []types.FindingAction{types.FindingRedact, types.FindingBlock, types.FindingRedact}
+	for i, v := range result.Violations {
+		if v.Action != actions[i] {
+			t.Errorf("Finding %d: expected action %s, got %s", i, actions[i], v.Action)
+		}
+	}
+	if !result.ShouldBlock {
+		t.Error("Expected the real card number to block")
+	}
+
+	if err := detector.SetTestAction("api_key", types.FindingAlias); err == nil {
+		t.Error("Expected aliasing secrets to be rejected")
+	}
+}
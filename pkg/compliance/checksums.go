@@ -0,0 +1,268 @@
+// Package compliance provides checksum validation for international
+// identifiers.
+package compliance
+
+import (
+	"strings"
+)
+
+// digitsOnly returns the digits of s, dropping separators.
+func digitsOnly(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, s)
+}
+
+// repeatedDigit reports whether digits is one digit repeated, as in
+// placeholders such as 000000000.
+func repeatedDigit(digits string) bool {
+	return digits != "" && strings.Count(digits, digits[:1]) == len(digits)
+}
+
+// ibanLengths is the IBAN length of each country using IBANs.
+var ibanLengths = map[string]int{
+	"AD": 24, "AE": 23, "AL": 28, "AT": 20, "AZ": 28, "BA": 20, "BE": 16, "BG": 22,
+	"BH": 22, "BR": 29, "CH": 21, "CR": 22, "CY": 28, "CZ": 24, "DE": 22, "DK": 18,
+	"DO": 28, "EE": 20, "EG": 29, "ES": 24, "FI": 18, "FO": 18, "FR": 27, "GB": 22,
+	"GE": 22, "GI": 23, "GL": 18, "GR": 27, "GT": 28, "HR": 21, "HU": 28, "IE": 22,
+	"IL": 23, "IS": 26, "IT": 27, "JO": 30, "KW": 30, "KZ": 20, "LB": 28, "LI": 21,
+	"LT": 20, "LU": 20, "LV": 21, "MC": 27, "MD": 24, "ME": 22, "MK": 19, "MR": 27,
+	"MT": 31, "MU": 30, "NL": 18, "NO": 15, "PK": 24, "PL": 28, "PS": 29, "PT": 25,
+	"QA": 29, "RO": 24, "RS": 22, "SA": 24, "SE": 24, "SI": 19, "SK": 24, "SM": 27,
+	"TN": 24, "TR": 26, "UA": 29, "VG": 24, "XK": 20,
+}
+
+// ValidateIBAN checks an IBAN's length for its country and its ISO 7064
+// mod-97 check digits.
+func ValidateIBAN(iban string) bool {
+	iban = strings.ToUpper(strings.ReplaceAll(iban, " ", ""))
+	if len(iban) < 4 || ibanLengths[iban[:2]] != len(iban) {
+		return false
+	}
+
+	// Move the country and check digits to the end, letters become 10-35
+	remainder := 0
+	for _, c := range iban[4:] + iban[:4] {
+		switch {
+		case c >= '0' && c <= '9':
+			remainder = (remainder*10 + int(c-'0')) % 97
+		case c >= 'A' && c <= 'Z':
+			remainder = (remainder*100 + int(c-'A') + 10) % 97
+		default:
+			return false
+		}
+	}
+	return remainder == 1
+}
+
+// ValidateNINO checks a UK National Insurance number's prefix, which may
+// not be one of the never-issued or administrative prefixes.
+func ValidateNINO(nino string) bool {
+	nino = strings.ToUpper(strings.ReplaceAll(nino, " ", ""))
+	if len(nino) != 9 {
+		return false
+	}
+	switch nino[:2] {
+	case "BG", "GB", "KN", "NK", "NT", "TN", "ZZ":
+		return false
+	}
+	return !repeatedDigit(nino[2:8])
+}
+
+// ValidateNHSNumber checks an NHS number's mod-11 check digit.
+func ValidateNHSNumber(number string) bool {
+	digits := digitsOnly(number)
+	if len(digits) != 10 || repeatedDigit(digits) {
+		return false
+	}
+
+	sum := 0
+	for i := 0; i < 9; i++ {
+		sum += int(digits[i]-'0') * (10 - i)
+	}
+	check := 11 - sum%11
+	if check == 11 {
+		check = 0
+	}
+	return check != 10 && check == int(digits[9]-'0')
+}
+
+// ValidateSIN checks a Canadian Social Insurance Number with the Luhn
+// algorithm. Numbers starting with 0 or 8 are never issued.
+func ValidateSIN(sin string) bool {
+	digits := digitsOnly(sin)
+	if len(digits) != 9 || digits[0] == '0' || digits[0] == '8' {
+		return false
+	}
+	return luhnValid(digits)
+}
+
+// ValidateSteuerID checks a German tax identification number: one digit of
+// the first ten appears two or three times and no other repeats, and the
+// last digit is the ISO 7064 MOD 11,10 check digit.
+func ValidateSteuerID(id string) bool {
+	digits := digitsOnly(id)
+	if len(digits) != 11 || digits[0] == '0' {
+		return false
+	}
+
+	var counts [10]int
+	for i := 0; i < 10; i++ {
+		counts[digits[i]-'0']++
+	}
+	repeated := 0
+	for _, count := range counts {
+		switch {
+		case count == 2 || count == 3:
+			repeated++
+		case count > 3:
+			return false
+		}
+	}
+	if repeated != 1 {
+		return false
+	}
+
+	product := 10
+	for i := 0; i < 10; i++ {
+		sum := (int(digits[i]-'0') + product) % 10
+		if sum == 0 {
+			sum = 10
+		}
+		product = sum * 2 % 11
+	}
+	check := 11 - product
+	if check == 10 {
+		check = 0
+	}
+	return check == int(digits[10]-'0')
+}
+
+// ValidateNIR checks a French social security number (NIR): its key is 97
+// minus the first 13 digits modulo 97. Corsican departments 2A and 2B count
+// as 19 and 18.
+func ValidateNIR(nir string) bool {
+	nir = strings.ToUpper(strings.ReplaceAll(nir, " ", ""))
+	if len(nir) != 15 {
+		return false
+	}
+	nir = strings.Replace(nir, "2A", "19", 1)
+	nir = strings.Replace(nir, "2B", "18", 1)
+	if digitsOnly(nir) != nir {
+		return false
+	}
+
+	var number uint64
+	for i := 0; i < 13; i++ {
+		number = number*10 + uint64(nir[i]-'0')
+	}
+	key := uint64(nir[13]-'0')*10 + uint64(nir[14]-'0')
+	return 97-number%97 == key
+}
+
+// Verhoeff tables: multiplication in the dihedral group D5 and the
+// position-dependent permutation.
+var (
+	verhoeffMultiply = [10][10]int{
+		{0, 1, 2, 3, 4, 5, 6, 7, 8, 9},
+		{1, 2, 3, 4, 0, 6, 7, 8, 9, 5},
+		{2, 3, 4, 0, 1, 7, 8, 9, 5, 6},
+		{3, 4, 0, 1, 2, 8, 9, 5, 6, 7},
+		{4, 0, 1, 2, 3, 9, 5, 6, 7, 8},
+		{5, 9, 8, 7, 6, 0, 4, 3, 2, 1},
+		{6, 5, 9, 8, 7, 1, 0, 4, 3, 2},
+		{7, 6, 5, 9, 8, 2, 1, 0, 4, 3},
+		{8, 7, 6, 5, 9, 3, 2, 1, 0, 4},
+		{9, 8, 7, 6, 5, 4, 3, 2, 1, 0},
+	}
+	verhoeffPermute = [8][10]int{
+		{0, 1, 2, 3, 4, 5, 6, 7, 8, 9},
+		{1, 5, 7, 6, 2, 8, 3, 0, 9, 4},
+		{5, 8, 0, 3, 7, 9, 6, 1, 4, 2},
+		{8, 9, 1, 6, 0, 4, 3, 5, 2, 7},
+		{9, 4, 5, 3, 1, 2, 6, 8, 7, 0},
+		{4, 2, 8, 6, 5, 7, 3, 9, 0, 1},
+		{2, 7, 9, 3, 8, 0, 6, 4, 1, 5},
+		{7, 0, 4, 6, 9, 1, 3, 2, 5, 8},
+	}
+)
+
+// ValidateAadhaar checks an Indian Aadhaar number: 12 digits, not starting
+// with 0 or 1, with a Verhoeff check digit.
+func ValidateAadhaar(number string) bool {
+	digits := digitsOnly(number)
+	if len(digits) != 12 || digits[0] < '2' || repeatedDigit(digits) {
+		return false
+	}
+
+	check := 0
+	for i := 0; i < len(digits); i++ {
+		digit := int(digits[len(digits)-1-i] - '0')
+		check = verhoeffMultiply[check][verhoeffPermute[i%8][digit]]
+	}
+	return check == 0
+}
+
+// tfnWeights are the Australian TFN check weights for 9 and 8 digit numbers.
+var tfnWeights = map[int][]int{
+	9: {1, 4, 3, 7, 5, 8, 6, 9, 10},
+	8: {10, 7, 8, 4, 6, 3, 5, 1},
+}
+
+// ValidateTFN checks an Australian Tax File Number: the weighted sum of its
+// digits is divisible by 11.
+func ValidateTFN(tfn string) bool {
+	digits := digitsOnly(tfn)
+	weights, ok := tfnWeights[len(digits)]
+	if !ok || repeatedDigit(digits) {
+		return false
+	}
+
+	sum := 0
+	for i, weight := range weights {
+		sum += int(digits[i]-'0') * weight
+	}
+	return sum%11 == 0
+}
+
+// ValidateE164 checks the length of an E.164 phone number: 8 to 15 digits,
+// or exactly 11 for the North American (+1) and Russian (+7) plans, whose
+// area codes never start with 0 or 1.
+func ValidateE164(number string) bool {
+	digits := digitsOnly(number)
+	if len(digits) < 8 || len(digits) > 15 || digits[0] == '0' {
+		return false
+	}
+	if digits[0] == '1' || digits[0] == '7' {
+		return len(digits) == 11 && digits[1] >= '2'
+	}
+	return !repeatedDigit(digits[1:])
+}
+
+// ValidateNPI checks a US National Provider Identifier: 10 digits starting
+// with 1 or 2, whose Luhn check digit is computed with the 80840 prefix.
+func ValidateNPI(npi string) bool {
+	digits := digitsOnly(npi)
+	if len(digits) != 10 || (digits[0] != '1' && digits[0] != '2') {
+		return false
+	}
+	return luhnValid("80840" + digits)
+}
+
+// ValidateDEA checks a DEA registration number: two letters and seven
+// digits, where the last digit of d1+d3+d5 + 2*(d2+d4+d6) is d7.
+func ValidateDEA(number string) bool {
+	if len(number) != 9 {
+		return false
+	}
+	digits := number[2:]
+	if digitsOnly(digits) != digits {
+		return false
+	}
+	d := func(i int) int { return int(digits[i] - '0') }
+	sum := d(0) + d(2) + d(4) + 2*(d(1)+d(3)+d(5))
+	return sum%10 == d(6)
+}
@@ -0,0 +1,168 @@
+package compliance
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func TestInternationalValidators(t *testing.T) {
+	tests := []struct {
+		name     string
+		validate func(string) bool
+		valid    []string
+		invalid  []string
+	}{
+		{
+			"IBAN", ValidateIBAN,
+			[]string{"DE89 3704 0044 0532 0130 00", "GB82 WEST 1234 5698 7654 32", "DE89370400440532013000"},
+			[]string{"DE89 3704 0044 0532 0130 01", "XX89 3704 0044 0532 0130 00", "GB82 WEST 1234 5698 7654 3"},
+		},
+		{
+			"NINO", ValidateNINO,
+			[]string{"AB 12 34 56 C", "JG103759A"},
+			[]string{"GB123456A", "AB000000A", "AB12345C"},
+		},
+		{
+			"NHS number", ValidateNHSNumber,
+			[]string{"943 476 5919", "943-476-5919"},
+			[]string{"9434765918", "0000000000", "943476591"},
+		},
+		{
+			"SIN", ValidateSIN,
+			[]string{"130 692 544", "130-692-544"},
+			[]string{"130 692 545", "046 454 286", "000 000 000"}, // Sample numbers start with 0
+		},
+		{
+			"Steuer-ID", ValidateSteuerID,
+			[]string{"86095742719", "65 929 970 489"},
+			[]string{"86095742718", "01234567890", "12345678901"},
+		},
+		{
+			"NIR", ValidateNIR,
+			[]string{"2 55 08 14 168 025 38", "185052A12345633"},
+			[]string{"2 55 08 14 168 025 39", "185052C12345633"},
+		},
+		{
+			"Aadhaar", ValidateAadhaar,
+			[]string{"2345 6789 0124", "234567890124"},
+			[]string{"2345 6789 0125", "1234 5678 9012", "2222 2222 2222"},
+		},
+		{
+			"TFN", ValidateTFN,
+			[]string{"123 456 782", "12345677"},
+			[]string{"123 456 789", "1234567", "000 000 000"},
+		},
+		{
+			"E.164", ValidateE164,
+			[]string{"+14155552671", "+442071838750", "+919876543210"},
+			[]string{"+1415555267", "+10155552671", "+1234567"},
+		},
+	}
+
+	for _, test := range tests {
+		for _, value := range test.valid {
+			if !test.validate(value) {
+				t.Errorf("%s: expected %q to be valid", test.name, value)
+			}
+		}
+		for _, value := range test.invalid {
+			if test.validate(value) {
+				t.Errorf("%s: expected %q to be invalid", test.name, value)
+			}
+		}
+	}
+}
+
+func TestDetector_RegionProfiles(t *testing.T) {
+	content := "Employee NINO AB 12 34 56 C, NHS 943 476 5919, IBAN GB82 WEST 1234 5698 7654 32, call +442071838750"
+
+	detector := NewDetector(true)
+	if result := detector.Scan(content); result.HasViolations {
+		t.Fatalf("Expected international detectors to be off by default, got %+v", result.Violations)
+	}
+
+	if err := detector.EnableProfile("uk"); err != nil {
+		t.Fatalf("EnableProfile failed: %v", err)
+	}
+	result := detector.Scan(content)
+	var found []string
+	for _, v := range result.Violations {
+		found = append(found, v.Type)
+	}
+	if got, want := strings.Join(found, ","), "UK_NINO,NHS_NUMBER,IBAN,PHONE"; got != want {
+		t.Errorf("Expected findings %s, got %s", want, got)
+	}
+	if !result.ShouldBlock {
+		t.Error("Expected national identifiers to block")
+	}
+
+	// Other regions' detectors stay off
+	if result := detector.Scan("Aadhaar 2345 6789 0124"); result.HasViolations {
+		t.Errorf("Expected Aadhaar detection to need the in profile, got %+v", result.Violations)
+	}
+
+	if err := detector.EnableProfile("atlantis"); err == nil {
+		t.Error("Expected an unknown profile to be rejected")
+	}
+}
+
+func TestDetector_LabelledNationalNumbers(t *testing.T) {
+	detector := NewDetector(true)
+	if err := detector.EnableProfile("international"); err != nil {
+		t.Fatalf("EnableProfile failed: %v", err)
+	}
+
+	tests := []struct {
+		content, want, value string
+	}{
+		{"Patient NHS no. 943 476 5919", "NHS_NUMBER", "943 476 5919"},
+		{"SIN: 130-692-544", "CA_SIN", "130-692-544"},
+		{"social insurance number 130 692 544", "CA_SIN", "130 692 544"},
+		{"Aadhaar 2345 6789 0124", "AADHAAR", "2345 6789 0124"},
+		{"TFN 123 456 782", "AU_TFN", "123 456 782"},
+		{"tax file number: 12345677", "AU_TFN", "12345677"},
+	}
+	for _, test := range tests {
+		result := detector.Scan(test.content)
+		if len(result.Violations) != 1 || result.Violations[0].Type != test.want {
+			t.Errorf("Scan(%q): expected %s, got %+v", test.content, test.want, result.Violations)
+			continue
+		}
+
+		// Only the number is reported, so redaction keeps the label
+		v := result.Violations[0]
+		if got := test.content[v.Position : v.Position+v.Length]; got != test.value {
+			t.Errorf("Scan(%q): expected %q reported, got %q", test.content, test.value, got)
+		}
+	}
+}
+
+func TestDetector_NationalNumbersNeedLabels(t *testing.T) {
+	detector := NewDetector(true)
+	if err := detector.EnableProfile("international"); err != nil {
+		t.Fatalf("EnableProfile failed: %v", err)
+	}
+	labelledTypes := map[string]bool{"NHS_NUMBER": true, "CA_SIN": true, "AADHAAR": true, "AU_TFN": true}
+
+	// Numbers that pass the checksums, and random digit runs of the same
+	// shapes, are not reported without a label
+	contents := []string{"order 943 476 5919", "ref 130-692-544", "build 2345 6789 0124", "invoice 123 456 782"}
+	random := rand.New(rand.NewSource(1))
+	for i := 0; i < 2000; i++ {
+		contents = append(contents, fmt.Sprintf("row %03d %03d %04d, id %03d-%03d-%03d, txn %04d %04d %04d, ref %08d",
+			random.Intn(1000), random.Intn(1000), random.Intn(10000),
+			random.Intn(1000), random.Intn(1000), random.Intn(1000),
+			2000+random.Intn(8000), random.Intn(10000), random.Intn(10000),
+			random.Intn(100000000)))
+	}
+
+	for _, content := range contents {
+		for _, v := range detector.Scan(content).Violations {
+			if labelledTypes[v.Type] {
+				t.Fatalf("Scan(%q): unexpected %s finding without a label", content, v.Type)
+			}
+		}
+	}
+}
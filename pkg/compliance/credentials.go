@@ -0,0 +1,186 @@
+// Package compliance provides the cloud and SaaS credential detector pack.
+package compliance
+
+import (
+	"hash/crc32"
+	"regexp"
+	"strings"
+
+	"github.com/enterprise/opencode-enterprise-shield/pkg/types"
+)
+
+// CredentialPackVersion identifies the credential detector pack. Bump it
+// whenever a detector in the pack is added or changed.
+const CredentialPackVersion = "2026.10.1"
+
+// credential describes one detector in the pack.
+type credential struct {
+	id, name    string
+	regex       string
+	group       bool              // Report the first capture group, not the whole match
+	validator   func(string) bool // Optional format check
+	description string
+}
+
+// credentialPack lists the credential detectors, in registration order.
+var credentialPack = []credential{
+	{
+		id: "github_app_token", name: "GitHub App or Refresh Token",
+		regex:       `\bgh[usr]_[A-Za-z0-9]{36}\b`,
+		validator:   ValidateGitHubToken,
+		description: "Detects GitHub user-to-server, server-to-server and refresh tokens",
+	},
+	{
+		id: "github_fine_grained", name: "GitHub Fine-Grained Token",
+		regex:       `\bgithub_pat_[A-Za-z0-9]{22}_[A-Za-z0-9]{59}\b`,
+		description: "Detects GitHub fine-grained personal access tokens",
+	},
+	{
+		id: "gitlab_token", name: "GitLab Personal Access Token",
+		regex:       `\bglpat-[A-Za-z0-9_-]{20}\b`,
+		description: "Detects GitLab personal access tokens",
+	},
+	{
+		id: "gcp_service_account", name: "GCP Service Account Key",
+		regex:       `"private_key_id"\s*:\s*"([0-9a-f]{40})"`,
+		group:       true,
+		description: "Detects private key IDs in GCP service account key files",
+	},
+	{
+		id: "google_api_key", name: "Google API Key",
+		regex:       `\bAIza[0-9A-Za-z_-]{35}\b`,
+		description: "Detects Google Cloud and Firebase API keys",
+	},
+	{
+		id: "azure_client_secret", name: "Azure AD Client Secret",
+		regex:       `(?:^|[^A-Za-z0-9_~.-])([A-Za-z0-9_~.-]{3}\dQ~[A-Za-z0-9_~.-]{31,34})(?:$|[^A-Za-z0-9_~.-])`,
+		group:       true,
+		description: "Detects Azure AD (Entra ID) application client secrets",
+	},
+	{
+		id: "stripe_key", name: "Stripe Live Key",
+		regex:       `\b[sr]k_live_[0-9A-Za-z]{24,99}\b`,
+		description: "Detects Stripe live secret and restricted keys",
+	},
+	{
+		id: "twilio_key", name: "Twilio API Key",
+		regex:       `\bSK[0-9a-f]{32}\b`,
+		description: "Detects Twilio API key SIDs",
+	},
+	{
+		id: "sendgrid_key", name: "SendGrid API Key",
+		regex:       `\bSG\.[A-Za-z0-9_-]{22}\.[A-Za-z0-9_-]{43}\b`,
+		description: "Detects SendGrid API keys",
+	},
+	{
+		id: "npm_token", name: "npm Access Token",
+		regex:       `\bnpm_[A-Za-z0-9]{36}\b`,
+		validator:   ValidateGitHubToken, // npm adopted GitHub's token format
+		description: "Detects npm access tokens",
+	},
+	{
+		id: "pypi_token", name: "PyPI API Token",
+		regex:       `\bpypi-AgEIcHlwaS5vcmc[A-Za-z0-9_-]{50,}`,
+		description: "Detects PyPI upload tokens",
+	},
+	{
+		id: "slack_webhook", name: "Slack Webhook URL",
+		regex:       `https://hooks\.slack\.com/services/T[A-Z0-9]{8,12}/B[A-Z0-9]{8,12}/[A-Za-z0-9]{24}`,
+		description: "Detects Slack incoming webhook URLs",
+	},
+	{
+		id: "datadog_key", name: "Datadog API or Application Key",
+		regex:       `(?i)(?:datadog|\bdd)[_-]?(?:api|app|application)[_-]?key['"]?\s*[:=]\s*['"]?([0-9a-f]{32}(?:[0-9a-f]{8})?)\b`,
+		group:       true,
+		description: "Detects Datadog API and application keys",
+	},
+	{
+		id: "heroku_key", name: "Heroku API Key",
+		regex:       `(?i)heroku[_-]?(?:api[_-]?)?(?:key|token)['"]?\s*[:=]\s*['"]?([0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12})\b`,
+		group:       true,
+		description: "Detects Heroku API keys",
+	},
+}
+
+// loadCredentialPack registers the credential detectors.
+func (d *Detector) loadCredentialPack() {
+	for _, c := range credentialPack {
+		regex := regexp.MustCompile(c.regex)
+		pattern := &Pattern{
+			Name:        c.name,
+			Type:        "API_KEY",
+			Regex:       regex,
+			Severity:    types.SeverityCritical,
+			Enabled:     true,
+			Priority:    PriorityVendor,
+			Validator:   c.validator,
+			Description: c.description,
+		}
+		if c.group {
+			pattern.Finder = groupFinder(regex)
+		}
+		if c.id == "gcp_service_account" {
+			pattern.Finder = serviceAccountFinder(pattern.Finder)
+		}
+		d.AddPattern(c.id, pattern)
+	}
+}
+
+// groupFinder finds the first capture group of each match of regex.
+func groupFinder(regex *regexp.Regexp) func(string) [][]int {
+	return func(content string) [][]int {
+		var locations [][]int
+		for _, m := range regex.FindAllStringSubmatchIndex(content, -1) {
+			if len(m) >= 4 && m[2] >= 0 {
+				locations = append(locations, []int{m[2], m[3]})
+			}
+		}
+		return locations
+	}
+}
+
+// serviceAccountType marks a GCP service account key file.
+var serviceAccountType = regexp.MustCompile(`"type"\s*:\s*"service_account"`)
+
+// serviceAccountFinder only reports private key IDs in content that is a
+// service account key file, since other Google key files use the same field.
+func serviceAccountFinder(find func(string) [][]int) func(string) [][]int {
+	return func(content string) [][]int {
+		if !serviceAccountType.MatchString(content) {
+			return nil
+		}
+		return find(content)
+	}
+}
+
+// base62Alphabet is the digit order GitHub uses to encode token checksums.
+const base62Alphabet = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+
+// ValidateGitHubToken checks the checksum of a GitHub token in the format
+// introduced in 2021: a prefix such as ghp_, 30 random characters and the
+// CRC32 of those characters in 6 base62 digits. npm access tokens (npm_)
+// use the same format.
+func ValidateGitHubToken(token string) bool {
+	i := strings.IndexByte(token, '_')
+	if i < 0 || len(token)-i-1 != 36 {
+		return false
+	}
+	random, checksum := token[i+1:i+31], token[i+31:]
+	return encodeBase62(crc32.ChecksumIEEE([]byte(random)), 6) == checksum
+}
+
+// encodeBase62 encodes n in base62, left-padded with zeros to width digits.
+func encodeBase62(n uint32, width int) string {
+	digits := make([]byte, 0, width)
+	for n > 0 {
+		digits = append(digits, base62Alphabet[n%62])
+		n /= 62
+	}
+	for len(digits) < width {
+		digits = append(digits, '0')
+	}
+	for i, j := 0, len(digits)-1; i < j; i, j = i+1, j-1 {
+		digits[i], digits[j] = digits[j], digits[i]
+	}
+	return string(digits)
+}
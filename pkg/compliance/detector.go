@@ -2,184 +2,243 @@
 package compliance
 
 import (
+	"fmt"
 	"regexp"
+	"sort"
 	"strings"
 
+	"github.com/enterprise/opencode-enterprise-shield/pkg/decode"
+	"github.com/enterprise/opencode-enterprise-shield/pkg/normalize"
 	"github.com/enterprise/opencode-enterprise-shield/pkg/types"
 )
 
 // Detector scans content for compliance violations (PII, secrets, etc.).
 type Detector struct {
-	patterns      map[string]*Pattern
+	patterns      []*Pattern // In registration order
 	blockCritical bool
+	decodeLimits  decode.Limits
+
+	controls map[string][]types.ComplianceControl // Finding type -> controls of enabled frameworks
 }
 
 // Pattern represents a compliance detection pattern.
 type Pattern struct {
+	ID          string // Set by AddPattern
 	Name        string
 	Type        string
 	Regex       *regexp.Regexp
+	Finder      func(string) [][]int // Optional; finds matches instead of Regex
 	Severity    types.Severity
 	Enabled     bool
-	Validator   func(string) bool // Optional validation function
+	Priority    int                            // Higher wins when matches overlap
+	Action      types.FindingAction            // Empty: block if critical and blocking is on, else warn
+	TestAction  types.FindingAction            // For findings flagged as test data; empty uses Action
+	Validator   func(string) bool              // Optional validation function
+	Annotate    func(string, *types.Violation) // Optional; adds details such as a card's brand
 	Description string
+	Remediation string // Hint shown when the finding produces a warning
 }
 
+// Pattern priorities. When matches of several patterns overlap, only the
+// match of the most specific pattern is reported.
+const (
+	PriorityHeuristic  = 5  // Statistical guesses, e.g. high-entropy strings
+	PriorityGeneric    = 10 // Keyword-anchored catch-alls, e.g. api_key=...
+	PriorityStructured = 20 // Values with a fixed shape, e.g. SSNs and JWTs
+	PriorityVendor     = 30 // Vendor-specific formats, e.g. AKIA... keys
+)
+
 // NewDetector creates a new compliance detector.
 func NewDetector(blockCritical bool) *Detector {
 	d := &Detector{
-		patterns:      make(map[string]*Pattern),
+		patterns:      make([]*Pattern, 0),
 		blockCritical: blockCritical,
+		decodeLimits:  decode.DefaultLimits(),
 	}
 	d.loadDefaultPatterns()
+	d.loadCredentialPack()
+	d.loadInternationalPatterns()
+	d.loadPHIPatterns()
+	scanner, _ := newEntropyScanner(DefaultEntropyConfig()) // Defaults always compile
+	d.addEntropyPatterns(scanner)
 	return d
 }
 
 // loadDefaultPatterns loads the default compliance detection patterns.
 func (d *Detector) loadDefaultPatterns() {
 	// SSN (Social Security Number)
-	d.patterns["ssn"] = &Pattern{
+	d.AddPattern("ssn", &Pattern{
 		Name:        "Social Security Number",
 		Type:        "SSN",
 		Regex:       regexp.MustCompile(`\b\d{3}-\d{2}-\d{4}\b`),
 		Severity:    types.SeverityCritical,
 		Enabled:     true,
+		Priority:    PriorityStructured,
 		Description: "Detects US Social Security Numbers",
-	}
+	})
 
 	// Credit Card Numbers (with Luhn validation)
-	d.patterns["credit_card"] = &Pattern{
+	d.AddPattern("credit_card", &Pattern{
 		Name:        "Credit Card Number",
 		Type:        "CREDIT_CARD",
-		Regex:       regexp.MustCompile(`\b\d{4}[- ]?\d{4}[- ]?\d{4}[- ]?\d{4}\b`),
+		Regex:       regexp.MustCompile(`\b(?:\d{4}[- ]?\d{4}[- ]?\d{4}[- ]?\d{4}|\d{4}[- ]?\d{6}[- ]?\d{4,5}|\d{12,19})\b`),
 		Severity:    types.SeverityCritical,
 		Enabled:     true,
-		Validator:   ValidateLuhn,
-		Description: "Detects credit card numbers with Luhn validation",
-	}
+		Priority:    PriorityStructured,
+		Validator:   ValidateCreditCard,
+		Annotate:    annotateCard,
+		Description: "Detects credit card numbers of known brands with Luhn validation",
+	})
 
 	// AWS Access Key
-	d.patterns["aws_key"] = &Pattern{
+	d.AddPattern("aws_key", &Pattern{
 		Name:        "AWS Access Key",
 		Type:        "API_KEY",
 		Regex:       regexp.MustCompile(`\bAKIA[0-9A-Z]{16}\b`),
 		Severity:    types.SeverityCritical,
 		Enabled:     true,
+		Priority:    PriorityVendor,
 		Description: "Detects AWS access key IDs",
-	}
+	})
 
 	// AWS Secret Key
-	d.patterns["aws_secret"] = &Pattern{
+	d.AddPattern("aws_secret", &Pattern{
 		Name:        "AWS Secret Key",
 		Type:        "API_KEY",
 		Regex:       regexp.MustCompile(`(?i)aws.{0,20}secret.{0,20}['\"][0-9a-zA-Z/+=]{40}['\"]`),
 		Severity:    types.SeverityCritical,
 		Enabled:     true,
+		Priority:    PriorityVendor,
 		Description: "Detects AWS secret access keys",
-	}
+	})
 
 	// GitHub Token
-	d.patterns["github_token"] = &Pattern{
+	d.AddPattern("github_token", &Pattern{
 		Name:        "GitHub Token",
 		Type:        "API_KEY",
 		Regex:       regexp.MustCompile(`\bghp_[a-zA-Z0-9]{36}\b`),
 		Severity:    types.SeverityCritical,
 		Enabled:     true,
-		Description: "Detects GitHub personal access tokens",
-	}
+		Priority:    PriorityVendor,
+		Validator:   ValidateGitHubToken,
+		Description: "Detects GitHub personal access tokens with a valid checksum",
+	})
 
 	// GitHub OAuth
-	d.patterns["github_oauth"] = &Pattern{
+	d.AddPattern("github_oauth", &Pattern{
 		Name:        "GitHub OAuth Token",
 		Type:        "API_KEY",
 		Regex:       regexp.MustCompile(`\bgho_[a-zA-Z0-9]{36}\b`),
 		Severity:    types.SeverityCritical,
 		Enabled:     true,
-		Description: "Detects GitHub OAuth tokens",
-	}
+		Priority:    PriorityVendor,
+		Validator:   ValidateGitHubToken,
+		Description: "Detects GitHub OAuth tokens with a valid checksum",
+	})
 
 	// Generic API Key
-	d.patterns["generic_api_key"] = &Pattern{
+	d.AddPattern("generic_api_key", &Pattern{
 		Name:        "Generic API Key",
 		Type:        "API_KEY",
 		Regex:       regexp.MustCompile(`(?i)(api[_-]?key|apikey)\s*[:=]\s*['"]?[a-zA-Z0-9]{20,}['"]?`),
 		Severity:    types.SeverityHigh,
 		Enabled:     true,
+		Priority:    PriorityGeneric,
 		Description: "Detects generic API keys in code",
-	}
+	})
 
 	// Private Key
-	d.patterns["private_key"] = &Pattern{
+	d.AddPattern("private_key", &Pattern{
 		Name:        "Private Key",
 		Type:        "PRIVATE_KEY",
 		Regex:       regexp.MustCompile(`-----BEGIN (RSA |EC |DSA |OPENSSH )?PRIVATE KEY-----`),
 		Severity:    types.SeverityCritical,
 		Enabled:     true,
+		Priority:    PriorityVendor,
 		Description: "Detects private key headers",
-	}
+	})
 
 	// Password in string
-	d.patterns["password_string"] = &Pattern{
+	d.AddPattern("password_string", &Pattern{
 		Name:        "Password in String",
 		Type:        "PASSWORD",
 		Regex:       regexp.MustCompile(`(?i)(password|passwd|pwd)\s*[:=]\s*['"]?[^\s'"]{8,}['"]?`),
 		Severity:    types.SeverityHigh,
 		Enabled:     true,
+		Priority:    PriorityGeneric,
 		Description: "Detects passwords in code strings",
-	}
+	})
 
 	// Bearer Token
-	d.patterns["bearer_token"] = &Pattern{
+	d.AddPattern("bearer_token", &Pattern{
 		Name:        "Bearer Token",
 		Type:        "API_KEY",
 		Regex:       regexp.MustCompile(`(?i)bearer\s+[a-zA-Z0-9_-]+\.[a-zA-Z0-9_-]+\.[a-zA-Z0-9_-]+`),
 		Severity:    types.SeverityHigh,
 		Enabled:     true,
+		Priority:    PriorityStructured,
 		Description: "Detects JWT bearer tokens",
-	}
+	})
 
 	// OpenAI API Key
-	d.patterns["openai_key"] = &Pattern{
+	d.AddPattern("openai_key", &Pattern{
 		Name:        "OpenAI API Key",
 		Type:        "API_KEY",
 		Regex:       regexp.MustCompile(`\bsk-[a-zA-Z0-9]{48}\b`),
 		Severity:    types.SeverityCritical,
 		Enabled:     true,
+		Priority:    PriorityVendor,
 		Description: "Detects OpenAI API keys",
-	}
+	})
 
 	// Anthropic API Key
-	d.patterns["anthropic_key"] = &Pattern{
+	d.AddPattern("anthropic_key", &Pattern{
 		Name:        "Anthropic API Key",
 		Type:        "API_KEY",
 		Regex:       regexp.MustCompile(`\bsk-ant-[a-zA-Z0-9-]{40,}\b`),
 		Severity:    types.SeverityCritical,
 		Enabled:     true,
+		Priority:    PriorityVendor,
 		Description: "Detects Anthropic API keys",
-	}
+	})
 
 	// Slack Token
-	d.patterns["slack_token"] = &Pattern{
+	d.AddPattern("slack_token", &Pattern{
 		Name:        "Slack Token",
 		Type:        "API_KEY",
 		Regex:       regexp.MustCompile(`xox[baprs]-[0-9]{10,13}-[0-9]{10,13}-[a-zA-Z0-9]{24}`),
 		Severity:    types.SeverityCritical,
 		Enabled:     true,
+		Priority:    PriorityVendor,
 		Description: "Detects Slack API tokens",
-	}
+	})
 
 	// Azure Storage Key
-	d.patterns["azure_storage"] = &Pattern{
+	d.AddPattern("azure_storage", &Pattern{
 		Name:        "Azure Storage Key",
 		Type:        "API_KEY",
 		Regex:       regexp.MustCompile(`(?i)AccountKey=[a-zA-Z0-9+/=]{88}`),
 		Severity:    types.SeverityCritical,
 		Enabled:     true,
+		Priority:    PriorityVendor,
 		Description: "Detects Azure storage account keys",
-	}
+	})
 }
 
-// Scan scans content for compliance violations.
+// Scan scans content for compliance violations. Violations are ordered by
+// position. Where matches overlap, only the one from the pattern with the
+// highest priority is kept, then the longest, then the one from the pattern
+// registered first.
+//
+// Base64, URL and hex encoded segments are decoded and scanned too, within
+// the decode limits. Findings in decoded text are reported at the position
+// of the encoded text they came from (the whole segment for base64 and hex),
+// with the encoding recorded on the violation.
+//
+// Each layer of text is scanned in its normalized shadow (see package
+// normalize), so full-width digits, zero-width characters and look-alike
+// letters do not hide a value. Findings are reported at their position in
+// the original text.
 func (d *Detector) Scan(content string) types.ComplianceResult {
 	result := types.ComplianceResult{
 		HasViolations: false,
@@ -187,52 +246,332 @@ func (d *Detector) Scan(content string) types.ComplianceResult {
 		Violations:    make([]types.Violation, 0),
 	}
 
-	for _, pattern := range d.patterns {
-		if !pattern.Enabled {
+	warned := make(map[finding]int)
+	for _, m := range d.scanLayer(content, decode.Find(content, d.decodeLimits)) {
+		pattern := m.pattern
+
+		violation := types.Violation{
+			RuleID:        strings.ToLower(pattern.Type),
+			RuleName:      pattern.Name,
+			Type:          pattern.Type,
+			Severity:      pattern.Severity,
+			RedactedValue: redactSensitive(m.value),
+			Position:      m.start,
+			Length:        m.end - m.start,
+			Encoding:      m.encoding,
+			Normalized:    m.normalized,
+			Controls:      d.controls[pattern.Type],
+		}
+		if pattern.Annotate != nil {
+			pattern.Annotate(m.value, &violation)
+		}
+		action := d.actionFor(pattern, violation.TestData)
+		violation.Action = action
+
+		result.Violations = append(result.Violations, violation)
+		result.HasViolations = true
+
+		if action == types.FindingBlock {
+			result.ShouldBlock = true
+		} else {
+			warned[finding{pattern, action}]++
+		}
+	}
+
+	result.Warnings = findingWarnings(warned)
+
+	return result
+}
+
+// actionFor returns the action for a pattern's findings.
+func (d *Detector) actionFor(pattern *Pattern, testData bool) types.FindingAction {
+	if testData && pattern.TestAction != "" {
+		return pattern.TestAction
+	}
+	if pattern.Action != "" {
+		return pattern.Action
+	}
+	if d.blockCritical && pattern.Severity == types.SeverityCritical {
+		return types.FindingBlock
+	}
+	return types.FindingWarn
+}
+
+// finding groups non-blocking findings for warnings.
+type finding struct {
+	pattern *Pattern
+	action  types.FindingAction
+}
+
+// match is one validated pattern match in scanned content.
+type match struct {
+	pattern    *Pattern
+	order      int // Index of the pattern in registration order
+	start, end int
+
+	value      string // Matched text, decoded if found in an encoded segment and normalized
+	encoding   string // Encodings the value was found in, outermost first
+	normalized bool   // The original text differs from value other than by encoding
+}
+
+// scanLayer returns the matches in text and in the encoded segments found
+// in it, ordered by position. Matches in a segment's decoded text take the
+// span they were decoded from (see decode.Segment.Span), and replace
+// literal matches within the segment,
+// which only matched the encoded form. Matches are found in the normalized
+// shadow of text and take the span of the original text they came from.
+func (d *Detector) scanLayer(text string, segments []decode.Segment) []match {
+	shadow := normalize.New(text)
+	matches := d.findMatches(shadow.Text)
+	for i := range matches {
+		m := &matches[i]
+		m.value = shadow.Text[m.start:m.end]
+		m.start, m.end = shadow.Span(m.start, m.end)
+		m.normalized = text[m.start:m.end] != m.value
+	}
+
+	for _, segment := range segments {
+		inner := d.scanLayer(segment.Decoded, segment.Nested)
+		if len(inner) == 0 {
 			continue
 		}
 
-		matches := pattern.Regex.FindAllStringIndex(content, -1)
-		for _, match := range matches {
-			matchedValue := content[match[0]:match[1]]
+		literal := matches[:0]
+		for _, m := range matches {
+			if m.encoding != "" || m.start < segment.Start || m.end > segment.End {
+				literal = append(literal, m)
+			}
+		}
+		matches = literal
 
+		for _, m := range inner {
+			m.start, m.end = segment.Span(m.start, m.end)
+			m.encoding = decode.JoinEncodings(segment.Encoding, m.encoding)
+			matches = append(matches, m)
+		}
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].start < matches[j].start
+	})
+	return matches
+}
+
+// findMatches returns the validated matches of every enabled pattern with
+// overlaps removed, ordered by position.
+func (d *Detector) findMatches(content string) []match {
+	var candidates []match
+	for i, pattern := range d.patterns {
+		if !pattern.Enabled {
+			continue
+		}
+
+		var locations [][]int
+		if pattern.Finder != nil {
+			locations = pattern.Finder(content)
+		} else {
+			locations = pattern.Regex.FindAllStringIndex(content, -1)
+		}
+		for _, loc := range locations {
 			// Run validator if present
-			if pattern.Validator != nil && !pattern.Validator(matchedValue) {
+			if pattern.Validator != nil && !pattern.Validator(content[loc[0]:loc[1]]) {
 				continue // Failed validation, skip
 			}
+			candidates = append(candidates, match{pattern: pattern, order: i, start: loc[0], end: loc[1]})
+		}
+	}
 
-			violation := types.Violation{
-				RuleID:        strings.ToLower(pattern.Type),
-				RuleName:      pattern.Name,
-				Type:          pattern.Type,
-				Severity:      pattern.Severity,
-				RedactedValue: redactSensitive(matchedValue),
-				Position:      match[0],
-				Length:        len(matchedValue),
+	// Claim spans from the most specific match down
+	sort.SliceStable(candidates, func(i, j int) bool {
+		a, b := candidates[i], candidates[j]
+		if a.pattern.Priority != b.pattern.Priority {
+			return a.pattern.Priority > b.pattern.Priority
+		}
+		if a.end-a.start != b.end-b.start {
+			return a.end-a.start > b.end-b.start
+		}
+		if a.order != b.order {
+			return a.order < b.order
+		}
+		return a.start < b.start
+	})
+	kept := make([]match, 0, len(candidates))
+	for _, candidate := range candidates {
+		overlaps := false
+		for _, k := range kept {
+			if candidate.start < k.end && k.start < candidate.end {
+				overlaps = true
+				break
 			}
+		}
+		if !overlaps {
+			kept = append(kept, candidate)
+		}
+	}
 
-			result.Violations = append(result.Violations, violation)
-			result.HasViolations = true
+	sort.SliceStable(kept, func(i, j int) bool {
+		if kept[i].start != kept[j].start {
+			return kept[i].start < kept[j].start
+		}
+		return kept[i].order < kept[j].order
+	})
+	return kept
+}
 
-			if d.blockCritical && pattern.Severity == types.SeverityCritical {
-				result.ShouldBlock = true
-			}
+// defaultRemediations are remediation hints by violation type, for patterns
+// that do not set their own.
+var defaultRemediations = map[string]string{
+	"API_KEY":     "Replace the key with a placeholder such as $API_KEY, and rotate it if it has been shared",
+	"PASSWORD":    "Replace the password with a placeholder and load it from a secret store",
+	"PRIVATE_KEY": "Remove the key material; share only the public key if one is needed",
+	"SECRET":      "If this is a secret, replace it with a placeholder and rotate it; otherwise add its shape to the entropy allowlist",
+	"SSN":         "Replace the number with a fictitious value such as 000-00-0000",
+	"CREDIT_CARD": "Replace the number with a test card number such as 4111 1111 1111 1111",
+}
+
+// findingOutcomes describe what happened to non-blocking findings.
+var findingOutcomes = map[types.FindingAction]string{
+	types.FindingWarn:   "sent to the provider",
+	types.FindingRedact: "redacted",
+	types.FindingAlias:  "aliased",
+}
+
+// findingWarnings turns non-blocking findings, counted per pattern and
+// action, into warnings ordered by rule for stable output.
+func findingWarnings(counts map[finding]int) []types.Warning {
+	warnings := make([]types.Warning, 0, len(counts))
+	for f, count := range counts {
+		pattern := f.pattern
+		remediation := pattern.Remediation
+		if remediation == "" {
+			remediation = defaultRemediations[pattern.Type]
+		}
+		if remediation == "" {
+			remediation = "Remove the value or replace it with a placeholder before sending"
+		}
+
+		noun := "matches"
+		if count == 1 {
+			noun = "match"
 		}
+		warnings = append(warnings, types.Warning{
+			Rule:        strings.ToLower(pattern.Type),
+			Reason:      fmt.Sprintf("%s: %d %s %s", pattern.Name, count, noun, findingOutcomes[f.action]),
+			Remediation: remediation,
+		})
 	}
 
-	return result
+	sort.Slice(warnings, func(i, j int) bool {
+		if warnings[i].Rule != warnings[j].Rule {
+			return warnings[i].Rule < warnings[j].Rule
+		}
+		return warnings[i].Reason < warnings[j].Reason
+	})
+	if len(warnings) == 0 {
+		return nil
+	}
+	return warnings
+}
+
+// SetDecodeLimits bounds the decoding of encoded segments before scanning.
+func (d *Detector) SetDecodeLimits(limits decode.Limits) error {
+	limits, err := limits.WithDefaults()
+	if err != nil {
+		return err
+	}
+	d.decodeLimits = limits
+	return nil
 }
 
 // EnablePattern enables or disables a specific pattern.
 func (d *Detector) EnablePattern(name string, enabled bool) {
-	if pattern, ok := d.patterns[name]; ok {
+	if pattern := d.pattern(name); pattern != nil {
 		pattern.Enabled = enabled
 	}
 }
 
-// AddPattern adds a custom pattern to the detector.
+// AddPattern adds a custom pattern to the detector. A pattern added under
+// the name of an existing one replaces it and keeps its place in the order.
 func (d *Detector) AddPattern(name string, pattern *Pattern) {
-	d.patterns[name] = pattern
+	pattern.ID = name
+	for i, existing := range d.patterns {
+		if existing.ID == name {
+			d.patterns[i] = pattern
+			return
+		}
+	}
+	d.patterns = append(d.patterns, pattern)
+}
+
+// secretTypes are finding types that must never be aliased, since aliases
+// keep the original value in the session to restore it in responses.
+var secretTypes = map[string]bool{
+	"API_KEY":     true,
+	"PASSWORD":    true,
+	"PRIVATE_KEY": true,
+	"SECRET":      true,
+}
+
+// SetAction sets the action for findings of a pattern, named by its ID
+// (e.g. "aws_key") or by its finding type (e.g. "api_key" for every API key
+// pattern). An empty action restores the default.
+func (d *Detector) SetAction(name string, action types.FindingAction) error {
+	matched, err := d.actionTargets(name, action)
+	if err != nil {
+		return err
+	}
+	for _, pattern := range matched {
+		pattern.Action = action
+	}
+	return nil
+}
+
+// SetTestAction sets the action for findings of a pattern that are flagged
+// as test data, such as well-known test card numbers, so test data can be
+// redacted where real data is blocked. An empty action uses the pattern's
+// action for test data too.
+func (d *Detector) SetTestAction(name string, action types.FindingAction) error {
+	matched, err := d.actionTargets(name, action)
+	if err != nil {
+		return err
+	}
+	for _, pattern := range matched {
+		pattern.TestAction = action
+	}
+	return nil
+}
+
+// actionTargets returns the patterns an action set by name applies to.
+func (d *Detector) actionTargets(name string, action types.FindingAction) ([]*Pattern, error) {
+	switch action {
+	case "", types.FindingBlock, types.FindingRedact, types.FindingAlias, types.FindingWarn:
+	default:
+		return nil, fmt.Errorf("unknown action %q for detector %s", action, name)
+	}
+
+	var matched []*Pattern
+	for _, pattern := range d.patterns {
+		if pattern.ID == name || strings.ToLower(pattern.Type) == name {
+			if action == types.FindingAlias && secretTypes[pattern.Type] {
+				return nil, fmt.Errorf("detector %s finds secrets, which cannot be aliased; use redact", name)
+			}
+			matched = append(matched, pattern)
+		}
+	}
+	if len(matched) == 0 {
+		return nil, fmt.Errorf("unknown detector %q", name)
+	}
+	return matched, nil
+}
+
+// pattern returns the pattern with the given name, or nil.
+func (d *Detector) pattern(name string) *Pattern {
+	for _, pattern := range d.patterns {
+		if pattern.ID == name {
+			return pattern
+		}
+	}
+	return nil
 }
 
 // redactSensitive creates a redacted version of sensitive data for logging.
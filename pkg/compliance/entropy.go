@@ -0,0 +1,264 @@
+// Package compliance provides detection of high-entropy secrets.
+package compliance
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+
+	"github.com/enterprise/opencode-enterprise-shield/pkg/types"
+)
+
+// EntropyConfig configures detection of random-looking strings, such as
+// secrets without a known vendor format. Zero fields take their defaults.
+type EntropyConfig struct {
+	MinLength        int      `yaml:"minLength,omitempty"`        // Shortest string considered
+	Base64Threshold  float64  `yaml:"base64Threshold,omitempty"`  // Bits per character
+	HexThreshold     float64  `yaml:"hexThreshold,omitempty"`     // Bits per character
+	ContextReduction float64  `yaml:"contextReduction,omitempty"` // Lowers thresholds near keywords
+	ContextWindow    int      `yaml:"contextWindow,omitempty"`    // Characters searched before a string
+	Keywords         []string `yaml:"keywords,omitempty"`         // Replace the default keywords
+
+	// Allowlist holds regular expressions for strings never reported, in
+	// addition to the built-in ones for UUIDs, git SHAs and lockfile hashes.
+	// Each is matched against the string and against the whole word around it.
+	Allowlist []string `yaml:"allowlist,omitempty"`
+}
+
+// DefaultEntropyConfig returns the default entropy detection settings.
+func DefaultEntropyConfig() EntropyConfig {
+	return EntropyConfig{
+		MinLength:        20,
+		Base64Threshold:  4.5,
+		HexThreshold:     3.5,
+		ContextReduction: 0.8,
+		ContextWindow:    32,
+		Keywords:         []string{"secret", "token", "key", "passwd", "password", "pwd", "credential", "auth"},
+	}
+}
+
+// defaultEntropyAllowlist matches strings that are random-looking but not
+// secrets.
+var defaultEntropyAllowlist = []string{
+	`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`, // UUID
+	`^[0-9a-f]{40}$`, // Git commit SHA
+	`^sha(1|256|384|512)-[A-Za-z0-9+/]+={0,2}$`, // Subresource integrity (package-lock.json, yarn.lock, pnpm-lock.yaml)
+	`^h1:[A-Za-z0-9+/]+={0,2}$`,                 // go.sum
+	`^sha256:[0-9a-f]{64}$`,                     // Pipfile.lock, poetry.lock, image digests
+}
+
+// entropyCandidate matches strings of base64 and hex characters. Trailing
+// padding is kept; other "=" separate a name from its value.
+var entropyCandidate = regexp.MustCompile(`[A-Za-z0-9+/_-]+={0,2}`)
+
+// wordDelimiters end the word around a candidate string.
+const wordDelimiters = " \t\r\n\"'`,;()[]{}<>"
+
+// entropyScanner finds high-entropy strings.
+type entropyScanner struct {
+	config    EntropyConfig
+	allowlist []*regexp.Regexp
+}
+
+// newEntropyScanner fills in defaults and compiles the allowlist.
+func newEntropyScanner(config EntropyConfig) (*entropyScanner, error) {
+	defaults := DefaultEntropyConfig()
+	if config.MinLength <= 0 {
+		config.MinLength = defaults.MinLength
+	}
+	if config.Base64Threshold <= 0 {
+		config.Base64Threshold = defaults.Base64Threshold
+	}
+	if config.HexThreshold <= 0 {
+		config.HexThreshold = defaults.HexThreshold
+	}
+	if config.ContextReduction <= 0 {
+		config.ContextReduction = defaults.ContextReduction
+	}
+	if config.ContextWindow <= 0 {
+		config.ContextWindow = defaults.ContextWindow
+	}
+	if len(config.Keywords) == 0 {
+		config.Keywords = defaults.Keywords
+	}
+
+	scanner := &entropyScanner{config: config}
+	for _, expr := range append(append([]string(nil), defaultEntropyAllowlist...), config.Allowlist...) {
+		compiled, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, fmt.Errorf("failed to compile entropy allowlist entry %q: %w", expr, err)
+		}
+		scanner.allowlist = append(scanner.allowlist, compiled)
+	}
+	return scanner, nil
+}
+
+// find returns the locations of high-entropy strings. With contextual set,
+// it returns those next to a keyword that pass the lowered threshold, which
+// includes those that pass the full one; otherwise, those that pass the full
+// threshold wherever they are.
+func (s *entropyScanner) find(content string, contextual bool) [][]int {
+	var locations [][]int
+	for _, loc := range entropyCandidate.FindAllStringIndex(content, -1) {
+		candidate := content[loc[0]:loc[1]]
+		if len(candidate) < s.config.MinLength || s.allowed(content, loc[0], loc[1]) {
+			continue
+		}
+		// Slashes are rare in random base64 but common in paths and URLs
+		if strings.Count(candidate, "/")*10 > len(candidate) {
+			continue
+		}
+
+		var threshold float64
+		switch {
+		case isHexString(candidate):
+			threshold = s.config.HexThreshold
+		case isBase64Like(candidate):
+			threshold = s.config.Base64Threshold
+		default:
+			continue
+		}
+
+		entropy := ShannonEntropy(candidate)
+		if contextual {
+			if entropy >= threshold-s.config.ContextReduction && s.nearKeyword(content, loc[0]) {
+				locations = append(locations, loc)
+			}
+		} else if entropy >= threshold {
+			locations = append(locations, loc)
+		}
+	}
+	return locations
+}
+
+// nearKeyword reports whether a context keyword appears shortly before start
+// on the same line.
+func (s *entropyScanner) nearKeyword(content string, start int) bool {
+	before := content[max(0, start-s.config.ContextWindow):start]
+	if i := strings.LastIndexByte(before, '\n'); i >= 0 {
+		before = before[i+1:]
+	}
+	before = strings.ToLower(before)
+	for _, keyword := range s.config.Keywords {
+		if strings.Contains(before, strings.ToLower(keyword)) {
+			return true
+		}
+	}
+	return false
+}
+
+// allowed reports whether the string at [start, end), or the word around
+// it, matches the allowlist.
+func (s *entropyScanner) allowed(content string, start, end int) bool {
+	wordStart := strings.LastIndexAny(content[:start], wordDelimiters) + 1
+	wordEnd := end
+	if i := strings.IndexAny(content[end:], wordDelimiters); i >= 0 {
+		wordEnd += i
+	} else {
+		wordEnd = len(content)
+	}
+
+	candidate, word := content[start:end], content[wordStart:wordEnd]
+	for _, allow := range s.allowlist {
+		if allow.MatchString(candidate) || allow.MatchString(word) {
+			return true
+		}
+	}
+	return false
+}
+
+// ShannonEntropy returns the Shannon entropy of s in bits per character.
+func ShannonEntropy(s string) float64 {
+	if s == "" {
+		return 0
+	}
+	var counts [256]int
+	for i := 0; i < len(s); i++ {
+		counts[s[i]]++
+	}
+	var entropy float64
+	n := float64(len(s))
+	for _, count := range counts {
+		if count > 0 {
+			p := float64(count) / n
+			entropy -= p * math.Log2(p)
+		}
+	}
+	return entropy
+}
+
+// isHexString reports whether s is hex digits mixing letters and digits.
+func isHexString(s string) bool {
+	var letters, digits bool
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case c >= '0' && c <= '9':
+			digits = true
+		case (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F'):
+			letters = true
+		default:
+			return false
+		}
+	}
+	return letters && digits
+}
+
+// isBase64Like reports whether s mixes letters and digits, as random tokens
+// do and identifiers and words rarely do.
+func isBase64Like(s string) bool {
+	return strings.ContainsAny(s, "0123456789") &&
+		strings.IndexFunc(s, func(r rune) bool { return (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') }) >= 0
+}
+
+// SetEntropy replaces the entropy detection settings.
+func (d *Detector) SetEntropy(config EntropyConfig) error {
+	scanner, err := newEntropyScanner(config)
+	if err != nil {
+		return err
+	}
+	d.addEntropyPatterns(scanner)
+	return nil
+}
+
+// addEntropyPatterns registers the two entropy patterns: random-looking
+// strings next to a keyword such as "secret", where a lower threshold
+// applies, and strings random enough to report anywhere. The first gets
+// high severity and wins where both match, the second medium, so neither
+// blocks by default. Replacing the patterns keeps how they were configured.
+func (d *Detector) addEntropyPatterns(scanner *entropyScanner) {
+	d.AddPattern("high_entropy_secret", d.carryOver("high_entropy_secret", &Pattern{
+		Name:        "High-Entropy Secret",
+		Type:        "SECRET",
+		Severity:    types.SeverityHigh,
+		Enabled:     true,
+		Priority:    PriorityHeuristic + 1, // A keyword makes it more likely a secret than high_entropy_string
+		Finder:      func(content string) [][]int { return scanner.find(content, true) },
+		Description: "Detects random-looking strings next to words such as secret or token",
+	}))
+
+	d.AddPattern("high_entropy_string", d.carryOver("high_entropy_string", &Pattern{
+		Name:        "High-Entropy String",
+		Type:        "SECRET",
+		Severity:    types.SeverityMedium,
+		Enabled:     true,
+		Priority:    PriorityHeuristic,
+		Finder:      func(content string) [][]int { return scanner.find(content, false) },
+		Description: "Detects random-looking strings that may be secrets",
+	}))
+}
+
+// carryOver copies what may have been configured on the existing pattern
+// named name, such as by a profile, a framework or SetAction, to the
+// pattern replacing it: whether it is enabled, its severity and its
+// actions. Framework control tags are kept by finding type and need no
+// copying.
+func (d *Detector) carryOver(name string, pattern *Pattern) *Pattern {
+	if existing := d.pattern(name); existing != nil {
+		pattern.Enabled = existing.Enabled
+		pattern.Severity = existing.Severity
+		pattern.Action = existing.Action
+		pattern.TestAction = existing.TestAction
+	}
+	return pattern
+}
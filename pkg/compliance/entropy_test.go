@@ -0,0 +1,141 @@
+package compliance
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/enterprise/opencode-enterprise-shield/pkg/types"
+)
+
+func TestShannonEntropy(t *testing.T) {
+	tests := []struct {
+		value string
+		want  float64
+	}{
+		{"", 0},
+		{"aaaa", 0},
+		{"abab", 1},
+		{"0123456789abcdef", 4},
+	}
+
+	for _, test := range tests {
+		if got := ShannonEntropy(test.value); math.Abs(got-test.want) > 1e-9 {
+			t.Errorf("ShannonEntropy(%q) = %f, want %f", test.value, got, test.want)
+		}
+	}
+}
+
+func TestDetector_HighEntropy(t *testing.T) {
+	detector := NewDetector(true)
+
+	tests := []struct {
+		name    string
+		content string
+		want    []types.Violation
+	}{
+		{
+			"random token anywhere",
+			"build k3J9sL2mQ8xV5nB7wR4tY6uI1oP0aZ",
+			[]types.Violation{
+				{RuleID: "secret", RuleName: "High-Entropy String", Type: "SECRET", Severity: types.SeverityMedium, RedactedValue: "k3J9****P0aZ", Position: 6, Length: 30, Action: types.FindingWarn},
+			},
+		},
+		{
+			"random token next to a keyword",
+			"INTERNAL_TOKEN=k3J9sL2mQ8xV5nB7wR4tY6uI1oP0aZ",
+			[]types.Violation{
+				{RuleID: "secret", RuleName: "High-Entropy Secret", Type: "SECRET", Severity: types.SeverityHigh, RedactedValue: "k3J9****P0aZ", Position: 15, Length: 30, Action: types.FindingWarn},
+			},
+		},
+		{
+			"very random value next to a keyword",
+			"secret = Zx8Qw2Ld7Vn4Kp9Rt3Hy6Bm1Jc5Gf0Ts",
+			[]types.Violation{
+				{RuleID: "secret", RuleName: "High-Entropy Secret", Type: "SECRET", Severity: types.SeverityHigh, RedactedValue: "Zx8Q****f0Ts", Position: 9, Length: 32, Action: types.FindingWarn},
+			},
+		},
+		{
+			"less random value next to a keyword",
+			"secret=d41d8cd98f00b204e9800998ecf8427e",
+			[]types.Violation{
+				{RuleID: "secret", RuleName: "High-Entropy Secret", Type: "SECRET", Severity: types.SeverityHigh, RedactedValue: "d41d****427e", Position: 7, Length: 32, Action: types.FindingWarn},
+			},
+		},
+		{"same value without a keyword", "md5 d41d8cd98f00b204e9800998ecf8427e", nil},
+		{"keyword on the previous line", "secret:\nd41d8cd98f00b204e9800998ecf8427e", nil},
+		{"uuid", "id 550e8400-e29b-41d4-a716-446655440000", nil},
+		{"git sha", "commit 7f3a9c2e1b8d4f6a0c5e9b2d7a1f3c8e4b6d0a2f", nil},
+		{"npm integrity", `"integrity": "sha512-Qk1Yp9X2bT3uV4wZ5aB6cD7eF8gH9iJ0kL1mN2oP3qR4sT5uV6wX7yZ8aB9cD0eF1gH2iJ3kL4mN5oP6qR7sT8u=="`, nil},
+		{"go.sum", "github.com/google/uuid v1.6.0 h1:NIvaJDMOsjHA8n1jAhLSgzrAzy1Hgr3hetguSLEN3R0=", nil},
+		{"identifier", "key: configurationmanager1", nil},
+		{"path", "open /usr/local/lib/python3.11/site-packages", nil},
+	}
+
+	for _, test := range tests {
+		result := detector.Scan(test.content)
+		want := test.want
+		if want == nil {
+			want = []types.Violation{}
+		}
+		if !reflect.DeepEqual(result.Violations, want) {
+			t.Errorf("%s: expected %+v, got %+v", test.name, want, result.Violations)
+		}
+	}
+}
+
+func TestDetector_SetEntropy(t *testing.T) {
+	detector := NewDetector(true)
+	content := "build id Bld7Xq2Lm9Rt4Vw8Yz3Kp6Nc1"
+
+	if result := detector.Scan(content); len(result.Violations) != 1 {
+		t.Fatalf("Expected the build ID to be found, got %+v", result.Violations)
+	}
+
+	if err := detector.SetEntropy(EntropyConfig{Allowlist: []string{`^Bld[A-Za-z0-9]+$`}}); err != nil {
+		t.Fatalf("SetEntropy failed: %v", err)
+	}
+	if result := detector.Scan(content); len(result.Violations) != 0 {
+		t.Errorf("Expected the allowlisted build ID to be ignored, got %+v", result.Violations)
+	}
+
+	if err := detector.SetEntropy(EntropyConfig{Allowlist: []string{`(`}}); err == nil {
+		t.Error("Expected an invalid allowlist entry to be rejected")
+	}
+
+	// Replacing the settings keeps a disabled pattern disabled
+	detector.EnablePattern("high_entropy_string", false)
+	if err := detector.SetEntropy(EntropyConfig{}); err != nil {
+		t.Fatalf("SetEntropy failed: %v", err)
+	}
+	if result := detector.Scan(content); len(result.Violations) != 0 {
+		t.Errorf("Expected the disabled pattern to stay disabled, got %+v", result.Violations)
+	}
+}
+
+func TestDetector_SetEntropyKeepsConfiguration(t *testing.T) {
+	detector := NewDetector(true)
+	if _, err := detector.EnableFramework("soc2"); err != nil {
+		t.Fatalf("EnableFramework failed: %v", err)
+	}
+	if err := detector.SetAction("high_entropy_secret", types.FindingRedact); err != nil {
+		t.Fatalf("SetAction failed: %v", err)
+	}
+	detector.pattern("high_entropy_secret").Severity = types.SeverityCritical
+
+	// Settings applied before SetEntropy survive it
+	if err := detector.SetEntropy(EntropyConfig{}); err != nil {
+		t.Fatalf("SetEntropy failed: %v", err)
+	}
+	result := detector.Scan("secret = Zx8Qw2Ld7Vn4Kp9Rt3Hy6Bm1Jc5Gf0Ts")
+	if len(result.Violations) != 1 {
+		t.Fatalf("Expected one finding, got %+v", result.Violations)
+	}
+	v := result.Violations[0]
+	if v.RuleName != "High-Entropy Secret" || v.Severity != types.SeverityCritical || v.Action != types.FindingRedact {
+		t.Errorf("Expected a critical finding to redact, got %s %s %s", v.RuleName, v.Severity, v.Action)
+	}
+	if len(v.Controls) == 0 || v.Controls[0].Framework != "SOC 2" {
+		t.Errorf("Expected the SOC 2 controls to be kept, got %+v", v.Controls)
+	}
+}
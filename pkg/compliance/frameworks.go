@@ -0,0 +1,182 @@
+// Package compliance provides compliance framework profiles.
+package compliance
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/enterprise/opencode-enterprise-shield/pkg/types"
+)
+
+// Framework bundles what a compliance framework calls for: the detectors to
+// run and their severities, the sanitization rules to apply, the minimum
+// audit log retention, and the control each kind of finding relates to.
+type Framework struct {
+	Name              string                    // As cited in controls, e.g. "PCI-DSS"
+	DetectorProfiles  []string                  // Detector profiles to enable
+	Severities        map[string]types.Severity // By detector ID or finding type
+	SanitizationRules []string                  // Sanitizer rule IDs to apply
+	MinRetentionDays  int                       // Minimum audit log retention
+	Controls          map[string]string         // Finding type -> control
+}
+
+// Finding types grouped by what frameworks care about.
+var (
+	secretFindings = []string{"API_KEY", "PASSWORD", "PRIVATE_KEY", "SECRET"}
+	healthFindings = []string{"NPI", "DEA_NUMBER", "MRN", "ICD10", "DOB", "HEALTH_PLAN_ID"}
+	personFindings = []string{
+		"SSN", "CREDIT_CARD", "PHONE", "EMAIL", "IBAN", "UK_NINO", "NHS_NUMBER",
+		"DE_TAX_ID", "FR_NIR", "CA_SIN", "AADHAAR", "AU_TFN",
+	}
+	internalFindings = []string{"SERVER", "TABLE", "IP", "CONNSTR", "PATH", "HOST"}
+)
+
+// frameworks are the named compliance framework profiles. Control numbers
+// follow PCI-DSS v3.2.1, the HIPAA rules in 45 CFR 164, the GDPR articles
+// and the 2017 SOC 2 trust services criteria.
+var frameworks = map[string]Framework{
+	"pci-dss": {
+		Name:              "PCI-DSS",
+		Severities:        map[string]types.Severity{"credit_card": types.SeverityCritical},
+		SanitizationRules: []string{"private_ip_10", "private_ip_172", "private_ip_192"},
+		MinRetentionDays:  365, // Requirement 10.7: one year of audit history
+		Controls: controlMap(
+			controls("3.4", "CREDIT_CARD"),       // Render PAN unreadable
+			controls("8.2.1", secretFindings...), // Protect authentication credentials
+			controls("1.3.7", "IP"),              // Do not disclose private IP addresses
+		),
+	},
+	"hipaa": {
+		Name:              "HIPAA",
+		DetectorProfiles:  []string{"hipaa"},
+		Severities:        map[string]types.Severity{"ssn": types.SeverityCritical},
+		SanitizationRules: []string{"internal_email"},
+		MinRetentionDays:  6 * 365, // 164.316(b)(2): six years
+		Controls: controlMap(
+			controls("164.502(a)", healthFindings...),          // Disclosure of PHI
+			controls("164.514(b)(2)", "SSN", "PHONE", "EMAIL"), // Identifiers of individuals
+			controls("164.312(d)", secretFindings...),          // Authentication
+		),
+	},
+	"gdpr": {
+		Name:              "GDPR",
+		DetectorProfiles:  []string{"eu"},
+		SanitizationRules: []string{"internal_email"},
+		Controls: controlMap(
+			controls("Art. 5(1)(c)", personFindings...), // Data minimisation
+			controls("Art. 9", healthFindings...),       // Special categories
+			controls("Art. 32", secretFindings...),      // Security of processing
+		),
+	},
+	"soc2": {
+		Name: "SOC 2",
+		Severities: map[string]types.Severity{
+			"password_string": types.SeverityCritical,
+			"generic_api_key": types.SeverityCritical,
+			"bearer_token":    types.SeverityCritical,
+		},
+		SanitizationRules: []string{"connection_string", "internal_hostname"},
+		MinRetentionDays:  365,
+		Controls: controlMap(
+			controls("CC6.1", secretFindings...),  // Logical access security
+			controls("C1.1", internalFindings...), // Confidential information
+			controls("P6.1", personFindings...),   // Disclosure to third parties
+		),
+	},
+}
+
+// controlEntry relates finding types to one control.
+type controlEntry struct {
+	control      string
+	findingTypes []string
+}
+
+// controls relates finding types to a control.
+func controls(control string, findingTypes ...string) controlEntry {
+	return controlEntry{control, findingTypes}
+}
+
+// controlMap builds a framework's finding type to control map.
+func controlMap(entries ...controlEntry) map[string]string {
+	m := make(map[string]string)
+	for _, entry := range entries {
+		for _, findingType := range entry.findingTypes {
+			m[findingType] = entry.control
+		}
+	}
+	return m
+}
+
+// LookupFramework returns the named compliance framework profile.
+func LookupFramework(name string) (Framework, error) {
+	framework, ok := frameworks[name]
+	if !ok {
+		return Framework{}, fmt.Errorf("unknown compliance framework %q (known: %v)", name, FrameworkNames())
+	}
+	return framework, nil
+}
+
+// FrameworkNames returns the names of the compliance frameworks, sorted.
+func FrameworkNames() []string {
+	names := make([]string, 0, len(frameworks))
+	for name := range frameworks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// EnableFramework enables a compliance framework's detectors and
+// severities, and tags later findings with its controls. It returns the
+// framework so callers can apply its sanitization rules and retention.
+func (d *Detector) EnableFramework(name string) (Framework, error) {
+	framework, err := LookupFramework(name)
+	if err != nil {
+		return Framework{}, err
+	}
+
+	for _, profile := range framework.DetectorProfiles {
+		if err := d.EnableProfile(profile); err != nil {
+			return Framework{}, err
+		}
+	}
+	for _, pattern := range d.patterns {
+		severity, ok := framework.Severities[pattern.ID]
+		if !ok {
+			severity, ok = framework.Severities[strings.ToLower(pattern.Type)]
+		}
+		if ok {
+			pattern.Severity = severity
+		}
+	}
+
+	if d.controls == nil {
+		d.controls = make(map[string][]types.ComplianceControl)
+	}
+	for findingType, control := range framework.Controls {
+		tag := types.ComplianceControl{Framework: framework.Name, Control: control}
+		if !containsControl(d.controls[findingType], tag) {
+			d.controls[findingType] = append(d.controls[findingType], tag)
+		}
+	}
+	return framework, nil
+}
+
+// TagControls tags violations found elsewhere, such as by sanitization
+// rules, with the controls of the enabled frameworks.
+func (d *Detector) TagControls(violations []types.Violation) {
+	for i := range violations {
+		violations[i].Controls = d.controls[violations[i].Type]
+	}
+}
+
+// containsControl reports whether tags contains tag.
+func containsControl(tags []types.ComplianceControl, tag types.ComplianceControl) bool {
+	for _, t := range tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
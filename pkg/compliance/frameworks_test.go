@@ -0,0 +1,91 @@
+package compliance
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/enterprise/opencode-enterprise-shield/pkg/types"
+)
+
+func TestDetector_EnableFramework(t *testing.T) {
+	detector := NewDetector(true)
+	content := "card 4111111111111111 password=hunter2hunter2"
+
+	// Without frameworks, findings carry no controls
+	for _, v := range detector.Scan(content).Violations {
+		if v.Controls != nil {
+			t.Errorf("Expected no controls without frameworks, got %v on %s", v.Controls, v.Type)
+		}
+	}
+
+	if _, err := detector.EnableFramework("pci-dss"); err != nil {
+		t.Fatalf("EnableFramework failed: %v", err)
+	}
+	soc2, err := detector.EnableFramework("soc2")
+	if err != nil {
+		t.Fatalf("EnableFramework failed: %v", err)
+	}
+	if soc2.MinRetentionDays != 365 || len(soc2.SanitizationRules) == 0 {
+		t.Errorf("Unexpected soc2 framework %+v", soc2)
+	}
+
+	result := detector.Scan(content)
+	if len(result.Violations) != 2 {
+		t.Fatalf("Expected 2 violations, got %+v", result.Violations)
+	}
+	card, password := result.Violations[0], result.Violations[1]
+
+	wantCard := []types.ComplianceControl{{Framework: "PCI-DSS", Control: "3.4"}, {Framework: "SOC 2", Control: "P6.1"}}
+	if !reflect.DeepEqual(card.Controls, wantCard) {
+		t.Errorf("Expected card controls %v, got %v", wantCard, card.Controls)
+	}
+	wantPassword := []types.ComplianceControl{{Framework: "PCI-DSS", Control: "8.2.1"}, {Framework: "SOC 2", Control: "CC6.1"}}
+	if !reflect.DeepEqual(password.Controls, wantPassword) {
+		t.Errorf("Expected password controls %v, got %v", wantPassword, password.Controls)
+	}
+	if got := password.Controls[0].String(); got != "PCI-DSS 8.2.1" {
+		t.Errorf("Expected control to print as PCI-DSS 8.2.1, got %q", got)
+	}
+
+	// SOC 2 raises passwords in code to critical, so they block
+	if password.Severity != types.SeverityCritical || password.Action != types.FindingBlock {
+		t.Errorf("Expected a critical, blocking password finding, got %+v", password)
+	}
+
+	// Enabling a framework twice does not duplicate its controls
+	if _, err := detector.EnableFramework("pci-dss"); err != nil {
+		t.Fatalf("EnableFramework failed: %v", err)
+	}
+	if got := detector.Scan(content).Violations[0].Controls; !reflect.DeepEqual(got, wantCard) {
+		t.Errorf("Expected card controls %v after re-enabling, got %v", wantCard, got)
+	}
+
+	if _, err := detector.EnableFramework("iso27001"); err == nil {
+		t.Error("Expected an unknown framework to be rejected")
+	}
+}
+
+func TestDetector_FrameworkProfiles(t *testing.T) {
+	detector := NewDetector(true)
+	if _, err := detector.EnableFramework("hipaa"); err != nil {
+		t.Fatalf("EnableFramework failed: %v", err)
+	}
+
+	// The hipaa framework enables the PHI detectors
+	result := detector.Scan("NPI 1234567893")
+	if len(result.Violations) != 1 {
+		t.Fatalf("Expected the NPI to be found, got %+v", result.Violations)
+	}
+	want := []types.ComplianceControl{{Framework: "HIPAA", Control: "164.502(a)"}}
+	if !reflect.DeepEqual(result.Violations[0].Controls, want) {
+		t.Errorf("Expected controls %v, got %v", want, result.Violations[0].Controls)
+	}
+
+	// Findings from sanitization rules are tagged too
+	violations := []types.Violation{{RuleID: "internal_email", Type: "EMAIL"}, {RuleID: "server_names", Type: "SERVER"}}
+	detector.TagControls(violations)
+	want = []types.ComplianceControl{{Framework: "HIPAA", Control: "164.514(b)(2)"}}
+	if !reflect.DeepEqual(violations[0].Controls, want) || violations[1].Controls != nil {
+		t.Errorf("Unexpected controls %v and %v", violations[0].Controls, violations[1].Controls)
+	}
+}
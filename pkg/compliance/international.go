@@ -0,0 +1,119 @@
+// Package compliance provides detectors for international personal data.
+package compliance
+
+import (
+	"regexp"
+
+	"github.com/enterprise/opencode-enterprise-shield/pkg/types"
+)
+
+// loadInternationalPatterns registers detectors for personal identifiers
+// outside the US. They are disabled until a region profile enables them,
+// since their formats overlap with ordinary numbers elsewhere. Identifiers
+// that are bare digit runs, such as NHS numbers and SINs, are only reported
+// after their label, since one in ten or so random numbers of the right
+// length passes their checksum.
+func (d *Detector) loadInternationalPatterns() {
+	d.AddPattern("iban", &Pattern{
+		Name:        "IBAN",
+		Type:        "IBAN",
+		Regex:       regexp.MustCompile(`\b[A-Z]{2}\d{2}(?: ?[A-Z0-9]{4}){2,7}(?: ?[A-Z0-9]{1,3})?\b`),
+		Severity:    types.SeverityHigh,
+		Priority:    PriorityStructured,
+		Validator:   ValidateIBAN,
+		Description: "Detects International Bank Account Numbers with mod-97 validation",
+	})
+
+	d.AddPattern("uk_nino", &Pattern{
+		Name:        "UK National Insurance Number",
+		Type:        "UK_NINO",
+		Regex:       regexp.MustCompile(`\b[A-CEGHJ-PR-TW-Z][A-CEGHJ-NPR-TW-Z] ?\d{2} ?\d{2} ?\d{2} ?[A-D]\b`),
+		Severity:    types.SeverityCritical,
+		Priority:    PriorityStructured,
+		Validator:   ValidateNINO,
+		Description: "Detects UK National Insurance numbers",
+	})
+
+	d.AddPattern("uk_nhs", &Pattern{
+		Name:        "NHS Number",
+		Type:        "NHS_NUMBER",
+		Regex:       labelled(`NHS`, `\d{3}[ -]?\d{3}[ -]?\d{4}`),
+		Severity:    types.SeverityCritical,
+		Priority:    PriorityStructured,
+		Validator:   ValidateNHSNumber,
+		Description: "Detects NHS numbers labelled as such, with mod-11 validation",
+	})
+
+	d.AddPattern("ca_sin", &Pattern{
+		Name:        "Canadian Social Insurance Number",
+		Type:        "CA_SIN",
+		Regex:       labelled(`SIN|NAS|social insurance`, `\d{3}[ -]?\d{3}[ -]?\d{3}`),
+		Severity:    types.SeverityCritical,
+		Priority:    PriorityStructured,
+		Validator:   ValidateSIN,
+		Description: "Detects Canadian Social Insurance Numbers labelled as such, with Luhn validation",
+	})
+
+	d.AddPattern("de_steuer_id", &Pattern{
+		Name:        "German Tax ID",
+		Type:        "DE_TAX_ID",
+		Regex:       regexp.MustCompile(`\b[1-9]\d ?\d{3} ?\d{3} ?\d{3}\b`),
+		Severity:    types.SeverityCritical,
+		Priority:    PriorityStructured,
+		Validator:   ValidateSteuerID,
+		Description: "Detects German tax identification numbers (Steuer-ID)",
+	})
+
+	d.AddPattern("fr_nir", &Pattern{
+		Name:        "French Social Security Number",
+		Type:        "FR_NIR",
+		Regex:       regexp.MustCompile(`\b[12] ?\d{2} ?\d{2} ?(?:\d{2}|2[AB]) ?\d{3} ?\d{3} ?\d{2}\b`),
+		Severity:    types.SeverityCritical,
+		Priority:    PriorityStructured,
+		Validator:   ValidateNIR,
+		Description: "Detects French social security numbers (NIR) with key validation",
+	})
+
+	d.AddPattern("in_aadhaar", &Pattern{
+		Name:        "Aadhaar Number",
+		Type:        "AADHAAR",
+		Regex:       labelled(`Aadhaar|Aadhar|UIDAI|UID`, `[2-9]\d{3}[ -]?\d{4}[ -]?\d{4}`),
+		Severity:    types.SeverityCritical,
+		Priority:    PriorityStructured,
+		Validator:   ValidateAadhaar,
+		Description: "Detects Indian Aadhaar numbers labelled as such, with Verhoeff validation",
+	})
+
+	d.AddPattern("au_tfn", &Pattern{
+		Name:        "Australian Tax File Number",
+		Type:        "AU_TFN",
+		Regex:       labelled(`TFN|tax file`, `\d{3} ?\d{3} ?\d{2,3}`),
+		Severity:    types.SeverityCritical,
+		Priority:    PriorityStructured,
+		Validator:   ValidateTFN,
+		Description: "Detects Australian Tax File Numbers labelled as such",
+	})
+
+	d.AddPattern("e164_phone", &Pattern{
+		Name:        "Phone Number",
+		Type:        "PHONE",
+		Regex:       regexp.MustCompile(`\+[1-9]\d{7,14}\b`),
+		Severity:    types.SeverityMedium,
+		Priority:    PriorityGeneric,
+		Validator:   ValidateE164,
+		Description: "Detects phone numbers in E.164 format",
+	})
+
+	// Report labelled numbers, not their labels
+	for _, id := range []string{"uk_nhs", "ca_sin", "in_aadhaar", "au_tfn"} {
+		pattern := d.pattern(id)
+		pattern.Finder = groupFinder(pattern.Regex)
+	}
+}
+
+// labelled compiles a pattern matching number after one of the
+// alternatives in labels, as in "NHS no. 943 476 5919" or "TFN: 123 456 782".
+// The number is the first capture group, which is all that is reported.
+func labelled(labels, number string) *regexp.Regexp {
+	return regexp.MustCompile(`(?i)\b(?:` + labels + `)\b(?:\s*(?:number|no\.?|#))?\s*[:#=-]?\s*(` + number + `)\b`)
+}
@@ -19,12 +19,17 @@ func ValidateLuhn(number string) bool {
 		return false
 	}
 
+	return luhnValid(cleaned)
+}
+
+// luhnValid reports whether a string of digits passes the Luhn check.
+func luhnValid(digits string) bool {
 	sum := 0
 	alternate := false
 
 	// Process from right to left
-	for i := len(cleaned) - 1; i >= 0; i-- {
-		digit := int(cleaned[i] - '0')
+	for i := len(digits) - 1; i >= 0; i-- {
+		digit := int(digits[i] - '0')
 
 		if alternate {
 			digit *= 2
@@ -39,35 +44,3 @@ func ValidateLuhn(number string) bool {
 
 	return sum%10 == 0
 }
-
-// GetCardType returns the card type based on the number prefix.
-func GetCardType(number string) string {
-	// Remove non-digits
-	cleaned := strings.Map(func(r rune) rune {
-		if r >= '0' && r <= '9' {
-			return r
-		}
-		return -1
-	}, number)
-
-	if len(cleaned) < 4 {
-		return "unknown"
-	}
-
-	// Check prefixes
-	switch {
-	case strings.HasPrefix(cleaned, "4"):
-		return "visa"
-	case strings.HasPrefix(cleaned, "51"), strings.HasPrefix(cleaned, "52"),
-		strings.HasPrefix(cleaned, "53"), strings.HasPrefix(cleaned, "54"),
-		strings.HasPrefix(cleaned, "55"):
-		return "mastercard"
-	case strings.HasPrefix(cleaned, "34"), strings.HasPrefix(cleaned, "37"):
-		return "amex"
-	case strings.HasPrefix(cleaned, "6011"), strings.HasPrefix(cleaned, "65"):
-		return "discover"
-	default:
-		return "unknown"
-	}
-}
-
@@ -0,0 +1,163 @@
+// Package compliance provides detectors for protected health information.
+package compliance
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/enterprise/opencode-enterprise-shield/pkg/types"
+)
+
+// datePattern matches numeric, ISO and written-out dates.
+const datePattern = `(?:\d{1,2}[/.-]\d{1,2}[/.-]\d{2,4}|\d{4}-\d{2}-\d{2}|` +
+	`(?:Jan|Feb|Mar|Apr|May|Jun|Jul|Aug|Sep|Oct|Nov|Dec)[a-z]*\.? \d{1,2},? \d{4}|` +
+	`\d{1,2} (?:Jan|Feb|Mar|Apr|May|Jun|Jul|Aug|Sep|Oct|Nov|Dec)[a-z]*\.? \d{4})`
+
+// PHI detection expressions.
+var (
+	icd10Code = regexp.MustCompile(`\b[A-TV-Z]\d[0-9AB](?:\.[0-9A-TV-Z]{1,4})?\b`)
+
+	// icd10Person matches what ties a diagnosis code to a person: a date, a
+	// labelled name, or a "Last, First" name
+	icd10Person = regexp.MustCompile(`(?i:` + datePattern + `)|` +
+		`(?i:\b(?:patient|pt|name|mr|mrs|ms|dr)\b\.?:?\s+)[A-Z][a-z]+|` +
+		`\b[A-Z][a-z]+, [A-Z][a-z]+\b`)
+
+	defaultMRNPattern = `(?i)\b(?:MRN|medical record(?: number| no\.?| #)?)\s*[:#]?\s*([A-Z0-9][A-Z0-9-]{4,14})\b`
+)
+
+// icd10Window is how far, on the same line, a date or name may be from an
+// ICD-10 code.
+const icd10Window = 40
+
+// loadPHIPatterns registers detectors for protected health information.
+// They are disabled until the hipaa profile enables them.
+func (d *Detector) loadPHIPatterns() {
+	d.AddPattern("us_npi", &Pattern{
+		Name:        "National Provider Identifier",
+		Type:        "NPI",
+		Regex:       regexp.MustCompile(`(?i)\bNPI\b(?:\s*(?:number|no\.?|#))?\s*[:#]?\s*([12]\d{9})\b`),
+		Severity:    types.SeverityHigh,
+		Priority:    PriorityStructured,
+		Validator:   ValidateNPI,
+		Description: "Detects US National Provider Identifiers labelled as NPI",
+	})
+
+	d.AddPattern("us_dea", &Pattern{
+		Name:        "DEA Registration Number",
+		Type:        "DEA_NUMBER",
+		Regex:       regexp.MustCompile(`\b[ABCDEFGHJKLMPRSTUX][A-Z9]\d{7}\b`),
+		Severity:    types.SeverityHigh,
+		Priority:    PriorityStructured,
+		Validator:   ValidateDEA,
+		Description: "Detects DEA registration numbers with checksum validation",
+	})
+
+	d.AddPattern("medical_record_number", &Pattern{
+		Name:        "Medical Record Number",
+		Type:        "MRN",
+		Regex:       regexp.MustCompile(defaultMRNPattern),
+		Severity:    types.SeverityHigh,
+		Priority:    PriorityStructured,
+		Description: "Detects medical record numbers",
+	})
+
+	d.AddPattern("icd10_patient", &Pattern{
+		Name:        "ICD-10 Diagnosis for a Patient",
+		Type:        "ICD10",
+		Regex:       icd10Code,
+		Finder:      findPatientDiagnoses,
+		Severity:    types.SeverityHigh,
+		Priority:    PriorityStructured,
+		Description: "Detects ICD-10 codes next to a name or date",
+	})
+
+	d.AddPattern("date_of_birth", &Pattern{
+		Name:        "Date of Birth",
+		Type:        "DOB",
+		Regex:       regexp.MustCompile(`(?i)\b(?:DOB|D\.O\.B\.?|date of birth|birth ?date|born(?: on)?)\s*[:=-]?\s*(` + datePattern + `)`),
+		Severity:    types.SeverityHigh,
+		Priority:    PriorityStructured,
+		Description: "Detects dates labelled as dates of birth",
+	})
+
+	d.AddPattern("medicare_mbi", &Pattern{
+		Name:        "Medicare Beneficiary Identifier",
+		Type:        "HEALTH_PLAN_ID",
+		Regex:       regexp.MustCompile(`\b[1-9][AC-HJKMNP-RT-Y][AC-HJKMNP-RT-Y0-9]\d-?[AC-HJKMNP-RT-Y][AC-HJKMNP-RT-Y0-9]\d-?[AC-HJKMNP-RT-Y]{2}\d{2}\b`),
+		Severity:    types.SeverityHigh,
+		Priority:    PriorityStructured,
+		Description: "Detects Medicare Beneficiary Identifiers",
+	})
+
+	d.AddPattern("health_plan_id", &Pattern{
+		Name:        "Health Plan Member ID",
+		Type:        "HEALTH_PLAN_ID",
+		Regex:       regexp.MustCompile(`(?i)\b(?:member|subscriber|insurance|health plan|policy)\s*(?:id|number|no\.?|#)\s*[:#]?\s*([A-Z0-9]{6,20})\b`),
+		Severity:    types.SeverityHigh,
+		Priority:    PriorityStructured,
+		Validator:   func(id string) bool { return strings.ContainsAny(id, "0123456789") },
+		Description: "Detects health plan member and subscriber IDs",
+	})
+
+	// Report labelled values, not their labels
+	for _, id := range []string{"us_npi", "medical_record_number", "date_of_birth", "health_plan_id"} {
+		pattern := d.pattern(id)
+		pattern.Finder = groupFinder(pattern.Regex)
+	}
+}
+
+// findPatientDiagnoses finds ICD-10 codes with a date or name within
+// icd10Window characters on the same line, since a diagnosis code alone does
+// not identify anyone.
+func findPatientDiagnoses(content string) [][]int {
+	var locations [][]int
+	for _, loc := range icd10Code.FindAllStringIndex(content, -1) {
+		start := max(0, loc[0]-icd10Window)
+		if i := strings.LastIndexByte(content[start:loc[0]], '\n'); i >= 0 {
+			start += i + 1
+		}
+		end := min(len(content), loc[1]+icd10Window)
+		if i := strings.IndexByte(content[loc[1]:end], '\n'); i >= 0 {
+			end = loc[1] + i
+		}
+		if icd10Person.MatchString(content[start:loc[0]]) || icd10Person.MatchString(content[loc[1]:end]) {
+			locations = append(locations, loc)
+		}
+	}
+	return locations
+}
+
+// SetMRNPatterns adds an organization's medical record number formats to the
+// default one. Each is a regular expression; if it has a capture group, only
+// the group is reported, so a pattern can require a label without reporting it.
+func (d *Detector) SetMRNPatterns(patterns []string) error {
+	regexes := []*regexp.Regexp{regexp.MustCompile(defaultMRNPattern)}
+	for _, expr := range patterns {
+		compiled, err := regexp.Compile(expr)
+		if err != nil {
+			return fmt.Errorf("failed to compile MRN pattern %q: %w", expr, err)
+		}
+		regexes = append(regexes, compiled)
+	}
+
+	finders := make([]func(string) [][]int, len(regexes))
+	for i, regex := range regexes {
+		if regex.NumSubexp() > 0 {
+			finders[i] = groupFinder(regex)
+		} else {
+			finders[i] = func(content string) [][]int { return regex.FindAllStringIndex(content, -1) }
+		}
+	}
+
+	// Overlaps between the patterns are resolved by Scan
+	d.pattern("medical_record_number").Finder = func(content string) [][]int {
+		var locations [][]int
+		for _, find := range finders {
+			locations = append(locations, find(content)...)
+		}
+		return locations
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+package compliance
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/enterprise/opencode-enterprise-shield/pkg/types"
+)
+
+func TestPHIValidators(t *testing.T) {
+	if !ValidateNPI("1234567893") {
+		t.Error("Expected 1234567893 to be a valid NPI")
+	}
+	for _, npi := range []string{"1234567890", "3234567893", "123456789"} {
+		if ValidateNPI(npi) {
+			t.Errorf("Expected %s to be an invalid NPI", npi)
+		}
+	}
+
+	if !ValidateDEA("AB1234563") {
+		t.Error("Expected AB1234563 to be a valid DEA number")
+	}
+	for _, dea := range []string{"AB1234564", "AB123456", "ABX234563"} {
+		if ValidateDEA(dea) {
+			t.Errorf("Expected %s to be an invalid DEA number", dea)
+		}
+	}
+}
+
+// phiFinding is the part of a violation PHI tests compare.
+type phiFinding struct {
+	Type     string
+	Value    string
+	Severity types.Severity
+}
+
+func scanPHI(detector *Detector, content string) []phiFinding {
+	findings := []phiFinding{}
+	for _, v := range detector.Scan(content).Violations {
+		findings = append(findings, phiFinding{v.Type, content[v.Position : v.Position+v.Length], v.Severity})
+	}
+	return findings
+}
+
+func TestDetector_HIPAAProfile(t *testing.T) {
+	content := "Patient: Smith, John DOB: 03/14/1985 MRN: 00123456\n" +
+		"Dx E11.9 seen 2026-10-01 by NPI 1234567893, DEA AB1234563\n" +
+		"Medicare 1EG4-TE5-MK73, Member ID: XYZ123456789\n" +
+		"Unrelated: upgrade to E11.9 of the library"
+
+	detector := NewDetector(true)
+	if findings := scanPHI(detector, content); len(findings) != 0 {
+		t.Fatalf("Expected PHI detectors to be off by default, got %+v", findings)
+	}
+
+	if err := detector.EnableProfile("hipaa"); err != nil {
+		t.Fatalf("EnableProfile failed: %v", err)
+	}
+	critical := types.SeverityCritical
+	want := []phiFinding{
+		{"DOB", "03/14/1985", critical},
+		{"MRN", "00123456", critical},
+		{"ICD10", "E11.9", critical},
+		{"NPI", "1234567893", critical},
+		{"DEA_NUMBER", "AB1234563", critical},
+		{"HEALTH_PLAN_ID", "1EG4-TE5-MK73", critical},
+		{"HEALTH_PLAN_ID", "XYZ123456789", critical},
+	}
+	if got := scanPHI(detector, content); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %+v, got %+v", want, got)
+	}
+	if !detector.Scan(content).ShouldBlock {
+		t.Error("Expected PHI to block under the hipaa profile")
+	}
+}
+
+func TestDetector_SetMRNPatterns(t *testing.T) {
+	detector := NewDetector(true)
+	if err := detector.EnableProfile("hipaa"); err != nil {
+		t.Fatalf("EnableProfile failed: %v", err)
+	}
+
+	content := "chart MR-0042137 and chart no. 88-1234"
+	if findings := scanPHI(detector, content); len(findings) != 0 {
+		t.Fatalf("Expected no findings before the organization's formats are set, got %+v", findings)
+	}
+
+	if err := detector.SetMRNPatterns([]string{`\bMR-\d{7}\b`, `chart no\. (\d{2}-\d{4})`}); err != nil {
+		t.Fatalf("SetMRNPatterns failed: %v", err)
+	}
+	want := []phiFinding{
+		{"MRN", "MR-0042137", types.SeverityCritical},
+		{"MRN", "88-1234", types.SeverityCritical},
+	}
+	if got := scanPHI(detector, content); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %+v, got %+v", want, got)
+	}
+
+	if err := detector.SetMRNPatterns([]string{`(`}); err == nil {
+		t.Error("Expected an invalid MRN pattern to be rejected")
+	}
+}
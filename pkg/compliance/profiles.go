@@ -0,0 +1,68 @@
+// Package compliance provides detector profiles.
+package compliance
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/enterprise/opencode-enterprise-shield/pkg/types"
+)
+
+// detectorProfile is a set of detectors that are off by default and enabled
+// together, such as those for a region.
+type detectorProfile struct {
+	patterns []string
+	severity types.Severity // Optional; overrides the detectors' severity
+}
+
+// detectorProfiles are the named detector profiles.
+var detectorProfiles = map[string]detectorProfile{
+	"uk":   {patterns: []string{"iban", "uk_nino", "uk_nhs", "e164_phone"}},
+	"eu":   {patterns: []string{"iban", "de_steuer_id", "fr_nir", "e164_phone"}},
+	"de":   {patterns: []string{"iban", "de_steuer_id", "e164_phone"}},
+	"fr":   {patterns: []string{"iban", "fr_nir", "e164_phone"}},
+	"ca":   {patterns: []string{"ca_sin", "e164_phone"}},
+	"in":   {patterns: []string{"in_aadhaar", "e164_phone"}},
+	"au":   {patterns: []string{"au_tfn", "e164_phone"}},
+	"apac": {patterns: []string{"in_aadhaar", "au_tfn", "e164_phone"}},
+	"international": {patterns: []string{
+		"iban", "uk_nino", "uk_nhs", "ca_sin", "de_steuer_id", "fr_nir",
+		"in_aadhaar", "au_tfn", "e164_phone",
+	}},
+
+	// Protected health information must never reach a provider
+	"hipaa": {
+		patterns: []string{
+			"us_npi", "us_dea", "medical_record_number", "icd10_patient",
+			"date_of_birth", "medicare_mbi", "health_plan_id",
+		},
+		severity: types.SeverityCritical,
+	},
+}
+
+// EnableProfile enables every detector in a named profile.
+func (d *Detector) EnableProfile(name string) error {
+	profile, ok := detectorProfiles[name]
+	if !ok {
+		return fmt.Errorf("unknown detector profile %q (known: %v)", name, ProfileNames())
+	}
+	for _, id := range profile.patterns {
+		if pattern := d.pattern(id); pattern != nil {
+			pattern.Enabled = true
+			if profile.severity != "" {
+				pattern.Severity = profile.severity
+			}
+		}
+	}
+	return nil
+}
+
+// ProfileNames returns the names of the detector profiles, sorted.
+func ProfileNames() []string {
+	names := make([]string, 0, len(detectorProfiles))
+	for name := range detectorProfiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
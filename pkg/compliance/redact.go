@@ -0,0 +1,61 @@
+// Package compliance provides redaction of compliance findings.
+package compliance
+
+import (
+	"sort"
+
+	"github.com/enterprise/opencode-enterprise-shield/pkg/types"
+)
+
+// RedactionPlaceholder returns the irreversible placeholder that replaces a
+// redacted finding, e.g. [REDACTED:SSN].
+func RedactionPlaceholder(findingType string) string {
+	return "[REDACTED:" + findingType + "]"
+}
+
+// Rewrite replaces the findings whose action is redact or alias in content,
+// which must be the content they were found in. Redacted findings become a
+// placeholder; aliased findings become the value aliasFor returns for them.
+// It returns the rewritten content and the findings it replaced, in
+// position order.
+func Rewrite(content string, violations []types.Violation, aliasFor func(value, findingType string) string) (string, []types.Violation) {
+	var replaced []types.Violation
+	for _, v := range violations {
+		if v.Action != types.FindingRedact && v.Action != types.FindingAlias {
+			continue
+		}
+		if v.Position < 0 || v.Position+v.Length > len(content) {
+			continue
+		}
+		replaced = append(replaced, v)
+	}
+	if len(replaced) == 0 {
+		return content, nil
+	}
+	sort.SliceStable(replaced, func(i, j int) bool {
+		return replaced[i].Position < replaced[j].Position
+	})
+
+	// Replace from the end so earlier positions stay valid
+	end := len(content)
+	kept := make([]types.Violation, 0, len(replaced))
+	for i := len(replaced) - 1; i >= 0; i-- {
+		v := replaced[i]
+		if v.Position+v.Length > end {
+			continue // Overlaps a later finding already replaced
+		}
+
+		replacement := RedactionPlaceholder(v.Type)
+		if v.Action == types.FindingAlias {
+			replacement = aliasFor(content[v.Position:v.Position+v.Length], v.Type)
+		}
+		content = content[:v.Position] + replacement + content[v.Position+v.Length:]
+		end = v.Position
+		kept = append(kept, v)
+	}
+
+	for i, j := 0, len(kept)-1; i < j; i, j = i+1, j-1 {
+		kept[i], kept[j] = kept[j], kept[i]
+	}
+	return content, kept
+}
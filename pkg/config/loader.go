@@ -6,7 +6,10 @@ import (
 	"path/filepath"
 	"time"
 
+	"github.com/enterprise/opencode-enterprise-shield/pkg/compliance"
+	"github.com/enterprise/opencode-enterprise-shield/pkg/decode"
 	"github.com/enterprise/opencode-enterprise-shield/pkg/hooks"
+	"github.com/enterprise/opencode-enterprise-shield/pkg/paths"
 	"github.com/enterprise/opencode-enterprise-shield/pkg/types"
 	"gopkg.in/yaml.v3"
 )
@@ -16,6 +19,7 @@ type FullConfig struct {
 	Enabled    bool            `yaml:"enabled"`
 	Session    SessionConfig   `yaml:"session"`
 	Rules      []types.SanitizationRule `yaml:"rules"`
+	RuleProfiles map[string][]string    `yaml:"ruleProfiles,omitempty"`
 	Compliance ComplianceConfig `yaml:"compliance"`
 	Policy     PolicyConfig    `yaml:"policy"`
 	Audit      AuditConfig     `yaml:"audit"`
@@ -23,15 +27,40 @@ type FullConfig struct {
 
 // SessionConfig holds session-related configuration.
 type SessionConfig struct {
-	TTL         string `yaml:"ttl"`
-	MaxMappings int    `yaml:"maxMappings"`
-	Encryption  bool   `yaml:"encryption"`
+	TTL             string `yaml:"ttl"`
+	MaxMappings     int    `yaml:"maxMappings"`
+	Encryption      bool   `yaml:"encryption"`
+	JanitorInterval string `yaml:"janitorInterval"`
+	SlidingTTL      bool   `yaml:"slidingTTL"`
+	MaxLifetime     string `yaml:"maxLifetime"`
+	StorePath       string `yaml:"storePath"`
+	KeyPath         string `yaml:"keyPath"`
+	SigningKeyPath  string `yaml:"signingKeyPath"`
+
+	// TrustedKeys sign the session bundles that may be imported
+	TrustedKeys []string `yaml:"trustedKeys,omitempty"`
 }
 
 // ComplianceConfig holds compliance detection configuration.
 type ComplianceConfig struct {
 	BlockOnCritical bool             `yaml:"blockOnCritical"`
 	Detectors       []DetectorConfig `yaml:"detectors"`
+
+	// Frameworks enable compliance framework profiles: pci-dss, hipaa,
+	// gdpr or soc2
+	Frameworks []string `yaml:"frameworks,omitempty"`
+
+	// Profiles enable detector sets that are off by default, e.g. "uk" or "eu"
+	Profiles []string `yaml:"profiles,omitempty"`
+
+	// MRNPatterns are the organization's medical record number formats
+	MRNPatterns []string `yaml:"mrnPatterns,omitempty"`
+
+	// Entropy tunes detection of random-looking secrets
+	Entropy *compliance.EntropyConfig `yaml:"entropy,omitempty"`
+
+	// Decode bounds decoding of base64, URL and hex segments before scanning
+	Decode *decode.Limits `yaml:"decode,omitempty"`
 }
 
 // DetectorConfig holds individual detector configuration.
@@ -40,12 +69,30 @@ type DetectorConfig struct {
 	Enabled     bool   `yaml:"enabled"`
 	Severity    string `yaml:"severity"`
 	ValidateLuhn bool  `yaml:"validateLuhn,omitempty"`
+
+	// Action for findings: block, redact, alias or warn. Unset blocks
+	// critical findings if blockOnCritical is set and warns otherwise.
+	Action string `yaml:"action,omitempty"`
+
+	// TestDataAction is the action for findings of well-known test data,
+	// such as test card numbers. Unset uses Action.
+	TestDataAction string `yaml:"testDataAction,omitempty"`
 }
 
 // PolicyConfig holds policy configuration.
 type PolicyConfig struct {
 	DefaultAccessLevel string `yaml:"defaultAccessLevel"`
 	RequireAuth        bool   `yaml:"requireAuth"`
+	File               string `yaml:"file,omitempty"`
+	ReloadInterval     string `yaml:"reloadInterval,omitempty"`
+
+	// Break-glass overrides, see "enterprise-shield override"
+	OverrideRevocations string   `yaml:"overrideRevocations,omitempty"`
+	OverrideTrustedKeys []string `yaml:"overrideTrustedKeys,omitempty"`
+	OverrideSigningKey  string   `yaml:"overrideSigningKey,omitempty"`
+
+	// UsageStore holds the token counters for token budgets
+	UsageStore string `yaml:"usageStore,omitempty"`
 }
 
 // AuditConfig holds audit logging configuration.
@@ -58,13 +105,9 @@ type AuditConfig struct {
 
 // Load loads configuration from a YAML file.
 func Load(path string) (*FullConfig, error) {
-	// Expand home directory
-	if path[0] == '~' {
-		home, err := os.UserHomeDir()
-		if err != nil {
-			return nil, err
-		}
-		path = filepath.Join(home, path[1:])
+	path, err := paths.ExpandHome(path)
+	if err != nil {
+		return nil, err
 	}
 
 	data, err := os.ReadFile(path)
@@ -94,9 +137,15 @@ func DefaultFullConfig() *FullConfig {
 	return &FullConfig{
 		Enabled: true,
 		Session: SessionConfig{
-			TTL:         "8h",
-			MaxMappings: 10000,
-			Encryption:  true,
+			TTL:             "8h",
+			MaxMappings:     10000,
+			Encryption:      true,
+			JanitorInterval: "5m",
+			SlidingTTL:      false,
+			MaxLifetime:     "24h",
+			StorePath:       "~/.opencode/sessions/enterprise-shield",
+			KeyPath:         "~/.opencode/keys/enterprise-shield-session.key",
+			SigningKeyPath:  "~/.opencode/keys/enterprise-shield-signing.key",
 		},
 		Compliance: ComplianceConfig{
 			BlockOnCritical: true,
@@ -109,6 +158,7 @@ func DefaultFullConfig() *FullConfig {
 		Policy: PolicyConfig{
 			DefaultAccessLevel: "sanitized_only",
 			RequireAuth:        true,
+			ReloadInterval:     "30s",
 		},
 		Audit: AuditConfig{
 			Enabled:       true,
@@ -125,27 +175,84 @@ func (c *FullConfig) ToHooksConfig() *hooks.Config {
 	if ttl == 0 {
 		ttl = 8 * time.Hour
 	}
+	janitorInterval, _ := time.ParseDuration(c.Session.JanitorInterval)
+	if janitorInterval == 0 {
+		janitorInterval = 5 * time.Minute
+	}
+	maxLifetime, _ := time.ParseDuration(c.Session.MaxLifetime)
+
+	defaults := hooks.DefaultConfig()
+	storePath := valueOrDefault(c.Session.StorePath, defaults.SessionStore)
+	keyPath := valueOrDefault(c.Session.KeyPath, defaults.SessionKeyPath)
+	signingKeyPath := valueOrDefault(c.Session.SigningKeyPath, defaults.SigningKeyPath)
+
+	reloadInterval, _ := time.ParseDuration(c.Policy.ReloadInterval)
+	if reloadInterval == 0 {
+		reloadInterval = defaults.PolicyReloadInterval
+	}
+
+	detectorActions := make(map[string]types.FindingAction)
+	testDataActions := make(map[string]types.FindingAction)
+	for _, detector := range c.Compliance.Detectors {
+		if detector.Action != "" {
+			detectorActions[detector.Type] = types.FindingAction(detector.Action)
+		}
+		if detector.TestDataAction != "" {
+			testDataActions[detector.Type] = types.FindingAction(detector.TestDataAction)
+		}
+	}
 
 	return &hooks.Config{
 		Enabled:         c.Enabled,
 		SessionTTL:      ttl,
 		MaxMappings:     c.Session.MaxMappings,
+		JanitorInterval: janitorInterval,
+		SlidingTTL:      c.Session.SlidingTTL,
+		MaxLifetime:     maxLifetime,
+		SessionStore:    storePath,
+		EncryptSessions: c.Session.Encryption,
+		SessionKeyPath:  keyPath,
+		SigningKeyPath:  signingKeyPath,
 		BlockOnCritical: c.Compliance.BlockOnCritical,
 		AuditLogPath:    c.Audit.LogPath,
 		SignAuditLogs:   c.Audit.SignEntries,
 		RetentionDays:   c.Audit.RetentionDays,
+
+		Frameworks:       c.Compliance.Frameworks,
+		DetectorActions:  detectorActions,
+		TestDataActions:  testDataActions,
+		DetectorProfiles: c.Compliance.Profiles,
+		MRNPatterns:      c.Compliance.MRNPatterns,
+		Entropy:          c.Compliance.Entropy,
+		Decode:           c.Compliance.Decode,
+
+		DefaultAccessLevel:   types.AccessLevel(c.Policy.DefaultAccessLevel),
+		PolicyFile:           c.Policy.File,
+		PolicyReloadInterval: reloadInterval,
+
+		RuleProfiles: c.RuleProfiles,
+
+		OverrideRevocations:    valueOrDefault(c.Policy.OverrideRevocations, defaults.OverrideRevocations),
+		SessionTrustedKeys:     c.Session.TrustedKeys,
+		OverrideTrustedKeys:    c.Policy.OverrideTrustedKeys,
+		OverrideSigningKeyPath: c.Policy.OverrideSigningKey,
+		UsageStore:             valueOrDefault(c.Policy.UsageStore, defaults.UsageStore),
+	}
+}
+
+// valueOrDefault returns value, or def if value is empty.
+func valueOrDefault(value, def string) string {
+	if value == "" {
+		return def
 	}
+	return value
 }
 
 // Save saves configuration to a YAML file.
 func Save(config *FullConfig, path string) error {
-	// Expand home directory
-	if path[0] == '~' {
-		home, err := os.UserHomeDir()
-		if err != nil {
-			return err
-		}
-		path = filepath.Join(home, path[1:])
+	path, err := paths.ExpandHome(path)
+	if err != nil {
+		return err
 	}
 
 	// Create directory if needed
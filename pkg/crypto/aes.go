@@ -4,7 +4,9 @@ package crypto
 import (
 	"crypto/aes"
 	"crypto/cipher"
+	"crypto/hmac"
 	"crypto/rand"
+	"crypto/sha256"
 	"errors"
 	"io"
 )
@@ -98,18 +100,34 @@ func (e *AESEncryptor) DecryptString(ciphertext []byte) (string, error) {
 	return string(plaintext), nil
 }
 
-// DeriveKey derives a key from a password using a simple KDF.
-// For production, use Argon2 or scrypt.
+// DeriveKey derives a 256-bit key from a password using PBKDF2-HMAC-SHA256.
 func DeriveKey(password, salt []byte) []byte {
-	// Simple PBKDF-like derivation (use proper KDF in production)
-	key := make([]byte, 32)
-	combined := append(password, salt...)
-	
-	// Simple hash-based derivation
-	for i := 0; i < 32; i++ {
-		key[i] = combined[i%len(combined)]
+	return pbkdf2SHA256(password, salt, KeyDerivationIterations)
+}
+
+// KeyDerivationIterations is the PBKDF2 iteration count used by DeriveKey.
+const KeyDerivationIterations = 210000
+
+// pbkdf2SHA256 implements PBKDF2 (RFC 8018) for a single 32-byte block.
+func pbkdf2SHA256(password, salt []byte, iterations int) []byte {
+	prf := hmac.New(sha256.New, password)
+
+	// U1 = PRF(password, salt || INT(1))
+	prf.Write(salt)
+	prf.Write([]byte{0, 0, 0, 1})
+	u := prf.Sum(nil)
+
+	key := make([]byte, len(u))
+	copy(key, u)
+
+	for i := 1; i < iterations; i++ {
+		prf.Reset()
+		prf.Write(u)
+		u = prf.Sum(u[:0])
+		for j := range key {
+			key[j] ^= u[j]
+		}
 	}
-	
+
 	return key
 }
-
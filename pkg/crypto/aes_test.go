@@ -0,0 +1,39 @@
+package crypto
+
+import (
+	"encoding/hex"
+	"testing"
+)
+
+func TestPBKDF2SHA256_KnownAnswers(t *testing.T) {
+	// PBKDF2-HMAC-SHA256 vectors from RFC 7914 section 11 and the widely
+	// published RFC 6070 inputs, truncated to the 32 bytes derived here
+	tests := []struct {
+		password, salt string
+		iterations     int
+		want           string
+	}{
+		{"passwd", "salt", 1, "55ac046e56e3089fec1691c22544b605f94185216dde0465e68b9d57c20dacbc"},
+		{"Password", "NaCl", 80000, "4ddcd8f60b98be21830cee5ef22701f9641a4418d04c0414aeff08876b34ab56"},
+		{"password", "salt", 4096, "c5e478d59288c841aa530db6845c4c8d962893a001ce4e11a4963873aa98134a"},
+	}
+
+	for _, tt := range tests {
+		got := hex.EncodeToString(pbkdf2SHA256([]byte(tt.password), []byte(tt.salt), tt.iterations))
+		if got != tt.want {
+			t.Errorf("pbkdf2SHA256(%q, %q, %d) = %s, want %s", tt.password, tt.salt, tt.iterations, got, tt.want)
+		}
+	}
+}
+
+func TestDeriveKey(t *testing.T) {
+	key := DeriveKey([]byte("correct horse"), []byte("battery staple"))
+	want := "9db4b63eb0d9e4d0a4741d21c37ecefe2752b9299bb46e3032dde69cec121ce2"
+	if got := hex.EncodeToString(key); got != want {
+		t.Errorf("DeriveKey() = %s, want %s", got, want)
+	}
+
+	if _, err := NewAESEncryptor(key); err != nil {
+		t.Errorf("Expected a valid AES-256 key, got %v", err)
+	}
+}
@@ -0,0 +1,106 @@
+// Package crypto provides key storage helpers for Enterprise Shield.
+package crypto
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/enterprise/opencode-enterprise-shield/pkg/paths"
+)
+
+// LoadOrCreateKey loads a 256-bit symmetric key from path, generating and
+// saving a new one (mode 0600) if the file does not exist.
+func LoadOrCreateKey(path string) ([]byte, error) {
+	path, err := paths.ExpandHome(path)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := os.ReadFile(path)
+	if err == nil {
+		if len(key) != 32 {
+			return nil, fmt.Errorf("key file %s must contain 32 bytes", path)
+		}
+		return key, nil
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("failed to read key file: %w", err)
+	}
+
+	key, err = GenerateKey()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate key: %w", err)
+	}
+	if err := writeKeyFile(path, key); err != nil {
+		if errors.Is(err, os.ErrExist) {
+			// Another process created the key first
+			return LoadOrCreateKey(path)
+		}
+		return nil, err
+	}
+	return key, nil
+}
+
+// LoadOrCreateSigningKey loads an Ed25519 private key from path, generating
+// and saving a new one (mode 0600) if the file does not exist.
+func LoadOrCreateSigningKey(path string) (ed25519.PrivateKey, error) {
+	path, err := paths.ExpandHome(path)
+	if err != nil {
+		return nil, err
+	}
+
+	seed, err := os.ReadFile(path)
+	if err == nil {
+		if len(seed) != ed25519.SeedSize {
+			return nil, fmt.Errorf("signing key file %s must contain %d bytes", path, ed25519.SeedSize)
+		}
+		return ed25519.NewKeyFromSeed(seed), nil
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("failed to read signing key file: %w", err)
+	}
+
+	_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate signing key: %w", err)
+	}
+	if err := writeKeyFile(path, privateKey.Seed()); err != nil {
+		if errors.Is(err, os.ErrExist) {
+			// Another process created the key first
+			return LoadOrCreateSigningKey(path)
+		}
+		return nil, err
+	}
+	return privateKey, nil
+}
+
+// KeyFingerprint identifies a public key, in the form OpenSSH uses:
+// "SHA256:" and the unpadded base64 SHA-256 of the key.
+func KeyFingerprint(key ed25519.PublicKey) string {
+	sum := sha256.Sum256(key)
+	return "SHA256:" + base64.RawStdEncoding.EncodeToString(sum[:])
+}
+
+// writeKeyFile creates a key file readable only by the current user.
+// It never overwrites an existing file.
+func writeKeyFile(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("failed to create key directory: %w", err)
+	}
+
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to create key file: %w", err)
+	}
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to write key file: %w", err)
+	}
+	return file.Close()
+}
@@ -0,0 +1,338 @@
+// Package decode provides bounded discovery and decoding of encoded
+// segments (base64, URL and hex escapes) in content, so detectors can scan
+// what the encoded text hides.
+package decode
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Encodings of segments.
+const (
+	Base64 = "base64"
+	URL    = "url"
+	Hex    = "hex"
+)
+
+// Limits bound the work of decoding. Decoding never expands content, but
+// nested encodings multiply the text to scan, so the total is capped. Zero
+// fields take their default.
+type Limits struct {
+	Disabled       bool `yaml:"disabled"`       // Scan literal text only
+	MaxDepth       int  `yaml:"maxDepth"`       // Nested encodings followed
+	MinLength      int  `yaml:"minLength"`      // Shortest base64 or hex segment considered
+	MaxSegmentSize int  `yaml:"maxSegmentSize"` // Longest encoded segment decoded, in bytes
+	MaxTotalSize   int  `yaml:"maxTotalSize"`   // Decoded bytes per call, across every segment and depth
+	MaxSegments    int  `yaml:"maxSegments"`    // Segments decoded per call
+}
+
+// DefaultLimits returns the default decoding limits.
+func DefaultLimits() Limits {
+	return Limits{
+		MaxDepth:       3,
+		MinLength:      8,
+		MaxSegmentSize: 64 * 1024,
+		MaxTotalSize:   1024 * 1024,
+		MaxSegments:    256,
+	}
+}
+
+// WithDefaults checks limits and fills zero fields with their defaults.
+func (l Limits) WithDefaults() (Limits, error) {
+	if l.MaxDepth < 0 || l.MinLength < 0 || l.MaxSegmentSize < 0 || l.MaxTotalSize < 0 || l.MaxSegments < 0 {
+		return l, fmt.Errorf("decode limits must not be negative")
+	}
+
+	defaults := DefaultLimits()
+	if l.MaxDepth == 0 {
+		l.MaxDepth = defaults.MaxDepth
+	}
+	if l.MinLength == 0 {
+		l.MinLength = defaults.MinLength
+	}
+	if l.MaxSegmentSize == 0 {
+		l.MaxSegmentSize = defaults.MaxSegmentSize
+	}
+	if l.MaxTotalSize == 0 {
+		l.MaxTotalSize = defaults.MaxTotalSize
+	}
+	if l.MaxSegments == 0 {
+		l.MaxSegments = defaults.MaxSegments
+	}
+	return l, nil
+}
+
+// Segment is an encoded span of content and its decoded text.
+type Segment struct {
+	Start, End int       // Span of the encoded text
+	Encoding   string    // Base64, URL or Hex
+	Decoded    string    // Decoded text
+	Nested     []Segment // Segments found in Decoded, positioned within it
+
+	encode  func(string) string
+	offsets []int // Offset in the encoded text of each decoded byte, and of the end; nil if bytes cannot be told apart
+}
+
+// Encode encodes text the way the segment was encoded, so a sanitized
+// decoded text can replace the original span. URL segments keep the
+// original encoding of the bytes that did not change.
+func (s Segment) Encode(text string) string {
+	return s.encode(text)
+}
+
+// Span maps the byte span [start, end) of the decoded text to the span of
+// content it was decoded from. In URL segments that is the span of those
+// bytes; in base64 and hex segments, whose encoded bytes do not line up with
+// decoded ones, it is the whole segment.
+func (s Segment) Span(start, end int) (int, int) {
+	if s.offsets == nil {
+		return s.Start, s.End
+	}
+	last := len(s.offsets) - 1
+	start, end = min(max(start, 0), last), min(max(end, start), last)
+	return s.Start + s.offsets[start], s.Start + s.offsets[end]
+}
+
+// JoinEncodings describes a finding in nested encodings, outermost first,
+// e.g. "url+base64". Either may be empty.
+func JoinEncodings(outer, inner string) string {
+	if outer == "" {
+		return inner
+	}
+	if inner == "" {
+		return outer
+	}
+	return outer + "+" + inner
+}
+
+// Candidate patterns. Only candidates that decode to printable text become
+// segments, which rules out words, identifiers, hashes and binary blobs.
+var (
+	base64Pattern    = regexp.MustCompile(`[A-Za-z0-9+/_-]+={0,2}`)
+	urlPattern       = regexp.MustCompile(`[^\s"'<>%]*(?:%[0-9A-Fa-f]{2}[^\s"'<>%]*)+`)
+	hexEscapePattern = regexp.MustCompile(`(?:\\x[0-9A-Fa-f]{2})+`)
+	hexPattern       = regexp.MustCompile(`\b(?:[0-9A-Fa-f]{2})+\b`)
+)
+
+// budget is what is left of the limits during one call to Find.
+type budget struct {
+	bytes, segments int
+}
+
+// Find returns the encoded segments of content that decode to printable
+// text, following nested encodings up to limits.MaxDepth. Segments do not
+// overlap; where candidates do, the longest is decoded. Once the size or
+// segment budget is spent, the remaining candidates are left undecoded.
+func Find(content string, limits Limits) []Segment {
+	if limits.Disabled {
+		return nil
+	}
+	b := &budget{bytes: limits.MaxTotalSize, segments: limits.MaxSegments}
+	return find(content, limits, 1, b)
+}
+
+// candidate is a span that may be encoded.
+type candidate struct {
+	start, end int
+	encoding   string
+}
+
+func find(content string, limits Limits, depth int, b *budget) []Segment {
+	if depth > limits.MaxDepth {
+		return nil
+	}
+
+	var candidates []candidate
+	add := func(pattern *regexp.Regexp, encoding string, minLength int) {
+		for _, loc := range pattern.FindAllStringIndex(content, -1) {
+			if loc[1]-loc[0] >= minLength {
+				candidates = append(candidates, candidate{loc[0], loc[1], encoding})
+			}
+		}
+	}
+	add(base64Pattern, Base64, limits.MinLength)
+	add(urlPattern, URL, 3)
+	add(hexEscapePattern, Hex, 4*2)
+	add(hexPattern, Hex, max(limits.MinLength, 2))
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].start != candidates[j].start {
+			return candidates[i].start < candidates[j].start
+		}
+		return candidates[i].end-candidates[i].start > candidates[j].end-candidates[j].start
+	})
+
+	var segments []Segment
+	taken := 0
+	for _, c := range candidates {
+		if c.start < taken || c.end-c.start > limits.MaxSegmentSize {
+			continue
+		}
+		if b.segments <= 0 {
+			break
+		}
+
+		encoded := content[c.start:c.end]
+		var segment Segment
+		var ok bool
+		if c.encoding == URL {
+			segment, ok = decodeURL(encoded)
+		} else {
+			segment.Decoded, segment.encode, ok = decodeCandidate(encoded, c.encoding)
+		}
+		if !ok || !printable(segment.Decoded) {
+			continue
+		}
+		if len(segment.Decoded) > b.bytes {
+			break
+		}
+		b.bytes -= len(segment.Decoded)
+		b.segments--
+
+		segment.Start, segment.End, segment.Encoding = c.start, c.end, c.encoding
+		segment.Nested = find(segment.Decoded, limits, depth+1, b)
+		segments = append(segments, segment)
+		taken = c.end
+	}
+	return segments
+}
+
+// decodeCandidate decodes a base64 or hex candidate and returns a function
+// that encodes text the same way.
+func decodeCandidate(encoded, encoding string) (string, func(string) string, bool) {
+	switch encoding {
+	case Base64:
+		return decodeBase64(encoded)
+	case Hex:
+		return decodeHex(encoded)
+	}
+	return "", nil, false
+}
+
+// decodeURL decodes %XX escapes, recording where each decoded byte came
+// from. Only escapes are decoded; every other byte, including '+', is kept.
+// Re-encoding keeps the unchanged prefix and suffix of the token as they
+// were and escapes only what is unsafe in the changed middle, so replacing
+// a value inside a URL leaves the rest of the URL intact.
+func decodeURL(encoded string) (Segment, bool) {
+	decoded := make([]byte, 0, len(encoded))
+	offsets := make([]int, 0, len(encoded)+1)
+	for i := 0; i < len(encoded); {
+		offsets = append(offsets, i)
+		if encoded[i] == '%' && i+2 < len(encoded) && isHex(encoded[i+1]) && isHex(encoded[i+2]) {
+			b, _ := hex.DecodeString(encoded[i+1 : i+3])
+			decoded = append(decoded, b[0])
+			i += 3
+			continue
+		}
+		decoded = append(decoded, encoded[i])
+		i++
+	}
+	offsets = append(offsets, len(encoded))
+	if len(decoded) == len(encoded) {
+		return Segment{}, false
+	}
+
+	original := string(decoded)
+	encode := func(text string) string {
+		prefix := 0
+		for prefix < len(text) && prefix < len(original) && text[prefix] == original[prefix] {
+			prefix++
+		}
+		suffix := 0
+		for suffix < len(text)-prefix && suffix < len(original)-prefix &&
+			text[len(text)-1-suffix] == original[len(original)-1-suffix] {
+			suffix++
+		}
+		return encoded[:offsets[prefix]] + escapeURL(text[prefix:len(text)-suffix]) + encoded[offsets[len(original)-suffix]:]
+	}
+	return Segment{Decoded: original, encode: encode, offsets: offsets}, true
+}
+
+// escapeURL escapes the bytes that cannot appear literally in a URL token:
+// '%', whitespace, quotes, angle brackets, control and non-ASCII bytes.
+func escapeURL(text string) string {
+	var sb strings.Builder
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		if c <= ' ' || c >= 0x7F || strings.IndexByte(`%"'<>`, c) >= 0 {
+			fmt.Fprintf(&sb, "%%%02X", c)
+			continue
+		}
+		sb.WriteByte(c)
+	}
+	return sb.String()
+}
+
+// isHex reports whether c is a hex digit.
+func isHex(c byte) bool {
+	return '0' <= c && c <= '9' || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F'
+}
+
+// decodeBase64 decodes standard or URL-safe base64, padded or not.
+func decodeBase64(encoded string) (string, func(string) string, bool) {
+	enc := base64.StdEncoding
+	if strings.ContainsAny(encoded, "-_") {
+		enc = base64.URLEncoding
+	}
+	if !strings.HasSuffix(encoded, "=") && len(encoded)%4 != 0 {
+		enc = enc.WithPadding(base64.NoPadding)
+	}
+	enc = enc.Strict()
+
+	decoded, err := enc.DecodeString(encoded)
+	if err != nil {
+		return "", nil, false
+	}
+	return string(decoded), func(text string) string { return enc.EncodeToString([]byte(text)) }, true
+}
+
+// decodeHex decodes plain hex or \x escapes, keeping the letter case.
+func decodeHex(encoded string) (string, func(string) string, bool) {
+	escaped := strings.HasPrefix(encoded, `\x`)
+	digits := encoded
+	if escaped {
+		digits = strings.ReplaceAll(encoded, `\x`, "")
+	}
+	decoded, err := hex.DecodeString(digits)
+	if err != nil {
+		return "", nil, false
+	}
+
+	upper := strings.ToUpper(digits) == digits && strings.ToLower(digits) != digits
+	encode := func(text string) string {
+		out := hex.EncodeToString([]byte(text))
+		if upper {
+			out = strings.ToUpper(out)
+		}
+		if escaped {
+			var sb strings.Builder
+			for i := 0; i < len(out); i += 2 {
+				sb.WriteString(`\x`)
+				sb.WriteString(out[i : i+2])
+			}
+			out = sb.String()
+		}
+		return out
+	}
+	return string(decoded), encode, true
+}
+
+// printable reports whether decoded bytes are text worth scanning.
+func printable(s string) bool {
+	if len(s) == 0 || !utf8.ValidString(s) {
+		return false
+	}
+	for _, r := range s {
+		if !unicode.IsPrint(r) && r != '\n' && r != '\r' && r != '\t' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,130 @@
+package decode
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// found is the part of a segment tests compare.
+type found struct {
+	Encoded, Encoding, Decoded string
+}
+
+func summarize(content string, segments []Segment) []found {
+	list := []found{}
+	for _, s := range segments {
+		list = append(list, found{content[s.Start:s.End], s.Encoding, s.Decoded})
+	}
+	return list
+}
+
+func TestFind(t *testing.T) {
+	b64 := base64.StdEncoding.EncodeToString([]byte("user:hunter2"))
+	raw := base64.RawURLEncoding.EncodeToString([]byte("token?id=42"))
+	hexText := hex.EncodeToString([]byte("password"))
+	content := "auth " + b64 + " jwt " + raw + " q=a%20b%2Fc hex " + hexText + ` esc \x41\x4b\x49\x41` +
+		" sha 3f786850e387550fdab836ed7e6dc881de23001b word Kubernetes"
+
+	want := []found{
+		{b64, Base64, "user:hunter2"},
+		{raw, Base64, "token?id=42"},
+		{"q=a%20b%2Fc", URL, "q=a b/c"},
+		{hexText, Hex, "password"},
+		{`\x41\x4b\x49\x41`, Hex, "AKIA"},
+	}
+	if got := summarize(content, Find(content, DefaultLimits())); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %+v, got %+v", want, got)
+	}
+
+	if got := Find(content, Limits{Disabled: true}); got != nil {
+		t.Errorf("Expected no segments when disabled, got %+v", got)
+	}
+}
+
+func TestFind_NestedAndEncode(t *testing.T) {
+	inner := "ssn%3A123-45-6789"
+	content := "v=" + base64.StdEncoding.EncodeToString([]byte(inner))
+
+	segments := Find(content, DefaultLimits())
+	if len(segments) != 1 || segments[0].Decoded != inner {
+		t.Fatalf("Expected one base64 segment, got %+v", segments)
+	}
+	nested := segments[0].Nested
+	if len(nested) != 1 || nested[0].Encoding != URL || nested[0].Decoded != "ssn:123-45-6789" {
+		t.Fatalf("Expected a nested URL segment, got %+v", nested)
+	}
+
+	// Segments re-encode the way they were encoded
+	if got := segments[0].Encode(inner); got != content[2:] {
+		t.Errorf("Expected %s, got %s", content[2:], got)
+	}
+	escaped := Find(`\x41\x4B`, DefaultLimits())[0]
+	if got := escaped.Encode("OK"); got != `\x4F\x4B` {
+		t.Errorf("Expected upper-case escapes, got %s", got)
+	}
+
+	// Depth limits stop at the outer layer
+	limits, err := Limits{MaxDepth: 1}.WithDefaults()
+	if err != nil {
+		t.Fatalf("WithDefaults failed: %v", err)
+	}
+	if segments := Find(content, limits); len(segments) != 1 || segments[0].Nested != nil {
+		t.Errorf("Expected no nested segments at depth 1, got %+v", segments)
+	}
+}
+
+func TestFind_Budget(t *testing.T) {
+	one := base64.StdEncoding.EncodeToString([]byte("first secret"))
+	two := base64.StdEncoding.EncodeToString([]byte("second secret"))
+	content := one + " " + two
+
+	limits, _ := Limits{MaxSegments: 1}.WithDefaults()
+	if got := Find(content, limits); len(got) != 1 {
+		t.Errorf("Expected the segment budget to stop after one segment, got %+v", got)
+	}
+
+	limits, _ = Limits{MaxTotalSize: len("first secret")}.WithDefaults()
+	if got := Find(content, limits); len(got) != 1 {
+		t.Errorf("Expected the size budget to stop after one segment, got %+v", got)
+	}
+
+	limits, _ = Limits{MaxSegmentSize: len(one) - 1}.WithDefaults()
+	if got := Find(content, limits); len(got) != 0 {
+		t.Errorf("Expected oversized segments to be skipped, got %+v", got)
+	}
+
+	if _, err := (Limits{MaxDepth: -1}).WithDefaults(); err == nil {
+		t.Error("Expected negative limits to be rejected")
+	}
+}
+
+func TestFind_URLKeepsUnescapedBytes(t *testing.T) {
+	content := "GET https://api.example.com/v1/query?host=10.0.0.1%20x&y=1 now"
+	segments := Find(content, DefaultLimits())
+	if len(segments) != 1 || segments[0].Encoding != URL {
+		t.Fatalf("Expected one URL segment, got %+v", segments)
+	}
+	segment := segments[0]
+
+	// Decoded offsets map to the bytes they came from
+	at := strings.Index(segment.Decoded, "10.0.0.1 x")
+	start, end := segment.Span(at, at+len("10.0.0.1 x"))
+	if got := content[start:end]; got != "10.0.0.1%20x" {
+		t.Errorf("Expected the span of the escaped text, got %q", got)
+	}
+
+	// Re-encoding keeps the URL as it was and escapes only what changed
+	changed := strings.Replace(segment.Decoded, "10.0.0.1", "IP_0", 1)
+	if got := segment.Encode(changed); got != "https://api.example.com/v1/query?host=IP_0%20x&y=1" {
+		t.Errorf("Expected only the value replaced, got %q", got)
+	}
+	if got := segment.Encode(strings.Replace(segment.Decoded, "host", "a b", 1)); got != "https://api.example.com/v1/query?a%20b=10.0.0.1%20x&y=1" {
+		t.Errorf("Expected unsafe bytes in the change escaped, got %q", got)
+	}
+	if got := segment.Encode(segment.Decoded); got != content[segment.Start:segment.End] {
+		t.Errorf("Expected an unchanged segment to encode as it was, got %q", got)
+	}
+}
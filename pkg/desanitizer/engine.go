@@ -28,7 +28,9 @@ func (e *Engine) Desanitize(content string, session *types.Session) types.Desani
 		UnmatchedAliases:   make([]string, 0),
 	}
 
-	if len(session.ReverseMappings) == 0 {
+	// Work from a snapshot so concurrent sanitization can keep adding mappings
+	reverseMappings := session.ReverseMappingsSnapshot()
+	if len(reverseMappings) == 0 {
 		result.ProcessingTimeMs = time.Since(startTime).Milliseconds()
 		return result
 	}
@@ -37,8 +39,8 @@ func (e *Engine) Desanitize(content string, session *types.Session) types.Desani
 
 	// Build list of aliases to replace, sorted by length (longest first)
 	// This prevents partial replacements (e.g., SERVER_10 before SERVER_1)
-	aliases := make([]string, 0, len(session.ReverseMappings))
-	for alias := range session.ReverseMappings {
+	aliases := make([]string, 0, len(reverseMappings))
+	for alias := range reverseMappings {
 		aliases = append(aliases, alias)
 	}
 	sort.Slice(aliases, func(i, j int) bool {
@@ -59,7 +61,7 @@ func (e *Engine) Desanitize(content string, session *types.Session) types.Desani
 		if err != nil {
 			// Fallback to simple string replacement
 			for _, alias := range aliases {
-				original, ok := session.GetOriginal(alias)
+				original, ok := reverseMappings[alias]
 				if !ok {
 					result.UnmatchedAliases = append(result.UnmatchedAliases, alias)
 					continue
@@ -72,7 +74,7 @@ func (e *Engine) Desanitize(content string, session *types.Session) types.Desani
 		} else {
 			// Use regex replacement
 			workingContent = re.ReplaceAllStringFunc(workingContent, func(match string) string {
-				original, ok := session.GetOriginal(match)
+				original, ok := reverseMappings[match]
 				if !ok {
 					result.UnmatchedAliases = append(result.UnmatchedAliases, match)
 					return match
@@ -2,16 +2,21 @@
 package hooks
 
 import (
+	"crypto/ed25519"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/enterprise/opencode-enterprise-shield/pkg/audit"
 	"github.com/enterprise/opencode-enterprise-shield/pkg/compliance"
+	"github.com/enterprise/opencode-enterprise-shield/pkg/crypto"
+	"github.com/enterprise/opencode-enterprise-shield/pkg/decode"
 	"github.com/enterprise/opencode-enterprise-shield/pkg/desanitizer"
 	"github.com/enterprise/opencode-enterprise-shield/pkg/policy"
 	"github.com/enterprise/opencode-enterprise-shield/pkg/sanitizer"
 	"github.com/enterprise/opencode-enterprise-shield/pkg/session"
 	"github.com/enterprise/opencode-enterprise-shield/pkg/types"
+	"github.com/enterprise/opencode-enterprise-shield/pkg/usage"
 )
 
 // Shield is the main Enterprise Shield middleware.
@@ -23,6 +28,11 @@ type Shield struct {
 	policyEngine   *policy.Engine
 	auditLogger    *audit.Logger
 	config         *Config
+
+	usage *usage.Store
+
+	frameworkRules     []string            // Sanitization rules of enabled compliance frameworks
+	sessionTrustedKeys []ed25519.PublicKey // Keys session bundles may be signed with
 }
 
 // Config holds the Shield configuration.
@@ -30,10 +40,67 @@ type Config struct {
 	Enabled         bool          `yaml:"enabled"`
 	SessionTTL      time.Duration `yaml:"sessionTTL"`
 	MaxMappings     int           `yaml:"maxMappings"`
+	JanitorInterval time.Duration `yaml:"janitorInterval"`
+	SlidingTTL      bool          `yaml:"slidingTTL"`
+	MaxLifetime     time.Duration `yaml:"maxLifetime"`
+	SessionStore    string        `yaml:"sessionStore"`
+	EncryptSessions bool          `yaml:"encryptSessions"`
+	SessionKeyPath  string        `yaml:"sessionKeyPath"`
+	SigningKeyPath  string        `yaml:"signingKeyPath"`
 	BlockOnCritical bool          `yaml:"blockOnCritical"`
 	AuditLogPath    string        `yaml:"auditLogPath"`
 	SignAuditLogs   bool          `yaml:"signAuditLogs"`
 	RetentionDays   int           `yaml:"retentionDays"`
+
+	// Frameworks enables compliance framework profiles such as "pci-dss",
+	// which bundle detectors, severities, sanitization rules and a minimum
+	// audit retention, and tag findings with the framework's controls
+	Frameworks []string `yaml:"frameworks"`
+
+	// DetectorActions sets the action for compliance findings by detector
+	// ID or finding type, e.g. "ssn": redact
+	DetectorActions map[string]types.FindingAction `yaml:"detectorActions"`
+
+	// TestDataActions sets the action for findings of well-known test data,
+	// such as test card numbers, by detector ID or finding type
+	TestDataActions map[string]types.FindingAction `yaml:"testDataActions"`
+
+	// DetectorProfiles enables detector sets that are off by default, such
+	// as those for a region
+	DetectorProfiles []string `yaml:"detectorProfiles"`
+
+	// MRNPatterns adds regular expressions for the organization's medical
+	// record numbers to the built-in one
+	MRNPatterns []string `yaml:"mrnPatterns"`
+
+	// Entropy tunes detection of random-looking secrets; nil keeps defaults
+	Entropy *compliance.EntropyConfig `yaml:"entropy"`
+
+	// Decode bounds decoding of encoded segments before scanning and
+	// sanitizing; nil keeps defaults
+	Decode *decode.Limits `yaml:"decode"`
+
+	// DefaultAccessLevel overrides the built-in default policy's access level
+	DefaultAccessLevel   types.AccessLevel `yaml:"defaultAccessLevel"`
+	PolicyFile           string            `yaml:"policyFile"`
+	PolicyReloadInterval time.Duration     `yaml:"policyReloadInterval"`
+
+	// RuleProfiles adds or replaces named rule sets policies can require
+	RuleProfiles map[string][]string `yaml:"ruleProfiles"`
+
+	// Break-glass overrides are honored only if signed by one of
+	// OverrideTrustedKeys (base64 Ed25519 public keys). Administrators issue
+	// them with the private key at OverrideSigningKeyPath.
+	OverrideRevocations    string   `yaml:"overrideRevocations"`
+	OverrideTrustedKeys    []string `yaml:"overrideTrustedKeys"`
+	OverrideSigningKeyPath string   `yaml:"overrideSigningKeyPath"`
+
+	// SessionTrustedKeys (base64 Ed25519 public keys) sign the session
+	// bundles that may be imported
+	SessionTrustedKeys []string `yaml:"sessionTrustedKeys"`
+
+	// UsageStore counts tokens for token budgets; empty keeps counts in memory
+	UsageStore string `yaml:"usageStore"`
 }
 
 // DefaultConfig returns the default configuration.
@@ -42,10 +109,23 @@ func DefaultConfig() *Config {
 		Enabled:         true,
 		SessionTTL:      8 * time.Hour,
 		MaxMappings:     10000,
+		JanitorInterval: 5 * time.Minute,
+		SlidingTTL:      false,
+		MaxLifetime:     24 * time.Hour,
+		SessionStore:    "~/.opencode/sessions/enterprise-shield",
+		EncryptSessions: true,
+		SessionKeyPath:  "~/.opencode/keys/enterprise-shield-session.key",
+		SigningKeyPath:  "~/.opencode/keys/enterprise-shield-signing.key",
 		BlockOnCritical: true,
 		AuditLogPath:    "~/.opencode/logs/enterprise-shield",
 		SignAuditLogs:   true,
 		RetentionDays:   365,
+
+		DefaultAccessLevel:   types.AccessSanitizedOnly,
+		PolicyReloadInterval: 30 * time.Second,
+
+		OverrideRevocations: "~/.opencode/policy/enterprise-shield-revoked-overrides.json",
+		UsageStore:          "~/.opencode/usage/enterprise-shield-usage.json",
 	}
 }
 
@@ -62,13 +142,104 @@ func NewShield(config *Config) (*Shield, error) {
 	sessionManager := session.NewManager(config.SessionTTL, config.MaxMappings)
 	policyEngine := policy.NewEngine()
 
+	// Frameworks first, so explicitly configured detectors refine them
+	var frameworkRules []string
+	retentionDays := config.RetentionDays
+	for _, name := range config.Frameworks {
+		framework, err := complianceDetector.EnableFramework(name)
+		if err != nil {
+			return nil, fmt.Errorf("invalid compliance framework: %w", err)
+		}
+		frameworkRules = append(frameworkRules, framework.SanitizationRules...)
+		retentionDays = max(retentionDays, framework.MinRetentionDays)
+	}
+	if err := sanitizerEngine.CheckSelection(sanitizer.RuleSelection{Rules: frameworkRules}); err != nil {
+		return nil, fmt.Errorf("invalid compliance framework: %w", err)
+	}
+
+	for _, profile := range config.DetectorProfiles {
+		if err := complianceDetector.EnableProfile(profile); err != nil {
+			return nil, fmt.Errorf("invalid detector profile: %w", err)
+		}
+	}
+	if len(config.MRNPatterns) > 0 {
+		if err := complianceDetector.SetMRNPatterns(config.MRNPatterns); err != nil {
+			return nil, fmt.Errorf("invalid MRN patterns: %w", err)
+		}
+	}
+	if config.Entropy != nil {
+		if err := complianceDetector.SetEntropy(*config.Entropy); err != nil {
+			return nil, fmt.Errorf("invalid entropy settings: %w", err)
+		}
+	}
+	if config.Decode != nil {
+		if err := complianceDetector.SetDecodeLimits(*config.Decode); err != nil {
+			return nil, fmt.Errorf("invalid decode limits: %w", err)
+		}
+		if err := sanitizerEngine.SetDecodeLimits(*config.Decode); err != nil {
+			return nil, fmt.Errorf("invalid decode limits: %w", err)
+		}
+	}
+	for name, action := range config.DetectorActions {
+		if err := complianceDetector.SetAction(name, action); err != nil {
+			return nil, fmt.Errorf("invalid detector action: %w", err)
+		}
+	}
+	for name, action := range config.TestDataActions {
+		if err := complianceDetector.SetTestAction(name, action); err != nil {
+			return nil, fmt.Errorf("invalid test data action: %w", err)
+		}
+	}
+
+	for name, ruleIDs := range config.RuleProfiles {
+		if err := sanitizerEngine.SetProfile(name, ruleIDs); err != nil {
+			return nil, fmt.Errorf("invalid rule profile: %w", err)
+		}
+	}
+
+	// Apply configured default access level, then the policy file on top
+	if config.DefaultAccessLevel != "" {
+		if err := policyEngine.SetDefaultAccessLevel(config.DefaultAccessLevel); err != nil {
+			return nil, fmt.Errorf("invalid policy configuration: %w", err)
+		}
+	}
+	if config.PolicyFile != "" {
+		if err := policyEngine.LoadFile(config.PolicyFile); err != nil {
+			return nil, fmt.Errorf("failed to load policy file: %w", err)
+		}
+	}
+
+	if err := configureOverrides(policyEngine, config); err != nil {
+		return nil, fmt.Errorf("failed to configure break-glass overrides: %w", err)
+	}
+	sessionTrustedKeys, err := parseTrustedKeys(config.SessionTrustedKeys)
+	if err != nil {
+		return nil, fmt.Errorf("invalid session trusted keys: %w", err)
+	}
+
+	// Persist sessions so other processes (e.g. the CLI) can see them
+	if config.SessionStore != "" {
+		files, err := newSessionFileStore(config)
+		if err != nil {
+			return nil, fmt.Errorf("failed to initialize session store: %w", err)
+		}
+		if err := sessionManager.SetPersistence(files); err != nil {
+			return nil, fmt.Errorf("failed to load persisted sessions: %w", err)
+		}
+	}
+
+	usageStore, err := usage.Open(config.UsageStore)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open usage store: %w", err)
+	}
+
 	// Initialize audit logger
-	auditLogger, err := audit.NewLogger(config.AuditLogPath, config.SignAuditLogs, config.RetentionDays)
+	auditLogger, err := audit.NewLogger(config.AuditLogPath, config.SignAuditLogs, retentionDays)
 	if err != nil {
 		return nil, fmt.Errorf("failed to initialize audit logger: %w", err)
 	}
 
-	return &Shield{
+	shield := &Shield{
 		sanitizer:      sanitizerEngine,
 		desanitizer:    desanitizerEngine,
 		compliance:     complianceDetector,
@@ -76,7 +247,24 @@ func NewShield(config *Config) (*Shield, error) {
 		policyEngine:   policyEngine,
 		auditLogger:    auditLogger,
 		config:         config,
-	}, nil
+
+		usage: usageStore,
+
+		frameworkRules:     frameworkRules,
+		sessionTrustedKeys: sessionTrustedKeys,
+	}
+
+	// Session expiry: sliding TTL, audit on expiry, background cleanup
+	if config.SlidingTTL {
+		sessionManager.SetSlidingExpiry(config.MaxLifetime)
+	}
+	sessionManager.SetExpiryCallback(shield.logSessionExpired)
+	sessionManager.StartJanitor(config.JanitorInterval)
+
+	// Pick up policy file edits without a restart
+	policyEngine.StartWatcher(config.PolicyReloadInterval, shield.logPolicyReload)
+
+	return shield, nil
 }
 
 // ProcessRequest processes an outgoing request (before sending to LLM).
@@ -93,59 +281,123 @@ func (s *Shield) ProcessRequest(req types.Request) types.Response {
 		return response
 	}
 
-	// Step 1: Policy check
+	// Step 1: Compliance scan, so policy conditions can see its findings
+	complianceResult := s.compliance.Scan(req.Content)
+
+	// Step 2: Policy check
 	policyCtx := policy.PolicyContext{
 		UserID:     req.UserID,
 		Department: req.Department,
 		Provider:   req.Provider,
+		Model:      req.Model,
 		Content:    req.Content,
+		SourceIP:   req.SourceIP,
+		Violations: complianceResult.Violations,
+		Time:       startTime,
+		Scope:      req.Scope,
+		Override:   req.Override,
 	}
 	policyDecision := s.policyEngine.Evaluate(policyCtx)
 
 	if policyDecision.Action == types.ActionBlock {
 		response.Blocked = true
 		response.BlockReason = policyDecision.Reason
-		s.logRequest(req, response, policyDecision.Action, nil, time.Since(startTime).Milliseconds())
+		s.logRequest(req, response, policyDecision, policyDecision.Action, nil, time.Since(startTime).Milliseconds())
 		return response
 	}
 
-	// Step 2: Compliance scan
-	complianceResult := s.compliance.Scan(req.Content)
 	if complianceResult.ShouldBlock {
 		response.Blocked = true
 		response.BlockReason = "Critical compliance violation detected"
 		response.Violations = complianceResult.Violations
-		s.logRequest(req, response, types.ActionBlock, complianceResult.Violations, time.Since(startTime).Milliseconds())
+		s.logRequest(req, response, policyDecision, types.ActionBlock, complianceResult.Violations, time.Since(startTime).Milliseconds())
 		return response
 	}
 
 	// Step 3: Get or create session
-	sess, _ := s.sessionManager.GetOrCreate(req.UserID, req.Department, req.SessionID)
+	sess, created := s.sessionManager.GetOrCreate(req.UserID, req.Department, req.Scope, req.SessionID)
 	response.SessionID = sess.SessionID
 
-	// Step 4: Sanitization (if required)
+	// Step 4: Redact or alias compliance findings configured not to block
+	mappings := make(map[string]string)
+	content, rewritten := compliance.Rewrite(req.Content, complianceResult.Violations, func(value, findingType string) string {
+		alias, isNew := s.sanitizer.Alias(sess, value, findingType)
+		if isNew {
+			mappings[value] = alias
+		}
+		return alias
+	})
+	response.Violations = append(response.Violations, rewritten...)
+	response.WasSanitized = len(rewritten) > 0
+
+	// Step 5: Sanitization (if required)
+	var sanitizeWarnings []types.Warning
+	var sanitizeViolations []types.Violation
 	if policyDecision.Action == types.ActionAllowWithSanitization {
-		sanitizeResult := s.sanitizer.Sanitize(req.Content, sess)
-		
+		selection := sanitizer.RuleSelection{
+			Rules:    append(append([]string(nil), policyDecision.RequiredSanitization...), s.frameworkRules...),
+			Profiles: policyDecision.RuleProfiles,
+		}
+		sanitizeResult, err := s.sanitizer.SanitizeWithRules(content, sess, selection)
+		if err != nil {
+			// Fail closed: never send content the policy could not sanitize
+			response.Blocked = true
+			response.BlockReason = fmt.Sprintf("Required sanitization unavailable: %v", err)
+			s.logRequest(req, response, policyDecision, types.ActionBlock, nil, time.Since(startTime).Milliseconds())
+			return response
+		}
+
+		s.compliance.TagControls(sanitizeResult.Violations)
+
 		if sanitizeResult.ShouldBlock {
 			response.Blocked = true
 			response.BlockReason = sanitizeResult.BlockReason
 			response.Violations = sanitizeResult.Violations
-			s.logRequest(req, response, types.ActionBlock, sanitizeResult.Violations, time.Since(startTime).Milliseconds())
+			s.logRequest(req, response, policyDecision, types.ActionBlock, sanitizeResult.Violations, time.Since(startTime).Milliseconds())
 			return response
 		}
 
 		response.Content = sanitizeResult.SanitizedContent
-		response.WasSanitized = sanitizeResult.WasSanitized
-		response.MappingsCreated = sanitizeResult.MappingsCreated
+		response.WasSanitized = response.WasSanitized || sanitizeResult.WasSanitized
+		for original, alias := range sanitizeResult.MappingsCreated {
+			mappings[original] = alias
+		}
 		response.Violations = append(response.Violations, sanitizeResult.Violations...)
+		sanitizeWarnings = sanitizeResult.Warnings
+		sanitizeViolations = sanitizeResult.Violations
 	} else {
-		response.Content = req.Content
+		response.Content = content
+	}
+	response.MappingsCreated = mappings
+
+	// Step 6: Token budgets, charged for the content actually sent
+	blockReason, budgetWarnings := s.chargeInputTokens(req, policyDecision, usage.EstimateTokens(response.Content), startTime)
+	if blockReason != "" {
+		response.Content = ""
+		response.Blocked = true
+		response.BlockReason = blockReason
+		s.logRequest(req, response, policyDecision, types.ActionRateLimited, nil, time.Since(startTime).Milliseconds())
+		return response
+	}
+
+	if created || len(response.MappingsCreated) > 0 {
+		_ = s.sessionManager.Save(sess)
+	}
+
+	// Step 7: Warnings for the developer
+	response.Warnings = append(response.Warnings, policyDecision.Warnings...)
+	response.Warnings = append(response.Warnings, complianceResult.Warnings...)
+	response.Warnings = append(response.Warnings, sanitizeWarnings...)
+	response.Warnings = append(response.Warnings, budgetWarnings...)
+
+	action := policyDecision.Action
+	if action == types.ActionAllow && len(response.Warnings) > 0 {
+		action = types.ActionAllowWithWarning
 	}
 
 	// Log the request
-	allViolations := append(complianceResult.Violations, response.Violations...)
-	s.logRequest(req, response, policyDecision.Action, allViolations, time.Since(startTime).Milliseconds())
+	allViolations := append(complianceResult.Violations, sanitizeViolations...)
+	s.logRequest(req, response, policyDecision, action, allViolations, time.Since(startTime).Milliseconds())
 
 	return response
 }
@@ -175,11 +427,100 @@ func (s *Shield) GetSession(sessionID string) (*types.Session, bool) {
 	return s.sessionManager.Get(sessionID)
 }
 
-// ClearSession clears a user's session.
+// ClearSession clears all of a user's sessions.
 func (s *Shield) ClearSession(userID string) {
 	s.sessionManager.Clear(userID)
 }
 
+// ListSessions returns all live sessions.
+func (s *Shield) ListSessions() []*types.Session {
+	return s.sessionManager.List()
+}
+
+// ForgetMapping revokes a single alias from a session so its original value
+// can no longer be restored.
+func (s *Shield) ForgetMapping(sessionID, alias string) bool {
+	sess, ok := s.sessionManager.Get(sessionID)
+	if !ok || !s.sessionManager.Forget(sessionID, alias) {
+		return false
+	}
+
+	entry := s.auditLogger.CreateSessionEntry(types.AuditEventMappingRevoked, sess)
+	_ = s.auditLogger.LogSync(entry)
+	return true
+}
+
+// ClearSessionScope clears a user's session for one scope only.
+func (s *Shield) ClearSessionScope(userID, scope string) {
+	s.sessionManager.ClearScope(userID, scope)
+}
+
+// ExportSession produces an encrypted, signed bundle of a session's mappings
+// for hand-off to a teammate. The bundle is encrypted with a key derived from
+// passphrase and signed with the shield's signing key.
+func (s *Shield) ExportSession(sessionID, exportedBy, passphrase string) (*session.Bundle, error) {
+	sess, ok := s.sessionManager.Export(sessionID)
+	if !ok {
+		return nil, fmt.Errorf("session %s not found or expired", sessionID)
+	}
+
+	signingKey, err := crypto.LoadOrCreateSigningKey(s.config.SigningKeyPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load signing key: %w", err)
+	}
+
+	bundle, err := session.ExportBundle(sess, exportedBy, passphrase, signingKey)
+	if err != nil {
+		return nil, err
+	}
+
+	entry := s.auditLogger.CreateSessionEntry(types.AuditEventSessionExported, sess)
+	_ = s.auditLogger.LogSync(entry)
+
+	return bundle, nil
+}
+
+// ImportSession loads an exported bundle as a new session owned by userID,
+// after checking that the user may see the original values it contains. The
+// user's department comes from their policy. The bundle must be signed by
+// one of the configured session trusted keys or the given keys; with
+// neither, the import is refused.
+func (s *Shield) ImportSession(bundle *session.Bundle, passphrase, userID string, trusted ...ed25519.PublicKey) (*types.Session, error) {
+	trusted = append(append([]ed25519.PublicKey(nil), s.sessionTrustedKeys...), trusted...)
+	if len(trusted) == 0 {
+		return nil, fmt.Errorf("import refused: no trusted keys are configured (session.trustedKeys) or given")
+	}
+
+	department := s.policyEngine.UserDepartment(userID)
+	decision := s.policyEngine.CanViewOriginals(userID, department, bundle.Department)
+	if decision.Action == types.ActionBlock {
+		return nil, fmt.Errorf("import denied by policy %s: %s", strings.Join(decision.PolicyApplied, ", "), decision.Reason)
+	}
+
+	sess, err := bundle.Open(passphrase, userID, department, s.config.SessionTTL, trusted...)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.sessionManager.Import(sess); err != nil {
+		return nil, fmt.Errorf("failed to store imported session: %w", err)
+	}
+
+	entry := s.auditLogger.CreateSessionEntry(types.AuditEventSessionImported, sess)
+	_ = s.auditLogger.LogSync(entry)
+
+	return sess, nil
+}
+
+// ReloadPolicies reloads the policy file if it changed. If the file is
+// invalid the current policies stay in effect and the error is returned.
+func (s *Shield) ReloadPolicies() error {
+	reloaded, err := s.policyEngine.Reload()
+	if reloaded || err != nil {
+		s.logPolicyReload(err)
+	}
+	return err
+}
+
 // SetUserPolicy sets a user's policy.
 func (s *Shield) SetUserPolicy(userID string, policy *types.UserPolicy) {
 	s.policyEngine.SetUserPolicy(userID, policy)
@@ -188,19 +529,27 @@ func (s *Shield) SetUserPolicy(userID string, policy *types.UserPolicy) {
 // GetStats returns shield statistics.
 func (s *Shield) GetStats() ShieldStats {
 	sessionStats := s.sessionManager.GetStats()
-	return ShieldStats{
+	stats := ShieldStats{
 		SessionStats: sessionStats,
 		RulesLoaded:  len(s.sanitizer.GetRules()),
 	}
+	if tokenUsage, err := s.usage.Stats(time.Now()); err == nil {
+		stats.TokenUsage = &tokenUsage
+	}
+	return stats
 }
 
 // Close cleans up resources.
 func (s *Shield) Close() error {
+	s.sessionManager.Stop()
+	s.policyEngine.Stop()
 	return s.auditLogger.Close()
 }
 
-// logRequest logs a request to the audit log.
-func (s *Shield) logRequest(req types.Request, resp types.Response, action types.Action, violations []types.Violation, processingMs int64) {
+// logRequest logs a request to the audit log. Requests made under a
+// break-glass override, or presenting one that was refused, are recorded as
+// override events with the justification or refusal as the reason.
+func (s *Shield) logRequest(req types.Request, resp types.Response, decision types.PolicyDecision, action types.Action, violations []types.Violation, processingMs int64) {
 	entry := s.auditLogger.CreateEntry(
 		req.UserID,
 		resp.SessionID,
@@ -211,6 +560,51 @@ func (s *Shield) logRequest(req types.Request, resp types.Response, action types
 		action,
 		processingMs,
 	)
+	entry.Model = req.Model
+	if decision.Override != nil {
+		entry.Event = types.AuditEventOverrideUsed
+		entry.Reason = overrideReason(decision.Override)
+	} else if decision.OverrideError != "" {
+		entry.Event = types.AuditEventOverrideRejected
+		entry.Reason = "break-glass override not applied: " + decision.OverrideError
+	}
+	s.auditLogger.Log(entry)
+}
+
+// logSessionExpired records a session expiry in the audit log.
+func (s *Shield) logSessionExpired(sess *types.Session) {
+	entry := s.auditLogger.CreateSessionEntry(types.AuditEventSessionExpired, sess)
+	s.auditLogger.Log(entry)
+}
+
+// newSessionFileStore opens the configured session store, encrypting session
+// files with the configured key when encryption is enabled.
+func newSessionFileStore(config *Config) (*session.FileStore, error) {
+	var encryptor *crypto.AESEncryptor
+	if config.EncryptSessions {
+		key, err := crypto.LoadOrCreateKey(config.SessionKeyPath)
+		if err != nil {
+			return nil, err
+		}
+		encryptor, err = crypto.NewAESEncryptor(key)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return session.NewFileStore(config.SessionStore, encryptor)
+}
+
+// logPolicyReload records a policy file reload attempt in the audit log.
+func (s *Shield) logPolicyReload(err error) {
+	entry := types.AuditEntry{
+		Event:  types.AuditEventPolicyReloaded,
+		Action: types.ActionAllow,
+		Reason: "policy file reloaded",
+	}
+	if err != nil {
+		entry.Action = types.ActionBlock
+		entry.Reason = err.Error()
+	}
 	s.auditLogger.Log(entry)
 }
 
@@ -218,6 +612,8 @@ func (s *Shield) logRequest(req types.Request, resp types.Response, action types
 type ShieldStats struct {
 	SessionStats session.SessionStats `json:"sessionStats"`
 	RulesLoaded  int                  `json:"rulesLoaded"`
+
+	TokenUsage *usage.Stats `json:"tokenUsage,omitempty"`
 }
 
 // --- OpenCode Hook Interface ---
@@ -246,6 +642,31 @@ func (h *Hook) OnRequest(userID, content, provider string) types.Response {
 	return h.shield.ProcessRequest(req)
 }
 
+// OnScopedRequest is called before a request is sent to the LLM from a
+// specific conversation or workspace, so each gets its own session.
+func (h *Hook) OnScopedRequest(userID, scope, content, provider string) types.Response {
+	req := types.Request{
+		UserID:   userID,
+		Scope:    scope,
+		Content:  content,
+		Provider: provider,
+	}
+	return h.shield.ProcessRequest(req)
+}
+
+// OnModelRequest is like OnScopedRequest for a request to a named model, so
+// model allowlists can be enforced and the model recorded in the audit log.
+func (h *Hook) OnModelRequest(userID, scope, content, provider, model string) types.Response {
+	req := types.Request{
+		UserID:   userID,
+		Scope:    scope,
+		Content:  content,
+		Provider: provider,
+		Model:    model,
+	}
+	return h.shield.ProcessRequest(req)
+}
+
 // OnResponse is called when a response is received from the LLM.
 func (h *Hook) OnResponse(content, sessionID string) types.DesanitizationResult {
 	return h.shield.ProcessResponse(content, sessionID)
@@ -256,6 +677,12 @@ func (h *Hook) OnScan(content string) types.ComplianceResult {
 	return h.shield.ScanContent(content)
 }
 
+// Shield returns the underlying Shield, for administrative operations that
+// are not part of the request/response hook flow.
+func (h *Hook) Shield() *Shield {
+	return h.shield
+}
+
 // Close cleans up hook resources.
 func (h *Hook) Close() error {
 	return h.shield.Close()
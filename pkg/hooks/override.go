@@ -0,0 +1,108 @@
+// Package hooks provides break-glass override administration.
+package hooks
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"fmt"
+	"time"
+
+	"github.com/enterprise/opencode-enterprise-shield/pkg/crypto"
+	"github.com/enterprise/opencode-enterprise-shield/pkg/policy"
+	"github.com/enterprise/opencode-enterprise-shield/pkg/types"
+)
+
+// configureOverrides lets the policy engine verify break-glass overrides
+// signed with a configured trusted key. The shield's own signing key is not
+// trusted: it is created on every machine, so anyone could sign with it.
+func configureOverrides(engine *policy.Engine, config *Config) error {
+	keys, err := parseTrustedKeys(config.OverrideTrustedKeys)
+	if err != nil {
+		return err
+	}
+
+	var revocations *policy.RevocationList
+	if config.OverrideRevocations != "" {
+		if revocations, err = policy.NewRevocationList(config.OverrideRevocations); err != nil {
+			return err
+		}
+	}
+
+	engine.SetOverrideKeys(keys, revocations)
+	return nil
+}
+
+// IssueOverride signs a break-glass override with the administrator key at
+// OverrideSigningKeyPath, creating the key if the file does not exist, and
+// records its issue in the audit log. It returns the completed grant, the
+// token the user presents with their requests, and the public key that
+// shields must list in OverrideTrustedKeys to honor it.
+func (s *Shield) IssueOverride(grant types.Override, ttl time.Duration) (*types.Override, string, ed25519.PublicKey, error) {
+	if s.config.OverrideSigningKeyPath == "" {
+		return nil, "", nil, fmt.Errorf("no override signing key is configured (policy.overrideSigningKey)")
+	}
+	signingKey, err := crypto.LoadOrCreateSigningKey(s.config.OverrideSigningKeyPath)
+	if err != nil {
+		return nil, "", nil, fmt.Errorf("failed to load override signing key: %w", err)
+	}
+
+	issued, token, err := policy.IssueOverride(grant, ttl, signingKey)
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	entry := types.AuditEntry{
+		Event:  types.AuditEventOverrideIssued,
+		UserID: issued.UserID,
+		Action: types.ActionAllow,
+		Reason: fmt.Sprintf("%s until %s", overrideReason(issued), issued.ExpiresAt.Format(time.RFC3339)),
+	}
+	if err := s.auditLogger.LogSync(entry); err != nil {
+		return nil, "", nil, fmt.Errorf("failed to audit override: %w", err)
+	}
+	return issued, token, signingKey.Public().(ed25519.PublicKey), nil
+}
+
+// RevokeOverride revokes a break-glass override before it expires and
+// records the revocation in the audit log.
+func (s *Shield) RevokeOverride(id, revokedBy, reason string) error {
+	if s.config.OverrideRevocations == "" {
+		return fmt.Errorf("no override revocation list is configured")
+	}
+	revocations, err := policy.NewRevocationList(s.config.OverrideRevocations)
+	if err != nil {
+		return err
+	}
+	if err := revocations.Revoke(policy.Revocation{ID: id, RevokedBy: revokedBy, Reason: reason}); err != nil {
+		return err
+	}
+
+	entry := types.AuditEntry{
+		Event:  types.AuditEventOverrideRevoked,
+		Action: types.ActionBlock,
+		Reason: fmt.Sprintf("break-glass override %s revoked by %s", id, revokedBy),
+	}
+	if reason != "" {
+		entry.Reason += ": " + reason
+	}
+	return s.auditLogger.LogSync(entry)
+}
+
+// overrideReason describes an override for the audit log.
+func overrideReason(o *types.Override) string {
+	return fmt.Sprintf("break-glass override %s (%s, scope %s) issued by %s: %s",
+		o.ID, o.AccessLevel, o.Scope, o.IssuedBy, o.Justification)
+}
+
+// parseTrustedKeys decodes base64 Ed25519 public keys.
+func parseTrustedKeys(encoded []string) ([]ed25519.PublicKey, error) {
+	var keys []ed25519.PublicKey
+	for _, e := range encoded {
+		key, err := base64.StdEncoding.DecodeString(e)
+		if err != nil || len(key) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("invalid trusted key %q", e)
+		}
+		keys = append(keys, ed25519.PublicKey(key))
+	}
+	return keys, nil
+}
@@ -0,0 +1,65 @@
+// Package hooks provides token budget accounting for requests and responses.
+package hooks
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/enterprise/opencode-enterprise-shield/pkg/types"
+)
+
+// budgetRemediation is shown with token budget warnings.
+const budgetRemediation = "Budgets reset at the start of each UTC day and month; ask your administrator if you need more"
+
+// chargeInputTokens checks a request's estimated input tokens against the
+// user's and department's token budgets and, unless a limit would be
+// exceeded, counts them. The check and the count are one atomic update of
+// the usage store. It returns a block reason, or warnings for soft limits
+// passed. If budgets apply but usage cannot be read or updated, the request
+// is blocked rather than let through unmetered.
+func (s *Shield) chargeInputTokens(req types.Request, decision types.PolicyDecision, tokens int64, now time.Time) (string, []types.Warning) {
+	blockReason, messages, err := s.usage.Charge(req.UserID, req.Department, decision.TokenBudget, decision.DepartmentTokenBudget, tokens, now)
+	if err != nil {
+		if decision.TokenBudget != nil || decision.DepartmentTokenBudget != nil {
+			return fmt.Sprintf("Token usage unavailable: %v", err), nil
+		}
+		return "", nil
+	}
+	if blockReason != "" {
+		return blockReason, nil
+	}
+
+	var warnings []types.Warning
+	for _, message := range messages {
+		warnings = append(warnings, types.Warning{
+			Rule:        "token_budget",
+			Reason:      message,
+			Remediation: budgetRemediation,
+		})
+	}
+	return "", warnings
+}
+
+// RecordOutputTokens counts the output tokens a provider reported for a
+// response against the budgets of the session's user and department.
+// Responses are never blocked for their size; the tokens count toward
+// whether later requests are allowed.
+func (s *Shield) RecordOutputTokens(sessionID string, tokens int64) error {
+	if tokens < 0 {
+		return fmt.Errorf("output tokens must not be negative")
+	}
+	sess, ok := s.sessionManager.Get(sessionID)
+	if !ok {
+		return fmt.Errorf("session %s not found or expired", sessionID)
+	}
+	if err := s.usage.Add(sess.UserID, sess.Department, tokens, time.Now()); err != nil {
+		return fmt.Errorf("failed to record output tokens: %w", err)
+	}
+	return nil
+}
+
+// OnUsage is called with the output token count a provider reported for a
+// response, so it counts toward token budgets.
+func (h *Hook) OnUsage(sessionID string, outputTokens int64) error {
+	return h.shield.RecordOutputTokens(sessionID, outputTokens)
+}
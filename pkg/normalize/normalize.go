@@ -0,0 +1,172 @@
+// Package normalize provides a normalized shadow of text for scanning, so
+// full-width characters, invisible characters and look-alike letters cannot
+// hide sensitive values from ASCII patterns. Offsets in the shadow map back
+// to the original text.
+//
+// The normalization is a hand-written subset of NFKC (full-width and other
+// compatibility forms, compatibility digits and spaces, ligatures), removal
+// of zero-width, bidi and other invisible characters and combining marks,
+// and folding of confusable Cyrillic and Greek letters and dashes to ASCII.
+package normalize
+
+import (
+	"unicode"
+	"unicode/utf8"
+)
+
+// Shadow is a normalized copy of a text.
+type Shadow struct {
+	Text string
+
+	original int   // Length of the original text
+	starts   []int // Original start of the rune each byte of Text came from; nil if Text is the original
+	ends     []int // Original end of that rune
+}
+
+// New returns the normalized shadow of text.
+func New(text string) Shadow {
+	if isASCII(text) {
+		return Shadow{Text: text, original: len(text)}
+	}
+
+	buf := make([]byte, 0, len(text))
+	starts := make([]int, 0, len(text))
+	ends := make([]int, 0, len(text))
+	for i := 0; i < len(text); {
+		r, size := utf8.DecodeRuneInString(text[i:])
+		replacement := text[i : i+size] // Invalid bytes are kept as they are
+		if r != utf8.RuneError {
+			replacement = normalizeRune(r)
+		}
+		for j := 0; j < len(replacement); j++ {
+			starts = append(starts, i)
+			ends = append(ends, i+size)
+		}
+		buf = append(buf, replacement...)
+		i += size
+	}
+
+	if string(buf) == text {
+		return Shadow{Text: text, original: len(text)}
+	}
+	return Shadow{Text: string(buf), original: len(text), starts: starts, ends: ends}
+}
+
+// Changed reports whether normalization changed the text.
+func (s Shadow) Changed() bool {
+	return s.starts != nil
+}
+
+// Span maps the byte span [start, end) of the shadow text to the span of
+// the original text it came from. Invisible characters inside a match are
+// part of the original span.
+func (s Shadow) Span(start, end int) (int, int) {
+	if s.starts == nil {
+		return start, end
+	}
+	if start >= len(s.Text) {
+		return s.original, s.original
+	}
+	if end <= start {
+		return s.starts[start], s.starts[start]
+	}
+	return s.starts[start], s.ends[end-1]
+}
+
+// isASCII reports whether text is ASCII, which normalization never changes.
+func isASCII(text string) bool {
+	for i := 0; i < len(text); i++ {
+		if text[i] >= utf8.RuneSelf {
+			return false
+		}
+	}
+	return true
+}
+
+// normalizeRune returns the normalized form of r: empty for invisible
+// characters, ASCII for compatibility forms and confusables, r otherwise.
+func normalizeRune(r rune) string {
+	switch {
+	case invisible(r):
+		return ""
+	case r >= 0xFF01 && r <= 0xFF5E: // Full-width ASCII
+		return string(r - 0xFEE0)
+	case r >= 0x1D400 && r <= 0x1D6A3: // Mathematical alphanumeric letters
+		return string(mathLetters[(r-0x1D400)%52])
+	}
+
+	if folded, ok := foldings[r]; ok {
+		return folded
+	}
+	if r > unicode.MaxASCII && unicode.Is(unicode.Nd, r) {
+		return string('0' + digitValue(r))
+	}
+	if unicode.IsSpace(r) || unicode.Is(unicode.Zs, r) {
+		return " "
+	}
+	return string(r)
+}
+
+// invisible reports whether r renders as nothing and can be slipped between
+// the characters of a value.
+func invisible(r rune) bool {
+	switch {
+	case r == 0x00AD, r == 0x034F, r == 0x061C, r == 0x115F, r == 0x1160, r == 0x17B4, r == 0x17B5,
+		r == 0x180E, r == 0x3164, r == 0xFEFF, r == 0xFFA0:
+		return true
+	case r >= 0x200B && r <= 0x200F, r >= 0x202A && r <= 0x202E, r >= 0x2060 && r <= 0x206F:
+		return true // Zero-width, bidi and invisible operators
+	case r >= 0xFE00 && r <= 0xFE0F, r >= 0xE0100 && r <= 0xE01EF, r >= 0xE0000 && r <= 0xE007F:
+		return true // Variation selectors and tags
+	}
+	return unicode.Is(unicode.Mn, r) || unicode.Is(unicode.Me, r)
+}
+
+// digitValue returns the value of a decimal digit. Unicode encodes every
+// decimal digit set as a contiguous run from zero to nine, so the value is
+// the distance from the start of the run of digits, modulo ten.
+func digitValue(r rune) rune {
+	start := r
+	for start > 0 && unicode.Is(unicode.Nd, start-1) && r-start < 100 {
+		start--
+	}
+	return (r - start) % 10
+}
+
+// mathLetters are the letters of each style in the Mathematical
+// Alphanumeric Symbols block, in block order.
+const mathLetters = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+
+// foldings are compatibility forms and confusables outside the ranges
+// handled in normalizeRune.
+var foldings = map[rune]string{
+	// Superscript, subscript and circled digits
+	0x00B9: "1", 0x00B2: "2", 0x00B3: "3", 0x2070: "0", 0x2074: "4", 0x2075: "5",
+	0x2076: "6", 0x2077: "7", 0x2078: "8", 0x2079: "9",
+	0x2080: "0", 0x2081: "1", 0x2082: "2", 0x2083: "3", 0x2084: "4",
+	0x2085: "5", 0x2086: "6", 0x2087: "7", 0x2088: "8", 0x2089: "9",
+	0x2460: "1", 0x2461: "2", 0x2462: "3", 0x2463: "4", 0x2464: "5",
+	0x2465: "6", 0x2466: "7", 0x2467: "8", 0x2468: "9", 0x24EA: "0",
+
+	// Ligatures and letter-like symbols
+	0xFB00: "ff", 0xFB01: "fi", 0xFB02: "fl", 0xFB03: "ffi", 0xFB04: "ffl",
+	0x2113: "l", 0x212A: "K", 0x212B: "A", 0x2160: "I", 0x2164: "V", 0x2169: "X",
+	0x0131: "i", 0x0237: "j",
+
+	// Dashes and minus signs
+	0x2010: "-", 0x2011: "-", 0x2012: "-", 0x2013: "-", 0x2014: "-", 0x2015: "-",
+	0x2212: "-", 0xFE58: "-", 0xFE63: "-", 0x02D7: "-",
+
+	// Cyrillic letters that look like Latin ones
+	0x0410: "A", 0x0412: "B", 0x0415: "E", 0x041A: "K", 0x041C: "M", 0x041D: "H",
+	0x041E: "O", 0x0420: "P", 0x0421: "C", 0x0422: "T", 0x0423: "Y", 0x0425: "X",
+	0x0405: "S", 0x0406: "I", 0x0408: "J", 0x04C0: "I",
+	0x0430: "a", 0x0435: "e", 0x043E: "o", 0x0440: "p", 0x0441: "c", 0x0443: "y",
+	0x0445: "x", 0x0455: "s", 0x0456: "i", 0x0458: "j", 0x0501: "d", 0x051B: "q",
+	0x051D: "w", 0x04BB: "h",
+
+	// Greek letters that look like Latin ones
+	0x0391: "A", 0x0392: "B", 0x0395: "E", 0x0396: "Z", 0x0397: "H", 0x0399: "I",
+	0x039A: "K", 0x039C: "M", 0x039D: "N", 0x039F: "O", 0x03A1: "P", 0x03A4: "T",
+	0x03A5: "Y", 0x03A7: "X", 0x03BF: "o", 0x03BD: "v", 0x03B9: "i",
+}
@@ -0,0 +1,72 @@
+package normalize
+
+import (
+	"testing"
+)
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name, text, want string
+	}{
+		{"ascii", "ssn 123-45-6789", "ssn 123-45-6789"},
+		{"full-width digits", "１２３－４５－６７８９", "123-45-6789"},
+		{"zero-width joiners", "1\u200d2\u200b3-45\u2060-6789\ufeff", "123-45-6789"},
+		{"soft hyphen and bidi", "AK\u00adIA\u202eX", "AKIAX"},
+		{"cyrillic homoglyph", "АKIA", "AKIA"},
+		{"greek homoglyph", "ΟK Κ", "OK K"},
+		{"math letters and digits", "\U0001D400\U0001D41A\U0001D7CF\U0001D7F0", "Aa14"},
+		{"other digit sets", "١٢٣ ०९", "123 09"},
+		{"spaces and dashes", "a\u00a0b\u3000c\u2013d", "a b c-d"},
+		{"combining marks", "1\u03052\u0336", "12"},
+		{"ligature", "ﬁle", "file"},
+		{"other letters kept", "café 東京", "café 東京"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			shadow := New(tt.text)
+			if shadow.Text != tt.want {
+				t.Errorf("New(%q) = %q, want %q", tt.text, shadow.Text, tt.want)
+			}
+			if shadow.Changed() != (tt.text != tt.want) {
+				t.Errorf("Changed() = %v for %q", shadow.Changed(), tt.text)
+			}
+		})
+	}
+}
+
+func TestShadow_Span(t *testing.T) {
+	text := "id: １\u200b2３-45"
+	shadow := New(text)
+	if shadow.Text != "id: 123-45" {
+		t.Fatalf("Expected normalized text, got %q", shadow.Text)
+	}
+
+	// A match in the shadow maps to the original bytes, including the
+	// invisible characters inside it
+	start, end := shadow.Span(4, 10)
+	if got := text[start:end]; got != "１\u200b2３-45" {
+		t.Errorf("Expected the original span, got %q", got)
+	}
+	start, end = shadow.Span(0, 3)
+	if got := text[start:end]; got != "id:" {
+		t.Errorf("Expected %q, got %q", "id:", got)
+	}
+
+	// Unchanged text maps to itself
+	plain := New("plain")
+	if start, end := plain.Span(1, 3); start != 1 || end != 3 {
+		t.Errorf("Expected identity span, got %d-%d", start, end)
+	}
+}
+
+func TestNew_InvalidUTF8(t *testing.T) {
+	text := "a\xffＢ"
+	shadow := New(text)
+	if shadow.Text != "a\xffB" {
+		t.Errorf("Expected invalid bytes kept, got %q", shadow.Text)
+	}
+	if start, end := shadow.Span(2, 3); text[start:end] != "Ｂ" {
+		t.Errorf("Expected the full-width B, got %q", text[start:end])
+	}
+}
@@ -0,0 +1,48 @@
+//go:build !unix
+
+// Package paths provides lock files on systems without flock.
+package paths
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Lock file timing on systems without flock.
+const (
+	lockTimeout = 10 * time.Second
+	lockStale   = 30 * time.Second // A lock older than this was left by a dead process
+	lockRetry   = 10 * time.Millisecond
+)
+
+// Lock creates the lock file at path exclusively, waiting while another
+// process holds it, and returns the function that releases it.
+func Lock(path string) (func(), error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, err
+	}
+
+	deadline := time.Now().Add(lockTimeout)
+	for {
+		file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_EXCL, 0600)
+		if err == nil {
+			file.Close()
+			return func() { os.Remove(path) }, nil
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return nil, err
+		}
+
+		if info, err := os.Stat(path); err == nil && time.Since(info.ModTime()) > lockStale {
+			os.Remove(path)
+			continue
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("timed out waiting for %s", path)
+		}
+		time.Sleep(lockRetry)
+	}
+}
@@ -0,0 +1,21 @@
+// Package paths provides file path helpers shared by the shield's packages.
+package paths
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// ExpandHome expands a leading ~ to the user's home directory. Other paths,
+// including the empty path, are returned unchanged.
+func ExpandHome(path string) (string, error) {
+	if path == "" || path[0] != '~' {
+		return path, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get home directory: %w", err)
+	}
+	return filepath.Join(home, path[1:]), nil
+}
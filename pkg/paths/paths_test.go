@@ -0,0 +1,55 @@
+package paths
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestExpandHome(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	tests := []struct {
+		path, want string
+	}{
+		{"~/.opencode/keys/a.key", filepath.Join(home, ".opencode/keys/a.key")},
+		{"~", home},
+		{"/etc/shield.yaml", "/etc/shield.yaml"},
+		{"relative/~/dir", "relative/~/dir"},
+		{"", ""},
+	}
+
+	for _, test := range tests {
+		got, err := ExpandHome(test.path)
+		if err != nil {
+			t.Fatalf("ExpandHome(%q) failed: %v", test.path, err)
+		}
+		if got != test.want {
+			t.Errorf("ExpandHome(%q) = %q, want %q", test.path, got, test.want)
+		}
+	}
+}
+
+func TestWriteFileAtomic(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nested", "state.json")
+
+	for _, data := range []string{"first", "second"} {
+		if err := WriteFileAtomic(path, []byte(data)); err != nil {
+			t.Fatalf("WriteFileAtomic failed: %v", err)
+		}
+		got, err := os.ReadFile(path)
+		if err != nil || string(got) != data {
+			t.Errorf("Expected %q, got %q (%v)", data, got, err)
+		}
+	}
+
+	// No temporary files are left behind
+	entries, _ := os.ReadDir(filepath.Dir(path))
+	if len(entries) != 1 {
+		t.Errorf("Expected only the written file, got %d entries", len(entries))
+	}
+	if info, err := os.Stat(path); err == nil && info.Mode().Perm() != 0600 {
+		t.Errorf("Expected an owner-only file, got %v", info.Mode().Perm())
+	}
+}
@@ -0,0 +1,350 @@
+// Package policy provides content and context conditions for policy evaluation.
+package policy
+
+import (
+	"fmt"
+	"net"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/enterprise/opencode-enterprise-shield/pkg/types"
+)
+
+// Condition is a rule evaluated against each request after the access policy.
+// Every criterion that is set must match; within a list, any entry matching
+// is enough. Every matching condition is evaluated and the most restrictive
+// action applies, whatever the file order; an action can tighten the access
+// decision but never loosen it. A condition marked "exempt" is the exception:
+// when it matches, the conditions after it are skipped. An
+// "allow_with_warning" condition adds a warning for the developer even when
+// the decision already requires sanitization.
+//
+// Example: block payroll questions from outside the VPN.
+//
+//	conditions:
+//	  - name: security-team
+//	    sourceCidrs: [192.168.50.0/24]
+//	    action: allow
+//	    exempt: true
+//	  - name: payroll-off-vpn
+//	    keywords: [payroll, salary]
+//	    outsideCidrs: [10.8.0.0/16]
+//	    action: block
+//	    reason: Payroll data may only be discussed from the corporate VPN
+//	  - name: many-findings-off-azure
+//	    when: violations.high > 3 && provider != "azure_openai"
+//	    action: block
+type Condition struct {
+	Name   string       `yaml:"name" json:"name"`
+	Action types.Action `yaml:"action" json:"action"`
+	Reason string       `yaml:"reason,omitempty" json:"reason,omitempty"`
+	Exempt bool         `yaml:"exempt,omitempty" json:"exempt,omitempty"` // Skip later conditions when matched
+
+	// Remediation is shown with the warning when the action is allow_with_warning.
+	Remediation string `yaml:"remediation,omitempty" json:"remediation,omitempty"`
+
+	Keywords     []string `yaml:"keywords,omitempty" json:"keywords,omitempty"`         // Case-insensitive substrings
+	Patterns     []string `yaml:"patterns,omitempty" json:"patterns,omitempty"`         // Regular expressions
+	MinLength    int      `yaml:"minLength,omitempty" json:"minLength,omitempty"`       // Content length in bytes
+	MaxLength    int      `yaml:"maxLength,omitempty" json:"maxLength,omitempty"`       // Content length in bytes
+	Violations   []string `yaml:"violations,omitempty" json:"violations,omitempty"`     // Violation types or rule IDs
+	SourceCIDRs  []string `yaml:"sourceCidrs,omitempty" json:"sourceCidrs,omitempty"`   // Source IP inside any range
+	OutsideCIDRs []string `yaml:"outsideCidrs,omitempty" json:"outsideCidrs,omitempty"` // Source IP outside every range
+	Hours        string   `yaml:"hours,omitempty" json:"hours,omitempty"`               // "HH:MM-HH:MM", may wrap midnight
+	Days         []string `yaml:"days,omitempty" json:"days,omitempty"`                 // mon, tue, ... sun
+	Timezone     string   `yaml:"timezone,omitempty" json:"timezone,omitempty"`         // IANA name, default local
+	Providers    []string `yaml:"providers,omitempty" json:"providers,omitempty"`
+	When         string   `yaml:"when,omitempty" json:"when,omitempty"` // Expression, see CompileExpression
+
+	when     *Expression
+	patterns []*regexp.Regexp
+	inside   []*net.IPNet
+	outside  []*net.IPNet
+	from, to int // Minutes since midnight
+	days     map[time.Weekday]bool
+	location *time.Location
+}
+
+// weekdays maps day names accepted in conditions to weekdays.
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday, "mon": time.Monday, "tue": time.Tuesday, "wed": time.Wednesday,
+	"thu": time.Thursday, "fri": time.Friday, "sat": time.Saturday,
+}
+
+// compile validates the condition and prepares it for matching, returning
+// every problem found.
+func (c *Condition) compile() []string {
+	var problems []string
+
+	if c.Name == "" {
+		problems = append(problems, "name is required")
+	}
+	switch c.Action {
+	case types.ActionAllow, types.ActionAllowWithWarning, types.ActionAllowWithSanitization, types.ActionBlock:
+	default:
+		problems = append(problems, fmt.Sprintf("unknown action %q", c.Action))
+	}
+
+	c.patterns = nil
+	for _, pattern := range c.Patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("invalid pattern %q: %v", pattern, err))
+			continue
+		}
+		c.patterns = append(c.patterns, re)
+	}
+
+	if c.MinLength < 0 || c.MaxLength < 0 {
+		problems = append(problems, "content lengths must not be negative")
+	} else if c.MaxLength > 0 && c.MinLength > c.MaxLength {
+		problems = append(problems, "minLength is greater than maxLength")
+	}
+
+	var err error
+	if c.inside, err = parseCIDRs(c.SourceCIDRs); err != nil {
+		problems = append(problems, err.Error())
+	}
+	if c.outside, err = parseCIDRs(c.OutsideCIDRs); err != nil {
+		problems = append(problems, err.Error())
+	}
+
+	if c.Hours != "" {
+		if c.from, c.to, err = parseHours(c.Hours); err != nil {
+			problems = append(problems, err.Error())
+		}
+	}
+
+	c.days = nil
+	if len(c.Days) > 0 {
+		c.days = make(map[time.Weekday]bool)
+		for _, day := range c.Days {
+			weekday, ok := weekdays[strings.ToLower(day)]
+			if !ok {
+				problems = append(problems, fmt.Sprintf("unknown day %q (use mon, tue, ... sun)", day))
+				continue
+			}
+			c.days[weekday] = true
+		}
+	}
+
+	c.location = time.Local
+	if c.Timezone != "" {
+		if c.location, err = time.LoadLocation(c.Timezone); err != nil {
+			problems = append(problems, fmt.Sprintf("unknown timezone %q", c.Timezone))
+		}
+	}
+
+	c.when = nil
+	if c.When != "" {
+		if c.when, err = CompileExpression(c.When); err != nil {
+			problems = append(problems, fmt.Sprintf("when: %v", err))
+		}
+	}
+
+	if !c.hasCriteria() {
+		problems = append(problems, "at least one criterion is required")
+	}
+
+	return problems
+}
+
+// hasCriteria reports whether the condition restricts anything at all.
+func (c *Condition) hasCriteria() bool {
+	return len(c.Keywords) > 0 || len(c.Patterns) > 0 || c.MinLength > 0 || c.MaxLength > 0 ||
+		len(c.Violations) > 0 || len(c.SourceCIDRs) > 0 || len(c.OutsideCIDRs) > 0 ||
+		c.Hours != "" || len(c.Days) > 0 || len(c.Providers) > 0 || c.When != ""
+}
+
+// Matches reports whether a request context satisfies every criterion.
+// A request without a parseable source IP never matches sourceCidrs and
+// always matches outsideCidrs, so unknown origins are not trusted. Likewise
+// a "when" expression that fails at runtime, such as by dividing by zero,
+// counts as matching unless the condition grants an exemption.
+func (c *Condition) Matches(ctx PolicyContext) bool {
+	if len(c.Providers) > 0 && !contains(c.Providers, ctx.Provider) {
+		return false
+	}
+
+	if c.MinLength > 0 && len(ctx.Content) < c.MinLength {
+		return false
+	}
+	if c.MaxLength > 0 && len(ctx.Content) > c.MaxLength {
+		return false
+	}
+
+	ip := net.ParseIP(ctx.SourceIP)
+	if len(c.inside) > 0 && (ip == nil || !inNetworks(ip, c.inside)) {
+		return false
+	}
+	if len(c.outside) > 0 && ip != nil && inNetworks(ip, c.outside) {
+		return false
+	}
+
+	if c.Hours != "" || c.days != nil {
+		now := ctx.Time
+		if now.IsZero() {
+			now = time.Now()
+		}
+		now = now.In(c.location)
+
+		if c.days != nil && !c.days[now.Weekday()] {
+			return false
+		}
+		if c.Hours != "" && !c.inHours(now.Hour()*60+now.Minute()) {
+			return false
+		}
+	}
+
+	if len(c.Violations) > 0 && !c.matchesViolation(ctx.Violations) {
+		return false
+	}
+
+	if len(c.Keywords) > 0 && !matchesKeyword(ctx.Content, c.Keywords) {
+		return false
+	}
+
+	if len(c.patterns) > 0 {
+		matched := false
+		for _, re := range c.patterns {
+			if re.MatchString(ctx.Content) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+
+	if c.when != nil {
+		matched, err := c.when.Eval(ctx, c.location)
+		if err != nil {
+			return !c.Exempt
+		}
+		return matched
+	}
+
+	return true
+}
+
+// inHours reports whether a minute of the day falls in the hour window.
+func (c *Condition) inHours(minute int) bool {
+	if c.from <= c.to {
+		return minute >= c.from && minute < c.to
+	}
+	// Window wraps midnight, e.g. 22:00-06:00
+	return minute >= c.from || minute < c.to
+}
+
+// matchesViolation reports whether any violation has a listed type or rule ID.
+func (c *Condition) matchesViolation(violations []types.Violation) bool {
+	for _, v := range violations {
+		for _, want := range c.Violations {
+			if strings.EqualFold(v.Type, want) || strings.EqualFold(v.RuleID, want) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// policyID returns the identifier recorded in PolicyApplied for the condition.
+func (c *Condition) policyID() string {
+	return "condition:" + c.Name
+}
+
+// warning returns the warning the condition adds to a decision.
+func (c *Condition) warning() types.Warning {
+	return types.Warning{
+		Rule:        c.policyID(),
+		Reason:      c.reason(),
+		Remediation: c.Remediation,
+	}
+}
+
+// reason returns the condition's reason, or a generic one.
+func (c *Condition) reason() string {
+	if c.Reason != "" {
+		return c.Reason
+	}
+	return fmt.Sprintf("Request matched condition %s", c.Name)
+}
+
+// matchesKeyword reports whether content contains any keyword, ignoring case.
+func matchesKeyword(content string, keywords []string) bool {
+	lower := strings.ToLower(content)
+	for _, keyword := range keywords {
+		if strings.Contains(lower, strings.ToLower(keyword)) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseCIDRs parses CIDR ranges. A bare IP address is treated as a single host.
+func parseCIDRs(cidrs []string) ([]*net.IPNet, error) {
+	networks := make([]*net.IPNet, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		if !strings.Contains(cidr, "/") {
+			ip := net.ParseIP(cidr)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid CIDR %q", cidr)
+			}
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 8*net.IPv4len
+			}
+			networks = append(networks, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid CIDR %q", cidr)
+		}
+		networks = append(networks, network)
+	}
+	return networks, nil
+}
+
+// inNetworks reports whether ip is inside any of the networks.
+func inNetworks(ip net.IP, networks []*net.IPNet) bool {
+	for _, network := range networks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseHours parses an "HH:MM-HH:MM" window into minutes since midnight.
+func parseHours(hours string) (int, int, error) {
+	start, end, ok := strings.Cut(hours, "-")
+	if !ok {
+		return 0, 0, fmt.Errorf("invalid hours %q (use HH:MM-HH:MM)", hours)
+	}
+	from, err := time.Parse("15:04", strings.TrimSpace(start))
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid hours %q (use HH:MM-HH:MM)", hours)
+	}
+	to, err := time.Parse("15:04", strings.TrimSpace(end))
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid hours %q (use HH:MM-HH:MM)", hours)
+	}
+	return from.Hour()*60 + from.Minute(), to.Hour()*60 + to.Minute(), nil
+}
+
+// actionRank orders actions from least to most restrictive.
+func actionRank(action types.Action) int {
+	switch action {
+	case types.ActionAllow:
+		return 0
+	case types.ActionAllowWithWarning:
+		return 1
+	case types.ActionAllowWithSanitization:
+		return 2
+	default:
+		return 3
+	}
+}
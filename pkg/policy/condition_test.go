@@ -0,0 +1,231 @@
+package policy
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/enterprise/opencode-enterprise-shield/pkg/types"
+)
+
+const testConditionsFile = `
+default:
+  accessLevel: unrestricted
+conditions:
+  - name: security-team-exempt
+    sourceCidrs: [192.168.50.0/24]
+    keywords: [payroll]
+    action: allow
+    exempt: true
+  - name: payroll-off-vpn
+    keywords: [payroll, salary]
+    outsideCidrs: [10.8.0.0/16, 192.168.50.7]
+    action: block
+    reason: Payroll data may only be discussed from the VPN
+  - name: secrets-to-openai
+    violations: [api_key]
+    providers: [openai]
+    action: allow_with_sanitization
+  - name: large-after-hours
+    minLength: 20
+    hours: "22:00-06:00"
+    days: [mon, tue, wed, thu, fri]
+    timezone: UTC
+    action: allow_with_warning
+  - name: ticket-numbers
+    patterns: ['\bINC-\d{6}\b']
+    action: allow_with_sanitization
+`
+
+func TestEvaluate_Conditions(t *testing.T) {
+	set, err := ParseFile([]byte(testConditionsFile), nil)
+	if err != nil {
+		t.Fatalf("ParseFile failed: %v", err)
+	}
+	engine := NewEngine()
+	engine.Load(set)
+
+	monday := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	mondayNight := time.Date(2026, 10, 19, 23, 30, 0, 0, time.UTC)
+	saturdayNight := time.Date(2026, 10, 24, 23, 30, 0, 0, time.UTC)
+	apiKey := []types.Violation{{RuleID: "openai_key", Type: "API_KEY"}}
+
+	tests := []struct {
+		name   string
+		ctx    PolicyContext
+		action types.Action
+		match  string
+	}{
+		{"payroll outside VPN", PolicyContext{Content: "Summarize the Payroll table", SourceIP: "203.0.113.9", Time: monday}, types.ActionBlock, "payroll-off-vpn"},
+		{"payroll on VPN", PolicyContext{Content: "Summarize the payroll table", SourceIP: "10.8.3.4", Time: monday}, types.ActionAllow, ""},
+		{"payroll without source IP", PolicyContext{Content: "payroll", Time: monday}, types.ActionBlock, "payroll-off-vpn"},
+		{"single host range", PolicyContext{Content: "salary bands", SourceIP: "192.168.50.7", Time: monday}, types.ActionAllow, ""},
+		{"earlier exemption wins", PolicyContext{Content: "payroll", SourceIP: "192.168.50.9", Time: monday}, types.ActionAllow, "security-team-exempt"},
+		{"violation and provider", PolicyContext{Content: "key", Provider: "openai", Violations: apiKey, Time: monday}, types.ActionAllowWithSanitization, "secrets-to-openai"},
+		{"violation other provider", PolicyContext{Content: "key", Provider: "anthropic", Violations: apiKey, Time: monday}, types.ActionAllow, ""},
+		{"after hours on weekday", PolicyContext{Content: strings.Repeat("x", 20), Time: mondayNight}, types.ActionAllowWithWarning, "large-after-hours"},
+		{"after hours on weekend", PolicyContext{Content: strings.Repeat("x", 20), Time: saturdayNight}, types.ActionAllow, ""},
+		{"short after hours", PolicyContext{Content: "short", Time: mondayNight}, types.ActionAllow, ""},
+		{"pattern", PolicyContext{Content: "see INC-004211", Time: monday}, types.ActionAllowWithSanitization, "ticket-numbers"},
+	}
+
+	for _, test := range tests {
+		decision := engine.Evaluate(test.ctx)
+		if decision.Action != test.action {
+			t.Errorf("%s: expected %s, got %s (%s)", test.name, test.action, decision.Action, decision.Reason)
+		}
+		applied := strings.Join(decision.PolicyApplied, ",")
+		if test.match != "" && !strings.Contains(applied, "condition:"+test.match) {
+			t.Errorf("%s: expected condition %s in %v", test.name, test.match, decision.PolicyApplied)
+		}
+		if test.match == "" && strings.Contains(applied, "condition:") {
+			t.Errorf("%s: expected no condition, got %v", test.name, decision.PolicyApplied)
+		}
+	}
+
+	decision := engine.Evaluate(PolicyContext{Content: "payroll", SourceIP: "203.0.113.9", Time: monday})
+	if decision.Reason != "Payroll data may only be discussed from the VPN" {
+		t.Errorf("Expected condition reason, got %q", decision.Reason)
+	}
+}
+
+func TestEvaluate_ConditionsNeverLoosen(t *testing.T) {
+	set, err := ParseFile([]byte(`
+default:
+  accessLevel: sanitized_only
+departments:
+  contractors:
+    accessLevel: blocked
+conditions:
+  - name: everything-allowed
+    minLength: 1
+    action: allow
+`), nil)
+	if err != nil {
+		t.Fatalf("ParseFile failed: %v", err)
+	}
+	engine := NewEngine()
+	engine.Load(set)
+
+	if decision := engine.Evaluate(PolicyContext{UserID: "a", Content: "hello"}); decision.Action != types.ActionAllowWithSanitization {
+		t.Errorf("Expected allow condition to keep sanitization, got %s", decision.Action)
+	}
+	if decision := engine.Evaluate(PolicyContext{UserID: "a", Department: "contractors", Content: "hello"}); decision.Action != types.ActionBlock {
+		t.Errorf("Expected allow condition to keep block, got %s", decision.Action)
+	}
+}
+
+func TestEvaluate_MostRestrictiveCondition(t *testing.T) {
+	set, err := ParseFile([]byte(`
+default:
+  accessLevel: unrestricted
+conditions:
+  - name: openai-notice
+    providers: [openai]
+    action: allow_with_warning
+    reason: Requests to OpenAI are reviewed
+  - name: not-an-exemption
+    keywords: [payroll]
+    action: allow
+  - name: payroll-blocked
+    keywords: [payroll]
+    action: block
+    reason: Payroll data may not be sent
+  - name: tickets
+    keywords: [INC-]
+    action: allow_with_sanitization
+`), nil)
+	if err != nil {
+		t.Fatalf("ParseFile failed: %v", err)
+	}
+	engine := NewEngine()
+	engine.Load(set)
+
+	// A warning listed before a block does not stop the block
+	decision := engine.Evaluate(PolicyContext{Provider: "openai", Content: "payroll for INC-1"})
+	if decision.Action != types.ActionBlock || decision.Reason != "Payroll data may not be sent" {
+		t.Errorf("Expected the block to apply, got %s (%s)", decision.Action, decision.Reason)
+	}
+	want := []string{"default", "condition:openai-notice", "condition:not-an-exemption", "condition:tickets", "condition:payroll-blocked"}
+	if strings.Join(decision.PolicyApplied, ",") != strings.Join(want, ",") {
+		t.Errorf("Expected %v, got %v", want, decision.PolicyApplied)
+	}
+	if len(decision.Warnings) != 1 || decision.Warnings[0].Rule != "condition:openai-notice" {
+		t.Errorf("Expected the warning to be kept, got %+v", decision.Warnings)
+	}
+	if got := decidingRule(decision); got != "condition:payroll-blocked" {
+		t.Errorf("Expected the block to be the deciding rule, got %s", got)
+	}
+
+	// Sanitization outranks the earlier warning
+	decision = engine.Evaluate(PolicyContext{Provider: "openai", Content: "see INC-1"})
+	if decision.Action != types.ActionAllowWithSanitization || len(decision.Warnings) != 1 {
+		t.Errorf("Expected sanitization with a warning, got %s %+v", decision.Action, decision.Warnings)
+	}
+}
+
+func TestParseFile_ConditionErrors(t *testing.T) {
+	_, err := ParseFile([]byte(`
+conditions:
+  - name: bad
+    action: explode
+    patterns: ['(']
+    outsideCidrs: [10.0.0.0/33]
+    hours: "9-17"
+    days: [someday]
+  - action: block
+  - name: bad
+    keywords: [x]
+    action: block
+`), nil)
+	if err == nil {
+		t.Fatal("Expected invalid conditions to be rejected")
+	}
+
+	for _, want := range []string{
+		`conditions[0] (bad): unknown action "explode"`,
+		`conditions[0] (bad): invalid pattern "("`,
+		`conditions[0] (bad): invalid CIDR "10.0.0.0/33"`,
+		`conditions[0] (bad): invalid hours "9-17"`,
+		`conditions[0] (bad): unknown day "someday"`,
+		`conditions[1]: name is required`,
+		`conditions[1]: at least one criterion is required`,
+		`conditions[2] (bad): duplicate condition name "bad"`,
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Expected error to contain %q, got:\n%v", want, err)
+		}
+	}
+}
+
+func TestEvaluate_WarningCondition(t *testing.T) {
+	set, err := ParseFile([]byte(`
+users:
+  alice:
+    accessLevel: unrestricted
+conditions:
+  - name: large-prompt
+    minLength: 10
+    action: allow_with_warning
+    reason: Large prompt
+    remediation: Send only the relevant part of the file
+`), nil)
+	if err != nil {
+		t.Fatalf("ParseFile failed: %v", err)
+	}
+	engine := NewEngine()
+	engine.Load(set)
+
+	want := types.Warning{Rule: "condition:large-prompt", Reason: "Large prompt", Remediation: "Send only the relevant part of the file"}
+
+	decision := engine.Evaluate(PolicyContext{UserID: "alice", Content: "0123456789"})
+	if decision.Action != types.ActionAllowWithWarning || len(decision.Warnings) != 1 || decision.Warnings[0] != want {
+		t.Errorf("Expected warning decision, got %s %+v", decision.Action, decision.Warnings)
+	}
+
+	// Sanitization still applies, with the warning attached
+	decision = engine.Evaluate(PolicyContext{UserID: "bob", Content: "0123456789"})
+	if decision.Action != types.ActionAllowWithSanitization || len(decision.Warnings) != 1 {
+		t.Errorf("Expected sanitization with warning, got %s %+v", decision.Action, decision.Warnings)
+	}
+}
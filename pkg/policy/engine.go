@@ -2,17 +2,40 @@
 package policy
 
 import (
+	"crypto/ed25519"
+	"fmt"
+	"os"
+	"sort"
 	"sync"
+	"time"
 
+	"github.com/enterprise/opencode-enterprise-shield/pkg/paths"
 	"github.com/enterprise/opencode-enterprise-shield/pkg/types"
 )
 
 // Engine evaluates access policies for users.
 type Engine struct {
-	policies       map[string]*types.UserPolicy // userID -> policy
-	deptPolicies   map[string]*types.UserPolicy // department -> default policy
-	defaultPolicy  *types.UserPolicy
-	mu             sync.RWMutex
+	policies      map[string]*types.UserPolicy // userID -> policy
+	deptPolicies  map[string]*types.UserPolicy // department -> default policy
+	rolePolicies  map[string]*types.UserPolicy // role -> policy
+	conditions    []*Condition
+	defaultPolicy *types.UserPolicy
+	basePolicy    *types.UserPolicy // default before any policy file is applied
+	mu            sync.RWMutex
+
+	// Policies set with SetUserPolicy, SetDepartmentPolicy and
+	// SetRolePolicy, which are kept over those of every loaded set
+	runtimeUsers map[string]*types.UserPolicy
+	runtimeDepts map[string]*types.UserPolicy
+	runtimeRoles map[string]*types.UserPolicy
+
+	policyFile  string
+	fileModTime time.Time
+	stopWatcher chan struct{}
+	watcherDone chan struct{}
+
+	overrideKeys []ed25519.PublicKey
+	revocations  *RevocationList
 }
 
 // NewEngine creates a new policy engine.
@@ -20,7 +43,12 @@ func NewEngine() *Engine {
 	return &Engine{
 		policies:      make(map[string]*types.UserPolicy),
 		deptPolicies:  make(map[string]*types.UserPolicy),
+		rolePolicies:  make(map[string]*types.UserPolicy),
 		defaultPolicy: DefaultPolicy(),
+		basePolicy:    DefaultPolicy(),
+		runtimeUsers:  make(map[string]*types.UserPolicy),
+		runtimeDepts:  make(map[string]*types.UserPolicy),
+		runtimeRoles:  make(map[string]*types.UserPolicy),
 	}
 }
 
@@ -37,19 +65,122 @@ func DefaultPolicy() *types.UserPolicy {
 }
 
 // Evaluate evaluates the policy for a request context.
+//
+// The user's own policy, or failing that their department's policy, or the
+// default policy, is merged with the policy of every role the user holds.
+// The most restrictive access level wins, provider allowlists intersect, a
+// model must satisfy every model allowlist and no denylist, and the lowest
+// request limits apply. Provider and model restrictions hold at every access
+// level, including unrestricted. The most restrictive matching condition
+// may then tighten the decision. PolicyApplied lists every contributor,
+// with the condition that decided the action last.
+//
+// A valid break-glass override presented with the request raises the access
+// level to the one it grants, and nothing else: provider and model
+// allowlists, a disabled policy and conditions still apply, so a condition
+// that blocks the request blocks it under an override too. An override that
+// is not valid is ignored, with a warning.
 func (e *Engine) Evaluate(ctx PolicyContext) types.PolicyDecision {
 	e.mu.RLock()
 	defer e.mu.RUnlock()
 
 	// Get effective policy
-	policy := e.getEffectivePolicy(ctx.UserID, ctx.Department)
+	policy := e.getEffectivePolicy(ctx.UserID, ctx.Department, ctx.Roles)
+
+	var override *types.Override
+	var overrideErr error
+	if ctx.Override != "" {
+		if override, overrideErr = e.verifyOverride(ctx); overrideErr == nil {
+			if restrictiveness(override.AccessLevel) < restrictiveness(policy.AccessLevel) {
+				policy.AccessLevel = override.AccessLevel
+			}
+			policy.applied = append(policy.applied, "override:"+override.ID)
+		}
+	}
+
+	decision := evaluateAccess(policy, ctx)
+	if override != nil {
+		decision.Override = override
+		if decision.Action != types.ActionBlock {
+			decision.Reason = "Break-glass override: " + override.Justification
+		}
+	} else if overrideErr != nil {
+		decision.OverrideError = overrideErr.Error()
+		decision.Warnings = append(decision.Warnings, types.Warning{
+			Rule:        "override",
+			Reason:      "Break-glass override not applied: " + overrideErr.Error(),
+			Remediation: "Ask for a new override, or continue without one",
+		})
+	}
+	if decision.Action == types.ActionBlock {
+		return decision
+	}
+	return e.applyConditions(decision, policy, ctx)
+}
+
+// applyConditions lets the matching conditions tighten a decision. The most
+// restrictive matching action applies; an exemption that matches stops the
+// conditions after it.
+func (e *Engine) applyConditions(decision types.PolicyDecision, policy *effectivePolicy, ctx PolicyContext) types.PolicyDecision {
+	// Conditions can only tighten the access decision. They see every role
+	// the user holds, not just those asserted by the caller.
+	ctx.Roles = e.getRoles(ctx.UserID, ctx.Department, ctx.Roles)
+	var matched []*Condition
+	var deciding *Condition
+	for _, condition := range e.conditions {
+		if !condition.Matches(ctx) {
+			continue
+		}
+		matched = append(matched, condition)
+		if condition.Action == types.ActionAllowWithWarning {
+			decision.Warnings = append(decision.Warnings, condition.warning())
+		}
+		if actionRank(condition.Action) > actionRank(decision.Action) {
+			decision.Action = condition.Action
+			decision.Reason = condition.reason()
+			deciding = condition
+		}
+		if condition.Exempt {
+			break
+		}
+	}
+
+	// The deciding condition is listed last
+	for _, condition := range matched {
+		if condition != deciding {
+			decision.PolicyApplied = append(decision.PolicyApplied, condition.policyID())
+		}
+	}
+	if deciding != nil {
+		decision.PolicyApplied = append(decision.PolicyApplied, deciding.policyID())
+		if decision.Action == types.ActionAllowWithSanitization {
+			decision.RequiredSanitization = policy.RequiredRules
+			decision.RuleProfiles = policy.profiles
+		}
+	}
+
+	return decision
+}
 
+// evaluateAccess decides a request from the effective policy alone.
+func evaluateAccess(policy *effectivePolicy, ctx PolicyContext) types.PolicyDecision {
 	// Check if policy is enabled
 	if !policy.Enabled {
 		return types.PolicyDecision{
 			Action:        types.ActionBlock,
 			Reason:        "User policy is disabled",
-			PolicyApplied: policy.PolicyID,
+			PolicyApplied: policy.applied,
+		}
+	}
+
+	// Provider and model allowlists apply at every access level
+	if policy.AccessLevel != types.AccessBlocked {
+		if reason := policy.modelDenial(ctx); reason != "" {
+			return types.PolicyDecision{
+				Action:        types.ActionBlock,
+				Reason:        reason,
+				PolicyApplied: policy.applied,
+			}
 		}
 	}
 
@@ -59,50 +190,122 @@ func (e *Engine) Evaluate(ctx PolicyContext) types.PolicyDecision {
 		return types.PolicyDecision{
 			Action:        types.ActionBlock,
 			Reason:        "User access is blocked",
-			PolicyApplied: policy.PolicyID,
+			PolicyApplied: policy.applied,
 		}
 	case types.AccessUnrestricted:
 		return types.PolicyDecision{
-			Action:        types.ActionAllow,
-			Reason:        "User has unrestricted access",
-			PolicyApplied: policy.PolicyID,
+			Action:                types.ActionAllow,
+			Reason:                "User has unrestricted access",
+			PolicyApplied:         policy.applied,
+			TokenBudget:           policy.TokenBudget,
+			DepartmentTokenBudget: policy.departmentBudget,
 		}
 	case types.AccessSanitizedOnly:
-		// Check provider allowlist
-		if len(policy.AllowedProviders) > 0 && ctx.Provider != "" {
-			allowed := false
-			for _, p := range policy.AllowedProviders {
-				if p == ctx.Provider {
-					allowed = true
-					break
-				}
-			}
-			if !allowed {
-				return types.PolicyDecision{
-					Action:        types.ActionBlock,
-					Reason:        "Provider not in allowed list",
-					PolicyApplied: policy.PolicyID,
-				}
-			}
+		return types.PolicyDecision{
+			Action:                types.ActionAllowWithSanitization,
+			Reason:                "Request requires sanitization",
+			PolicyApplied:         policy.applied,
+			RequiredSanitization:  policy.RequiredRules,
+			RuleProfiles:          policy.profiles,
+			TokenBudget:           policy.TokenBudget,
+			DepartmentTokenBudget: policy.departmentBudget,
 		}
+	}
+
+	// Default: allow with sanitization
+	return types.PolicyDecision{
+		Action:                types.ActionAllowWithSanitization,
+		PolicyApplied:         policy.applied,
+		RequiredSanitization:  policy.RequiredRules,
+		RuleProfiles:          policy.profiles,
+		TokenBudget:           policy.TokenBudget,
+		DepartmentTokenBudget: policy.departmentBudget,
+	}
+}
+
+// CanViewOriginals decides whether a user may see the original values behind
+// aliases created in another user's session, as when importing an exported
+// session. Blocked or disabled users are denied; users with sanitized-only
+// access may only view originals from their own department.
+func (e *Engine) CanViewOriginals(userID, department, ownerDepartment string) types.PolicyDecision {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	policy := e.getEffectivePolicy(userID, department, nil)
 
+	if !policy.Enabled || policy.AccessLevel == types.AccessBlocked {
 		return types.PolicyDecision{
-			Action:               types.ActionAllowWithSanitization,
-			Reason:               "Request requires sanitization",
-			PolicyApplied:        policy.PolicyID,
-			RequiredSanitization: policy.RequiredRules,
+			Action:        types.ActionBlock,
+			Reason:        "User access is blocked",
+			PolicyApplied: policy.applied,
+		}
+	}
+
+	if policy.AccessLevel == types.AccessSanitizedOnly && ownerDepartment != "" && ownerDepartment != department {
+		return types.PolicyDecision{
+			Action:        types.ActionBlock,
+			Reason:        "Originals belong to another department",
+			PolicyApplied: policy.applied,
 		}
 	}
 
-	// Default: allow with sanitization
 	return types.PolicyDecision{
-		Action:        types.ActionAllowWithSanitization,
-		PolicyApplied: policy.PolicyID,
+		Action:        types.ActionAllow,
+		Reason:        "User may view original values",
+		PolicyApplied: policy.applied,
+	}
+}
+
+// UserDepartment returns the department of a user's own policy, or "" if
+// they have none.
+func (e *Engine) UserDepartment(userID string) string {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	if policy, ok := e.policies[userID]; ok {
+		return policy.Department
+	}
+	return ""
+}
+
+// effectivePolicy is the merge of every policy that applies to a user.
+type effectivePolicy struct {
+	types.UserPolicy
+	applied  []string // IDs of the contributing policies, in merge order
+	profiles []string // Rule profiles required by any contributing policy
+
+	// restrictProviders is set when any contributing policy has a provider
+	// allowlist, since the intersection of allowlists may be empty.
+	restrictProviders bool
+
+	// modelAllowlists holds each contributing policy's model allowlist. Globs
+	// cannot be intersected, so a model must match every one of them.
+	modelAllowlists [][]string
+
+	// departmentBudget limits the combined tokens of the user's department.
+	departmentBudget *types.TokenBudget
+}
+
+// getEffectivePolicy merges the user's identity policy with the policies of
+// every role they hold. extraRoles are roles supplied by the caller, such as
+// groups from an identity provider.
+func (e *Engine) getEffectivePolicy(userID, department string, extraRoles []string) *effectivePolicy {
+	policies := []*types.UserPolicy{e.getIdentityPolicy(userID, department)}
+	for _, role := range e.getRoles(userID, department, extraRoles) {
+		if policy, ok := e.rolePolicies[role]; ok {
+			policies = append(policies, policy)
+		}
+	}
+	merged := mergePolicies(policies)
+	if policy, ok := e.deptPolicies[department]; ok && department != "" {
+		merged.departmentBudget = policy.DepartmentTokenBudget
 	}
+	return merged
 }
 
-// getEffectivePolicy returns the effective policy for a user.
-func (e *Engine) getEffectivePolicy(userID, department string) *types.UserPolicy {
+// getIdentityPolicy returns the user's own policy, their department's policy
+// or the default policy, in that order of precedence.
+func (e *Engine) getIdentityPolicy(userID, department string) *types.UserPolicy {
 	// Check user-specific policy first
 	if policy, ok := e.policies[userID]; ok {
 		return policy
@@ -119,22 +322,356 @@ func (e *Engine) getEffectivePolicy(userID, department string) *types.UserPolicy
 	return e.defaultPolicy
 }
 
-// SetUserPolicy sets or updates a user's policy.
+// getRoles returns the sorted roles a user holds: those granted by the
+// default, department and user policies and by the caller, expanded through
+// roles that include other roles.
+func (e *Engine) getRoles(userID, department string, extraRoles []string) []string {
+	pending := append([]string(nil), e.defaultPolicy.Roles...)
+	if policy, ok := e.deptPolicies[department]; ok && department != "" {
+		pending = append(pending, policy.Roles...)
+	}
+	if policy, ok := e.policies[userID]; ok {
+		pending = append(pending, policy.Roles...)
+	}
+	pending = append(pending, extraRoles...)
+
+	held := make(map[string]bool)
+	for len(pending) > 0 {
+		role := pending[len(pending)-1]
+		pending = pending[:len(pending)-1]
+		if held[role] {
+			continue
+		}
+		held[role] = true
+		if policy, ok := e.rolePolicies[role]; ok {
+			pending = append(pending, policy.Roles...)
+		}
+	}
+
+	roles := make([]string, 0, len(held))
+	for role := range held {
+		roles = append(roles, role)
+	}
+	sort.Strings(roles)
+	return roles
+}
+
+// mergePolicies merges policies so that the most restrictive setting of each
+// wins. The first policy supplies the merged policy's identity.
+func mergePolicies(policies []*types.UserPolicy) *effectivePolicy {
+	first := policies[0]
+	merged := &effectivePolicy{
+		UserPolicy: types.UserPolicy{
+			PolicyID:    first.PolicyID,
+			UserID:      first.UserID,
+			Department:  first.Department,
+			AccessLevel: types.AccessUnrestricted,
+			Enabled:     true,
+		},
+	}
+
+	for _, policy := range policies {
+		merged.applied = append(merged.applied, policy.PolicyID)
+		merged.Enabled = merged.Enabled && policy.Enabled
+
+		if restrictiveness(policy.AccessLevel) > restrictiveness(merged.AccessLevel) {
+			merged.AccessLevel = policy.AccessLevel
+		}
+
+		if len(policy.AllowedProviders) > 0 {
+			if merged.restrictProviders {
+				merged.AllowedProviders = intersect(merged.AllowedProviders, policy.AllowedProviders)
+			} else {
+				merged.AllowedProviders = append([]string(nil), policy.AllowedProviders...)
+				merged.restrictProviders = true
+			}
+		}
+
+		if len(policy.AllowedModels) > 0 {
+			merged.modelAllowlists = append(merged.modelAllowlists, policy.AllowedModels)
+		}
+		for _, glob := range policy.DeniedModels {
+			if !contains(merged.DeniedModels, glob) {
+				merged.DeniedModels = append(merged.DeniedModels, glob)
+			}
+		}
+
+		merged.TokenBudget = lowerBudget(merged.TokenBudget, policy.TokenBudget)
+
+		merged.DailyRequestLimit = lowerLimit(merged.DailyRequestLimit, policy.DailyRequestLimit)
+		merged.HourlyRequestLimit = lowerLimit(merged.HourlyRequestLimit, policy.HourlyRequestLimit)
+
+		for _, rule := range policy.RequiredRules {
+			if !contains(merged.RequiredRules, rule) {
+				merged.RequiredRules = append(merged.RequiredRules, rule)
+			}
+		}
+		if policy.RuleProfile != "" && !contains(merged.profiles, policy.RuleProfile) {
+			merged.profiles = append(merged.profiles, policy.RuleProfile)
+		}
+	}
+
+	return merged
+}
+
+// restrictiveness orders access levels from least to most restrictive.
+// Unknown levels are treated as blocked.
+func restrictiveness(level types.AccessLevel) int {
+	switch level {
+	case types.AccessUnrestricted:
+		return 0
+	case types.AccessSanitizedOnly:
+		return 1
+	default:
+		return 2
+	}
+}
+
+// lowerLimit returns the lower of two request limits, where zero means no limit.
+func lowerLimit(a, b int) int {
+	if a == 0 || (b != 0 && b < a) {
+		return b
+	}
+	return a
+}
+
+// lowerBudget merges two token budgets, keeping the lower of each
+// threshold, where zero or a nil budget means no threshold.
+func lowerBudget(a, b *types.TokenBudget) *types.TokenBudget {
+	if a == nil || b == nil {
+		if a == nil {
+			return b
+		}
+		return a
+	}
+	return &types.TokenBudget{
+		DailyWarning:   lowerTokens(a.DailyWarning, b.DailyWarning),
+		DailyLimit:     lowerTokens(a.DailyLimit, b.DailyLimit),
+		MonthlyWarning: lowerTokens(a.MonthlyWarning, b.MonthlyWarning),
+		MonthlyLimit:   lowerTokens(a.MonthlyLimit, b.MonthlyLimit),
+	}
+}
+
+// lowerTokens returns the lower of two token thresholds, where zero means none.
+func lowerTokens(a, b int64) int64 {
+	if a == 0 || (b != 0 && b < a) {
+		return b
+	}
+	return a
+}
+
+// intersect returns the elements of a that are also in b, in a's order.
+func intersect(a, b []string) []string {
+	result := make([]string, 0, len(a))
+	for _, v := range a {
+		if contains(b, v) {
+			result = append(result, v)
+		}
+	}
+	return result
+}
+
+// contains reports whether list contains v.
+func contains(list []string, v string) bool {
+	for _, item := range list {
+		if item == v {
+			return true
+		}
+	}
+	return false
+}
+
+// SetDefaultAccessLevel sets the access level of the built-in default policy.
+// A policy file loaded afterwards inherits it unless it sets its own.
+func (e *Engine) SetDefaultAccessLevel(level types.AccessLevel) error {
+	if !validAccessLevel(level) {
+		return fmt.Errorf("unknown access level %q", level)
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.basePolicy.AccessLevel = level
+	e.defaultPolicy.AccessLevel = level
+	return nil
+}
+
+// Load replaces the default, department, user and role policies and the
+// conditions with a policy set. Policies set at runtime with SetUserPolicy,
+// SetDepartmentPolicy or SetRolePolicy are kept and take precedence over
+// the set's policies for the same user, department or role.
+// The swap is atomic: concurrent evaluations see either the old or new set.
+func (e *Engine) Load(set *PolicySet) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.load(set)
+}
+
+// load applies a policy set. Caller must hold e.mu.
+func (e *Engine) load(set *PolicySet) {
+	e.defaultPolicy = set.Default
+	e.deptPolicies = overlay(set.Departments, e.runtimeDepts)
+	e.policies = overlay(set.Users, e.runtimeUsers)
+	e.rolePolicies = overlay(set.Roles, e.runtimeRoles)
+	e.conditions = set.Conditions
+}
+
+// overlay returns a copy of policies with the runtime policies added over it.
+func overlay(policies, runtime map[string]*types.UserPolicy) map[string]*types.UserPolicy {
+	merged := make(map[string]*types.UserPolicy, len(policies)+len(runtime))
+	for name, policy := range policies {
+		merged[name] = policy
+	}
+	for name, policy := range runtime {
+		merged[name] = policy
+	}
+	return merged
+}
+
+// LoadFile loads a policy file and remembers its path for Reload.
+// On error the current policies are left untouched.
+func (e *Engine) LoadFile(path string) error {
+	path, err := paths.ExpandHome(path)
+	if err != nil {
+		return err
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("failed to read policy file: %w", err)
+	}
+
+	e.mu.RLock()
+	base := *e.basePolicy
+	e.mu.RUnlock()
+
+	set, err := LoadFile(path, &base)
+	if err != nil {
+		return err
+	}
+
+	// The policies and the file they came from change together, so Reload
+	// never sees one without the other
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.load(set)
+	e.policyFile = path
+	e.fileModTime = info.ModTime()
+	return nil
+}
+
+// Reload reloads the policy file if it changed since it was last loaded.
+// It reports whether new policies were applied. If the file is invalid the
+// current policies stay in effect and the error is returned.
+func (e *Engine) Reload() (bool, error) {
+	e.mu.RLock()
+	path, loadedAt := e.policyFile, e.fileModTime
+	e.mu.RUnlock()
+
+	if path == "" {
+		return false, nil
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return false, fmt.Errorf("failed to read policy file: %w", err)
+	}
+	if info.ModTime().Equal(loadedAt) {
+		return false, nil
+	}
+
+	if err := e.LoadFile(path); err != nil {
+		// Don't retry the same broken file on every tick
+		e.mu.Lock()
+		e.fileModTime = info.ModTime()
+		e.mu.Unlock()
+		return false, err
+	}
+	return true, nil
+}
+
+// StartWatcher checks the policy file every interval and reloads it when it
+// changes, calling onReload after each reload attempt. Calling it again while
+// a watcher runs is a no-op.
+func (e *Engine) StartWatcher(interval time.Duration, onReload func(error)) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if interval <= 0 || e.policyFile == "" || e.stopWatcher != nil {
+		return
+	}
+
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	e.stopWatcher = stop
+	e.watcherDone = done
+
+	go func() {
+		defer close(done)
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				reloaded, err := e.Reload()
+				if (reloaded || err != nil) && onReload != nil {
+					onReload(err)
+				}
+			case <-stop:
+				return
+			}
+		}
+	}()
+}
+
+// Stop stops the policy file watcher, if running, and waits for it to exit.
+func (e *Engine) Stop() {
+	e.mu.Lock()
+	stop, done := e.stopWatcher, e.watcherDone
+	e.stopWatcher, e.watcherDone = nil, nil
+	e.mu.Unlock()
+
+	if stop != nil {
+		close(stop)
+		<-done
+	}
+}
+
+// SetUserPolicy sets or updates a user's policy. It is kept when a policy
+// set or file is loaded later.
 func (e *Engine) SetUserPolicy(userID string, policy *types.UserPolicy) {
 	e.mu.Lock()
 	defer e.mu.Unlock()
 
 	policy.UserID = userID
 	e.policies[userID] = policy
+	e.runtimeUsers[userID] = policy
 }
 
-// SetDepartmentPolicy sets the default policy for a department.
+// SetDepartmentPolicy sets the default policy for a department. It is kept
+// when a policy set or file is loaded later.
 func (e *Engine) SetDepartmentPolicy(department string, policy *types.UserPolicy) {
 	e.mu.Lock()
 	defer e.mu.Unlock()
 
 	policy.Department = department
 	e.deptPolicies[department] = policy
+	e.runtimeDepts[department] = policy
+}
+
+// SetRolePolicy sets the policy for a role. Users and departments hold roles
+// through their policies' Roles, and role policies may include other roles.
+// It is kept when a policy set or file is loaded later.
+func (e *Engine) SetRolePolicy(role string, policy *types.UserPolicy) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.rolePolicies[role] = policy
+	e.runtimeRoles[role] = policy
 }
 
 // GetUserPolicy retrieves a user's policy.
@@ -146,12 +683,14 @@ func (e *Engine) GetUserPolicy(userID string) (*types.UserPolicy, bool) {
 	return policy, ok
 }
 
-// DeleteUserPolicy removes a user's policy.
+// DeleteUserPolicy removes a user's policy. A policy for the user in a
+// policy file applies again from the next load.
 func (e *Engine) DeleteUserPolicy(userID string) {
 	e.mu.Lock()
 	defer e.mu.Unlock()
 
 	delete(e.policies, userID)
+	delete(e.runtimeUsers, userID)
 }
 
 // PolicyContext contains context for policy evaluation.
@@ -159,7 +698,13 @@ type PolicyContext struct {
 	UserID     string
 	Department string
 	Provider   string
+	Model      string
 	Content    string
 	SourceIP   string
-}
+	Roles      []string          // Roles asserted by the caller, e.g. identity provider groups
+	Violations []types.Violation // Findings of the compliance scan, for conditions
+	Time       time.Time         // Time of the request; zero means now
 
+	Scope    string // Conversation ID or workspace path, for overrides
+	Override string // Break-glass override token presented with the request
+}
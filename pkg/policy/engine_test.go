@@ -0,0 +1,176 @@
+package policy
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/enterprise/opencode-enterprise-shield/pkg/types"
+)
+
+const testRolesFile = `
+providers: [ollama]
+default:
+  accessLevel: sanitized_only
+  allowedProviders: [openai, anthropic, ollama]
+departments:
+  engineering:
+    roles: [developers]
+users:
+  alice:
+    accessLevel: unrestricted
+    roles: [sre]
+  bob:
+    roles: [contractors, data-science]
+roles:
+  developers:
+    requiredRules: [api_key]
+  sre:
+    allowedProviders: [anthropic, ollama]
+    dailyRequestLimit: 50
+  data-science:
+    allowedProviders: [openai, anthropic]
+    roles: [developers]
+  contractors:
+    accessLevel: blocked
+`
+
+func TestEvaluate_RolesMergeMostRestrictive(t *testing.T) {
+	set, err := ParseFile([]byte(testRolesFile), nil)
+	if err != nil {
+		t.Fatalf("ParseFile failed: %v", err)
+	}
+	engine := NewEngine()
+	engine.Load(set)
+
+	tests := []struct {
+		name     string
+		ctx      PolicyContext
+		action   types.Action
+		reason   string
+		policies []string
+	}{
+		{
+			name:     "role keeps user access level",
+			ctx:      PolicyContext{UserID: "alice", Provider: "anthropic"},
+			action:   types.ActionAllow,
+			policies: []string{"user:alice", "role:sre"},
+		},
+		{
+			name:     "blocked role wins",
+			ctx:      PolicyContext{UserID: "bob", Provider: "openai"},
+			action:   types.ActionBlock,
+			reason:   "User access is blocked",
+			policies: []string{"user:bob", "role:contractors", "role:data-science", "role:developers"},
+		},
+		{
+			name:     "department role applies",
+			ctx:      PolicyContext{UserID: "carol", Department: "engineering", Provider: "openai"},
+			action:   types.ActionAllowWithSanitization,
+			policies: []string{"department:engineering", "role:developers"},
+		},
+		{
+			name:     "allowlists intersect",
+			ctx:      PolicyContext{UserID: "dave", Provider: "ollama", Roles: []string{"data-science"}},
+			action:   types.ActionBlock,
+			reason:   "Provider not in allowed list",
+			policies: []string{"default", "role:data-science", "role:developers"},
+		},
+		{
+			name:     "intersection keeps shared provider",
+			ctx:      PolicyContext{UserID: "erin", Provider: "anthropic", Roles: []string{"sre", "data-science"}},
+			action:   types.ActionAllowWithSanitization,
+			policies: []string{"default", "role:data-science", "role:developers", "role:sre"},
+		},
+	}
+
+	for _, test := range tests {
+		decision := engine.Evaluate(test.ctx)
+		if decision.Action != test.action {
+			t.Errorf("%s: expected %s, got %s (%s)", test.name, test.action, decision.Action, decision.Reason)
+		}
+		if test.reason != "" && decision.Reason != test.reason {
+			t.Errorf("%s: expected reason %q, got %q", test.name, test.reason, decision.Reason)
+		}
+		if !reflect.DeepEqual(decision.PolicyApplied, test.policies) {
+			t.Errorf("%s: expected policies %v, got %v", test.name, test.policies, decision.PolicyApplied)
+		}
+	}
+
+	// sre and data-science share only anthropic
+	decision := engine.Evaluate(PolicyContext{UserID: "erin", Provider: "openai", Roles: []string{"sre", "data-science"}})
+	if decision.Action != types.ActionBlock {
+		t.Errorf("Expected provider outside the intersection to be blocked, got %s", decision.Action)
+	}
+	decision = engine.Evaluate(PolicyContext{UserID: "erin", Provider: "ollama", Roles: []string{"sre", "data-science"}})
+	if decision.Action != types.ActionBlock {
+		t.Errorf("Expected provider outside the intersection to be blocked, got %s", decision.Action)
+	}
+	decision = engine.Evaluate(PolicyContext{UserID: "carol", Department: "engineering"})
+	if !reflect.DeepEqual(decision.RequiredSanitization, []string{"api_key"}) {
+		t.Errorf("Expected role required rules to apply, got %v", decision.RequiredSanitization)
+	}
+}
+
+func TestMergePolicies(t *testing.T) {
+	merged := mergePolicies([]*types.UserPolicy{
+		{PolicyID: "a", AccessLevel: types.AccessUnrestricted, AllowedProviders: []string{"openai", "google"}, DailyRequestLimit: 100, Enabled: true},
+		{PolicyID: "b", AccessLevel: types.AccessSanitizedOnly, AllowedProviders: []string{"anthropic"}, HourlyRequestLimit: 5, Enabled: true},
+		{PolicyID: "c", AccessLevel: types.AccessUnrestricted, DailyRequestLimit: 40, RequiredRules: []string{"ssn"}, Enabled: false},
+	})
+
+	if merged.PolicyID != "a" || !reflect.DeepEqual(merged.applied, []string{"a", "b", "c"}) {
+		t.Errorf("Unexpected identity %s / %v", merged.PolicyID, merged.applied)
+	}
+	if merged.AccessLevel != types.AccessSanitizedOnly {
+		t.Errorf("Expected most restrictive access level, got %s", merged.AccessLevel)
+	}
+	if !merged.restrictProviders || len(merged.AllowedProviders) != 0 {
+		t.Errorf("Expected empty provider intersection, got %v", merged.AllowedProviders)
+	}
+	if merged.DailyRequestLimit != 40 || merged.HourlyRequestLimit != 5 {
+		t.Errorf("Expected lowest limits, got %d/%d", merged.DailyRequestLimit, merged.HourlyRequestLimit)
+	}
+	if merged.Enabled {
+		t.Error("Expected a disabled contributor to disable the merged policy")
+	}
+}
+
+func TestParseFile_UnknownRole(t *testing.T) {
+	_, err := ParseFile([]byte("users:\n  a:\n    roles: [ghost]\n"), nil)
+	if err == nil || err.Error() != "invalid policy file:\n  users.a: unknown role \"ghost\"" {
+		t.Errorf("Expected unknown role error, got %v", err)
+	}
+}
+
+func TestEvaluate_RuleProfiles(t *testing.T) {
+	set, err := ParseFile([]byte(`
+default:
+  ruleProfile: baseline
+departments:
+  vendors:
+    ruleProfile: contractor
+    requiredRules: [internal_email]
+    roles: [auditors]
+roles:
+  auditors:
+    ruleProfile: strict
+`), nil)
+	if err != nil {
+		t.Fatalf("ParseFile failed: %v", err)
+	}
+	engine := NewEngine()
+	engine.Load(set)
+
+	decision := engine.Evaluate(PolicyContext{UserID: "v", Department: "vendors"})
+	if !reflect.DeepEqual(decision.RuleProfiles, []string{"contractor", "strict"}) {
+		t.Errorf("Expected merged rule profiles, got %v", decision.RuleProfiles)
+	}
+	if !reflect.DeepEqual(decision.RequiredSanitization, []string{"internal_email"}) {
+		t.Errorf("Expected required rules, got %v", decision.RequiredSanitization)
+	}
+
+	decision = engine.Evaluate(PolicyContext{UserID: "w"})
+	if !reflect.DeepEqual(decision.RuleProfiles, []string{"baseline"}) {
+		t.Errorf("Expected default rule profile, got %v", decision.RuleProfiles)
+	}
+}
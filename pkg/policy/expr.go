@@ -0,0 +1,562 @@
+// Package policy provides a small expression language for policy rules.
+package policy
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// The expression language is a sandboxed subset of CEL. Expressions are
+// compiled and type-checked when the policy file loads, evaluate in bounded
+// time (there are no loops or user-defined functions) and must produce a bool.
+//
+// Variables:
+//
+//	user               string        requesting user ID
+//	department         string        user's department
+//	roles              list(string)  roles the user holds
+//	provider           string        LLM provider
+//	model              string        model name
+//	content_length     int           request content length in bytes
+//	violations.critical, .high, .medium, .low, .total
+//	                   int           compliance findings by severity
+//	hour               int           hour of day, 0-23
+//
+// Operators, by increasing precedence:
+//
+//	||  &&  == != < <= > >= in  + -  * / %  ! -(unary)  .field .method()
+//
+// Functions: size(x) for strings and lists, and the string methods
+// contains, startsWith, endsWith and matches (regular expression literal).
+//
+// Example:
+//
+//	violations.high > 3 && provider != "azure_openai"
+//	"contractors" in roles && !user.endsWith("@company.com")
+
+// Limits that keep compilation bounded for hostile input.
+const (
+	maxExpressionLength = 4096
+	maxExpressionDepth  = 64
+)
+
+// exprType is the static type of an expression.
+type exprType string
+
+const (
+	typeInt        exprType = "int"
+	typeString     exprType = "string"
+	typeBool       exprType = "bool"
+	typeIntList    exprType = "list(int)"
+	typeStringList exprType = "list(string)"
+	typeViolations exprType = "violations"
+)
+
+// exprVariables are the variables available to expressions.
+var exprVariables = map[string]exprType{
+	"user":           typeString,
+	"department":     typeString,
+	"roles":          typeStringList,
+	"provider":       typeString,
+	"model":          typeString,
+	"content_length": typeInt,
+	"violations":     typeViolations,
+	"hour":           typeInt,
+}
+
+// violationFields are the fields of the violations variable.
+var violationFields = []string{"critical", "high", "medium", "low", "total"}
+
+// ExpressionError is a compile error in an expression, with the 1-based
+// column where it was found.
+type ExpressionError struct {
+	Column  int
+	Message string
+}
+
+// Error implements error.
+func (e *ExpressionError) Error() string {
+	return fmt.Sprintf("column %d: %s", e.Column, e.Message)
+}
+
+// Expression is a compiled, type-checked policy expression.
+type Expression struct {
+	source string
+	root   exprNode
+}
+
+// CompileExpression parses and type-checks an expression. The expression
+// must evaluate to a bool.
+func CompileExpression(source string) (*Expression, error) {
+	if len(source) > maxExpressionLength {
+		return nil, errorAt(maxExpressionLength, "expression longer than %d characters", maxExpressionLength)
+	}
+
+	tokens, err := lex(source)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{tokens: tokens}
+	root, err := p.parseExpr()
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != tokenEOF {
+		return nil, errorAt(tok.pos, "unexpected %s", tok)
+	}
+
+	typ, err := check(root)
+	if err != nil {
+		return nil, err
+	}
+	if typ != typeBool {
+		return nil, errorAt(0, "expression must be bool, got %s", typ)
+	}
+
+	return &Expression{source: source, root: root}, nil
+}
+
+// String returns the expression source.
+func (x *Expression) String() string {
+	return x.source
+}
+
+// References reports whether the expression reads a variable.
+func (x *Expression) References(name string) bool {
+	return references(x.root, name)
+}
+
+// references reports whether a node or any node below it reads a variable.
+func references(node exprNode, name string) bool {
+	switch n := node.(type) {
+	case *identNode:
+		return n.name == name
+	case *listNode:
+		for _, element := range n.elements {
+			if references(element, name) {
+				return true
+			}
+		}
+	case *memberNode:
+		return references(n.target, name)
+	case *callNode:
+		if n.target != nil && references(n.target, name) {
+			return true
+		}
+		for _, arg := range n.args {
+			if references(arg, name) {
+				return true
+			}
+		}
+	case *unaryNode:
+		return references(n.operand, name)
+	case *binaryNode:
+		return references(n.left, name) || references(n.right, name)
+	}
+	return false
+}
+
+// errorAt creates an ExpressionError at a 0-based byte offset.
+func errorAt(pos int, format string, args ...interface{}) *ExpressionError {
+	return &ExpressionError{Column: pos + 1, Message: fmt.Sprintf(format, args...)}
+}
+
+// Lexer
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenIdent
+	tokenInt
+	tokenString
+	tokenOp
+)
+
+type token struct {
+	kind tokenKind
+	text string // Identifier, operator or decoded string
+	num  int64
+	pos  int
+}
+
+// String describes a token for error messages.
+func (t token) String() string {
+	switch t.kind {
+	case tokenEOF:
+		return "end of expression"
+	case tokenString:
+		return strconv.Quote(t.text)
+	case tokenInt:
+		return strconv.FormatInt(t.num, 10)
+	default:
+		return fmt.Sprintf("%q", t.text)
+	}
+}
+
+// operators lists the operators, longest first so "<=" wins over "<".
+var operators = []string{"&&", "||", "==", "!=", "<=", ">=", "<", ">", "!", "+", "-", "*", "/", "%", "(", ")", "[", "]", ",", "."}
+
+// lex splits an expression into tokens.
+func lex(source string) ([]token, error) {
+	var tokens []token
+
+	for i := 0; i < len(source); {
+		c := source[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+
+		case isIdentByte(c):
+			start := i
+			for i < len(source) && (isIdentByte(source[i]) || isDigit(source[i])) {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokenIdent, text: source[start:i], pos: start})
+
+		case isDigit(c):
+			start := i
+			for i < len(source) && isDigit(source[i]) {
+				i++
+			}
+			n, err := strconv.ParseInt(source[start:i], 10, 64)
+			if err != nil {
+				return nil, errorAt(start, "integer %s out of range", source[start:i])
+			}
+			tokens = append(tokens, token{kind: tokenInt, num: n, pos: start})
+
+		case c == '"' || c == '\'':
+			start := i
+			var b strings.Builder
+			i++
+			for {
+				if i >= len(source) {
+					return nil, errorAt(start, "unterminated string")
+				}
+				if source[i] == c {
+					i++
+					break
+				}
+				if source[i] == '\\' && i+1 < len(source) {
+					i++
+					switch source[i] {
+					case 'n':
+						b.WriteByte('\n')
+					case 't':
+						b.WriteByte('\t')
+					case '\\', '"', '\'':
+						b.WriteByte(source[i])
+					default:
+						// Keep unknown escapes so regular expressions like "\d" work
+						b.WriteByte('\\')
+						b.WriteByte(source[i])
+					}
+					i++
+					continue
+				}
+				b.WriteByte(source[i])
+				i++
+			}
+			tokens = append(tokens, token{kind: tokenString, text: b.String(), pos: start})
+
+		default:
+			matched := false
+			for _, op := range operators {
+				if strings.HasPrefix(source[i:], op) {
+					tokens = append(tokens, token{kind: tokenOp, text: op, pos: i})
+					i += len(op)
+					matched = true
+					break
+				}
+			}
+			if !matched {
+				if c == '=' || c == '&' || c == '|' {
+					return nil, errorAt(i, "unexpected %q (did you mean %q?)", string(c), string(c)+string(c))
+				}
+				return nil, errorAt(i, "unexpected character %q", string(c))
+			}
+		}
+	}
+
+	return append(tokens, token{kind: tokenEOF, pos: len(source)}), nil
+}
+
+// isIdentByte reports whether c may start an identifier.
+func isIdentByte(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+// isDigit reports whether c is a decimal digit.
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// AST
+
+type exprNode interface {
+	position() int
+}
+
+type literalNode struct {
+	pos   int
+	value interface{} // int64, string or bool
+}
+
+type listNode struct {
+	pos      int
+	elements []exprNode
+}
+
+type identNode struct {
+	pos  int
+	name string
+}
+
+type memberNode struct {
+	pos    int
+	target exprNode
+	field  string
+}
+
+type callNode struct {
+	pos    int
+	target exprNode // nil for global functions
+	name   string
+	args   []exprNode
+	regex  *regexp.Regexp // Compiled pattern for matches
+}
+
+type unaryNode struct {
+	pos     int
+	op      string
+	operand exprNode
+}
+
+type binaryNode struct {
+	pos         int
+	op          string
+	left, right exprNode
+}
+
+func (n *literalNode) position() int { return n.pos }
+func (n *listNode) position() int    { return n.pos }
+func (n *identNode) position() int   { return n.pos }
+func (n *memberNode) position() int  { return n.pos }
+func (n *callNode) position() int    { return n.pos }
+func (n *unaryNode) position() int   { return n.pos }
+func (n *binaryNode) position() int  { return n.pos }
+
+// Parser
+
+// parser is a recursive-descent parser over the token stream.
+type parser struct {
+	tokens []token
+	next   int
+	depth  int
+}
+
+// peek returns the next token without consuming it.
+func (p *parser) peek() token {
+	return p.tokens[p.next]
+}
+
+// advance consumes and returns the next token.
+func (p *parser) advance() token {
+	tok := p.tokens[p.next]
+	if tok.kind != tokenEOF {
+		p.next++
+	}
+	return tok
+}
+
+// acceptOp consumes the next token if it is one of the operators.
+func (p *parser) acceptOp(ops ...string) (token, bool) {
+	tok := p.peek()
+	if tok.kind != tokenOp && !(tok.kind == tokenIdent && tok.text == "in") {
+		return tok, false
+	}
+	for _, op := range ops {
+		if tok.text == op {
+			return p.advance(), true
+		}
+	}
+	return tok, false
+}
+
+// expectOp consumes an operator or reports what was found instead.
+func (p *parser) expectOp(op string) error {
+	if _, ok := p.acceptOp(op); !ok {
+		tok := p.peek()
+		return errorAt(tok.pos, "expected %q, found %s", op, tok)
+	}
+	return nil
+}
+
+// parseExpr parses an expression at the lowest precedence.
+func (p *parser) parseExpr() (exprNode, error) {
+	return p.parseBinary(0)
+}
+
+// precedence lists binary operators from lowest to highest precedence.
+var precedence = [][]string{
+	{"||"},
+	{"&&"},
+	{"==", "!=", "<", "<=", ">", ">=", "in"},
+	{"+", "-"},
+	{"*", "/", "%"},
+}
+
+// parseBinary parses left-associative binary operators at a precedence level.
+func (p *parser) parseBinary(level int) (exprNode, error) {
+	if level == len(precedence) {
+		return p.parseUnary()
+	}
+
+	left, err := p.parseBinary(level + 1)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		tok, ok := p.acceptOp(precedence[level]...)
+		if !ok {
+			return left, nil
+		}
+		right, err := p.parseBinary(level + 1)
+		if err != nil {
+			return nil, err
+		}
+		left = &binaryNode{pos: tok.pos, op: tok.text, left: left, right: right}
+	}
+}
+
+// parseUnary parses prefix operators.
+func (p *parser) parseUnary() (exprNode, error) {
+	p.depth++
+	defer func() { p.depth-- }()
+	if p.depth > maxExpressionDepth {
+		return nil, errorAt(p.peek().pos, "expression nested more than %d levels deep", maxExpressionDepth)
+	}
+
+	if tok, ok := p.acceptOp("!", "-"); ok {
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &unaryNode{pos: tok.pos, op: tok.text, operand: operand}, nil
+	}
+	return p.parsePostfix()
+}
+
+// parsePostfix parses field access and method calls.
+func (p *parser) parsePostfix() (exprNode, error) {
+	node, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		dot, ok := p.acceptOp(".")
+		if !ok {
+			return node, nil
+		}
+		name := p.advance()
+		if name.kind != tokenIdent {
+			return nil, errorAt(name.pos, "expected field or method name after \".\", found %s", name)
+		}
+		if _, ok := p.acceptOp("("); ok {
+			args, err := p.parseArgs()
+			if err != nil {
+				return nil, err
+			}
+			node = &callNode{pos: name.pos, target: node, name: name.text, args: args}
+			continue
+		}
+		node = &memberNode{pos: dot.pos, target: node, field: name.text}
+	}
+}
+
+// parseArgs parses call arguments after the opening parenthesis.
+func (p *parser) parseArgs() ([]exprNode, error) {
+	var args []exprNode
+	if _, ok := p.acceptOp(")"); ok {
+		return args, nil
+	}
+	for {
+		arg, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, arg)
+		if _, ok := p.acceptOp(")"); ok {
+			return args, nil
+		}
+		if err := p.expectOp(","); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// parsePrimary parses literals, variables, function calls, lists and
+// parenthesized expressions.
+func (p *parser) parsePrimary() (exprNode, error) {
+	tok := p.advance()
+
+	switch tok.kind {
+	case tokenInt:
+		return &literalNode{pos: tok.pos, value: tok.num}, nil
+	case tokenString:
+		return &literalNode{pos: tok.pos, value: tok.text}, nil
+	case tokenIdent:
+		switch tok.text {
+		case "true", "false":
+			return &literalNode{pos: tok.pos, value: tok.text == "true"}, nil
+		case "in":
+			return nil, errorAt(tok.pos, "unexpected \"in\"")
+		}
+		if _, ok := p.acceptOp("("); ok {
+			args, err := p.parseArgs()
+			if err != nil {
+				return nil, err
+			}
+			return &callNode{pos: tok.pos, name: tok.text, args: args}, nil
+		}
+		return &identNode{pos: tok.pos, name: tok.text}, nil
+	case tokenOp:
+		switch tok.text {
+		case "(":
+			node, err := p.parseExpr()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expectOp(")"); err != nil {
+				return nil, err
+			}
+			return node, nil
+		case "[":
+			list := &listNode{pos: tok.pos}
+			if _, ok := p.acceptOp("]"); ok {
+				return list, nil
+			}
+			for {
+				element, err := p.parseExpr()
+				if err != nil {
+					return nil, err
+				}
+				list.elements = append(list.elements, element)
+				if _, ok := p.acceptOp("]"); ok {
+					return list, nil
+				}
+				if err := p.expectOp(","); err != nil {
+					return nil, err
+				}
+			}
+		}
+	case tokenEOF:
+		return nil, errorAt(tok.pos, "unexpected end of expression")
+	}
+
+	return nil, errorAt(tok.pos, "unexpected %s", tok)
+}
@@ -0,0 +1,465 @@
+// Package policy provides type checking and evaluation of policy expressions.
+package policy
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/enterprise/opencode-enterprise-shield/pkg/types"
+)
+
+// check type-checks a node and returns its type.
+func check(node exprNode) (exprType, error) {
+	switch n := node.(type) {
+	case *literalNode:
+		switch n.value.(type) {
+		case int64:
+			return typeInt, nil
+		case string:
+			return typeString, nil
+		default:
+			return typeBool, nil
+		}
+
+	case *listNode:
+		if len(n.elements) == 0 {
+			return "", errorAt(n.pos, "empty list literal")
+		}
+		var elementType exprType
+		for _, element := range n.elements {
+			typ, err := check(element)
+			if err != nil {
+				return "", err
+			}
+			if elementType == "" {
+				elementType = typ
+			} else if typ != elementType {
+				return "", errorAt(element.position(), "list elements must all be %s, got %s", elementType, typ)
+			}
+		}
+		switch elementType {
+		case typeInt:
+			return typeIntList, nil
+		case typeString:
+			return typeStringList, nil
+		}
+		return "", errorAt(n.pos, "lists of %s are not supported", elementType)
+
+	case *identNode:
+		typ, ok := exprVariables[n.name]
+		if !ok {
+			return "", errorAt(n.pos, "unknown variable %q%s", n.name, suggest(n.name, sortedVariables()))
+		}
+		return typ, nil
+
+	case *memberNode:
+		typ, err := check(n.target)
+		if err != nil {
+			return "", err
+		}
+		if typ != typeViolations {
+			return "", errorAt(n.pos, "%s has no field %q", typ, n.field)
+		}
+		if !contains(violationFields, n.field) {
+			return "", errorAt(n.pos+1, "unknown field %q on violations%s", n.field, suggest(n.field, violationFields))
+		}
+		return typeInt, nil
+
+	case *callNode:
+		return checkCall(n)
+
+	case *unaryNode:
+		typ, err := check(n.operand)
+		if err != nil {
+			return "", err
+		}
+		want := typeBool
+		if n.op == "-" {
+			want = typeInt
+		}
+		if typ != want {
+			return "", errorAt(n.pos, "operator %s needs %s, got %s", n.op, want, typ)
+		}
+		return want, nil
+
+	case *binaryNode:
+		left, err := check(n.left)
+		if err != nil {
+			return "", err
+		}
+		right, err := check(n.right)
+		if err != nil {
+			return "", err
+		}
+
+		switch n.op {
+		case "&&", "||":
+			if left != typeBool || right != typeBool {
+				return "", errorAt(n.pos, "operator %s needs bool operands, got %s and %s", n.op, left, right)
+			}
+			return typeBool, nil
+		case "==", "!=":
+			if left != right || (left != typeInt && left != typeString && left != typeBool) {
+				return "", errorAt(n.pos, "cannot compare %s %s %s", left, n.op, right)
+			}
+			return typeBool, nil
+		case "<", "<=", ">", ">=":
+			if left != right || (left != typeInt && left != typeString) {
+				return "", errorAt(n.pos, "cannot compare %s %s %s", left, n.op, right)
+			}
+			return typeBool, nil
+		case "in":
+			if (left == typeString && right == typeStringList) || (left == typeInt && right == typeIntList) {
+				return typeBool, nil
+			}
+			return "", errorAt(n.pos, "cannot test %s in %s", left, right)
+		case "+":
+			if left == right && (left == typeInt || left == typeString) {
+				return left, nil
+			}
+			return "", errorAt(n.pos, "cannot add %s and %s", left, right)
+		default:
+			if left != typeInt || right != typeInt {
+				return "", errorAt(n.pos, "operator %s needs int operands, got %s and %s", n.op, left, right)
+			}
+			return typeInt, nil
+		}
+	}
+
+	return "", errorAt(node.position(), "unsupported expression")
+}
+
+// checkCall type-checks a function or method call.
+func checkCall(n *callNode) (exprType, error) {
+	args := make([]exprType, len(n.args))
+	for i, arg := range n.args {
+		typ, err := check(arg)
+		if err != nil {
+			return "", err
+		}
+		args[i] = typ
+	}
+
+	if n.target == nil {
+		if n.name != "size" {
+			return "", errorAt(n.pos, "unknown function %q", n.name)
+		}
+		if len(args) != 1 || !sizeable(args[0]) {
+			return "", errorAt(n.pos, "size needs one string or list argument")
+		}
+		return typeInt, nil
+	}
+
+	target, err := check(n.target)
+	if err != nil {
+		return "", err
+	}
+
+	switch n.name {
+	case "size":
+		if len(args) != 0 || !sizeable(target) {
+			return "", errorAt(n.pos, "%s has no method size()", target)
+		}
+		return typeInt, nil
+	case "contains", "startsWith", "endsWith", "matches":
+		if target != typeString {
+			return "", errorAt(n.pos, "%s has no method %s()", target, n.name)
+		}
+		if len(args) != 1 || args[0] != typeString {
+			return "", errorAt(n.pos, "%s needs one string argument", n.name)
+		}
+		if n.name == "matches" {
+			literal, ok := n.args[0].(*literalNode)
+			if !ok {
+				return "", errorAt(n.args[0].position(), "matches needs a string literal pattern")
+			}
+			re, err := regexp.Compile(literal.value.(string))
+			if err != nil {
+				return "", errorAt(literal.pos, "invalid pattern: %v", err)
+			}
+			n.regex = re
+		}
+		return typeBool, nil
+	}
+
+	return "", errorAt(n.pos, "unknown method %q%s", n.name, suggest(n.name, []string{"contains", "endsWith", "matches", "size", "startsWith"}))
+}
+
+// sizeable reports whether size() applies to a type.
+func sizeable(typ exprType) bool {
+	return typ == typeString || typ == typeStringList || typ == typeIntList
+}
+
+// errDivisionByZero is returned when an expression divides by zero.
+var errDivisionByZero = errors.New("division by zero")
+
+// exprEnv holds variable values for one evaluation.
+type exprEnv struct {
+	vars       map[string]interface{}
+	violations map[string]int64
+}
+
+// newExprEnv builds the evaluation context for a request. The hour is taken
+// in loc.
+func newExprEnv(ctx PolicyContext, loc *time.Location) *exprEnv {
+	now := ctx.Time
+	if now.IsZero() {
+		now = time.Now()
+	}
+
+	violations := map[string]int64{"total": int64(len(ctx.Violations))}
+	for _, v := range ctx.Violations {
+		switch v.Severity {
+		case types.SeverityCritical, types.SeverityHigh, types.SeverityMedium, types.SeverityLow:
+			violations[string(v.Severity)]++
+		}
+	}
+
+	roles := append([]string(nil), ctx.Roles...)
+	return &exprEnv{
+		vars: map[string]interface{}{
+			"user":           ctx.UserID,
+			"department":     ctx.Department,
+			"roles":          roles,
+			"provider":       ctx.Provider,
+			"model":          ctx.Model,
+			"content_length": int64(len(ctx.Content)),
+			"hour":           int64(now.In(loc).Hour()),
+		},
+		violations: violations,
+	}
+}
+
+// Eval evaluates the expression for a request context, taking the hour of
+// day in loc (local time if nil).
+func (x *Expression) Eval(ctx PolicyContext, loc *time.Location) (bool, error) {
+	if loc == nil {
+		loc = time.Local
+	}
+	value, err := eval(x.root, newExprEnv(ctx, loc))
+	if err != nil {
+		return false, err
+	}
+	return value.(bool), nil
+}
+
+// eval evaluates a type-checked node.
+func eval(node exprNode, env *exprEnv) (interface{}, error) {
+	switch n := node.(type) {
+	case *literalNode:
+		return n.value, nil
+
+	case *listNode:
+		values := make([]interface{}, len(n.elements))
+		for i, element := range n.elements {
+			value, err := eval(element, env)
+			if err != nil {
+				return nil, err
+			}
+			values[i] = value
+		}
+		return values, nil
+
+	case *identNode:
+		return env.vars[n.name], nil
+
+	case *memberNode:
+		return env.violations[n.field], nil
+
+	case *callNode:
+		return evalCall(n, env)
+
+	case *unaryNode:
+		value, err := eval(n.operand, env)
+		if err != nil {
+			return nil, err
+		}
+		if n.op == "!" {
+			return !value.(bool), nil
+		}
+		return -value.(int64), nil
+
+	case *binaryNode:
+		left, err := eval(n.left, env)
+		if err != nil {
+			return nil, err
+		}
+
+		// Short-circuit logical operators
+		switch n.op {
+		case "&&":
+			if !left.(bool) {
+				return false, nil
+			}
+			return eval(n.right, env)
+		case "||":
+			if left.(bool) {
+				return true, nil
+			}
+			return eval(n.right, env)
+		}
+
+		right, err := eval(n.right, env)
+		if err != nil {
+			return nil, err
+		}
+		return evalBinary(n.op, left, right)
+	}
+
+	return nil, fmt.Errorf("unsupported expression at column %d", node.position()+1)
+}
+
+// evalBinary applies a non-logical binary operator.
+func evalBinary(op string, left, right interface{}) (interface{}, error) {
+	switch op {
+	case "==":
+		return left == right, nil
+	case "!=":
+		return left != right, nil
+	case "in":
+		switch list := right.(type) {
+		case []string:
+			return contains(list, left.(string)), nil
+		case []interface{}:
+			for _, v := range list {
+				if v == left {
+					return true, nil
+				}
+			}
+		}
+		return false, nil
+	}
+
+	if l, ok := left.(string); ok {
+		r := right.(string)
+		switch op {
+		case "+":
+			return l + r, nil
+		case "<":
+			return l < r, nil
+		case "<=":
+			return l <= r, nil
+		case ">":
+			return l > r, nil
+		default:
+			return l >= r, nil
+		}
+	}
+
+	l, r := left.(int64), right.(int64)
+	switch op {
+	case "+":
+		return l + r, nil
+	case "-":
+		return l - r, nil
+	case "*":
+		return l * r, nil
+	case "/", "%":
+		if r == 0 {
+			return nil, errDivisionByZero
+		}
+		if op == "/" {
+			return l / r, nil
+		}
+		return l % r, nil
+	case "<":
+		return l < r, nil
+	case "<=":
+		return l <= r, nil
+	case ">":
+		return l > r, nil
+	default:
+		return l >= r, nil
+	}
+}
+
+// evalCall evaluates a function or method call.
+func evalCall(n *callNode, env *exprEnv) (interface{}, error) {
+	var target interface{}
+	var err error
+	if n.target != nil {
+		if target, err = eval(n.target, env); err != nil {
+			return nil, err
+		}
+	}
+	args := make([]interface{}, len(n.args))
+	for i, arg := range n.args {
+		if args[i], err = eval(arg, env); err != nil {
+			return nil, err
+		}
+	}
+
+	switch n.name {
+	case "size":
+		if n.target == nil {
+			target = args[0]
+		}
+		switch v := target.(type) {
+		case string:
+			return int64(len(v)), nil
+		case []string:
+			return int64(len(v)), nil
+		case []interface{}:
+			return int64(len(v)), nil
+		}
+	case "contains":
+		return strings.Contains(target.(string), args[0].(string)), nil
+	case "startsWith":
+		return strings.HasPrefix(target.(string), args[0].(string)), nil
+	case "endsWith":
+		return strings.HasSuffix(target.(string), args[0].(string)), nil
+	case "matches":
+		return n.regex.MatchString(target.(string)), nil
+	}
+
+	return nil, fmt.Errorf("unsupported call %s at column %d", n.name, n.pos+1)
+}
+
+// sortedVariables returns the variable names in sorted order.
+func sortedVariables() []string {
+	names := make([]string, 0, len(exprVariables))
+	for name := range exprVariables {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// suggest returns a "did you mean" hint for a misspelled name, or "".
+func suggest(name string, candidates []string) string {
+	best, bestDistance := "", 3
+	for _, candidate := range candidates {
+		if d := editDistance(name, candidate); d < bestDistance {
+			best, bestDistance = candidate, d
+		}
+	}
+	if best == "" {
+		return ""
+	}
+	return fmt.Sprintf(" (did you mean %q?)", best)
+}
+
+// editDistance returns the Levenshtein distance between two strings.
+func editDistance(a, b string) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(b)]
+}
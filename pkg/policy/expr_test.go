@@ -0,0 +1,143 @@
+package policy
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/enterprise/opencode-enterprise-shield/pkg/types"
+)
+
+func TestExpression_Eval(t *testing.T) {
+	ctx := PolicyContext{
+		UserID:     "alice@contractor.io",
+		Department: "engineering",
+		Roles:      []string{"contractors", "sre"},
+		Provider:   "openai",
+		Model:      "gpt-4o",
+		Content:    "0123456789",
+		Violations: []types.Violation{
+			{Severity: types.SeverityHigh}, {Severity: types.SeverityHigh},
+			{Severity: types.SeverityHigh}, {Severity: types.SeverityHigh},
+			{Severity: types.SeverityLow},
+		},
+		Time: time.Date(2026, 10, 19, 14, 30, 0, 0, time.UTC),
+	}
+
+	tests := []struct {
+		expr string
+		want bool
+	}{
+		{`violations.high > 3 && provider != "azure_openai"`, true},
+		{`violations.high > 3 && provider != "openai"`, false},
+		{`violations.total == 5 && violations.critical == 0`, true},
+		{`"contractors" in roles && !user.endsWith("@company.com")`, true},
+		{`provider in ["anthropic", "google"]`, false},
+		{`hour >= 9 && hour < 17`, true},
+		{`hour in [13, 14]`, true},
+		{`content_length * 2 - 5 == 15 && content_length % 3 == 1`, true},
+		{`size(roles) == 2 && user.size() > 10`, true},
+		{`model.startsWith("gpt-") && model.contains("4o")`, true},
+		{`user.matches("^[a-z]+@contractor\\.io$")`, true},
+		{`department + "/" + provider == "engineering/openai"`, true},
+		{`-content_length < 0 || 1 / 0 == 0`, true},
+		{`(false || true) && !(hour > 20)`, true},
+		{`'sre' in roles`, true},
+	}
+
+	for _, test := range tests {
+		expr, err := CompileExpression(test.expr)
+		if err != nil {
+			t.Errorf("%s: compile failed: %v", test.expr, err)
+			continue
+		}
+		got, err := expr.Eval(ctx, time.UTC)
+		if err != nil {
+			t.Errorf("%s: eval failed: %v", test.expr, err)
+			continue
+		}
+		if got != test.want {
+			t.Errorf("%s: expected %v, got %v", test.expr, test.want, got)
+		}
+	}
+}
+
+func TestExpression_DivisionByZero(t *testing.T) {
+	expr, err := CompileExpression(`content_length / violations.critical > 1`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := expr.Eval(PolicyContext{Content: "abc"}, nil); err == nil {
+		t.Error("Expected division by zero to fail")
+	}
+}
+
+func TestCompileExpression_Errors(t *testing.T) {
+	tests := []struct {
+		expr string
+		want string
+	}{
+		{`violations.hgh > 3`, `column 12: unknown field "hgh" on violations (did you mean "high"?)`},
+		{`usr == "a"`, `column 1: unknown variable "usr" (did you mean "user"?)`},
+		{`provider == 3`, `column 10: cannot compare string == int`},
+		{`hour + 1`, `column 1: expression must be bool, got int`},
+		{`hour > 3 & true`, `column 10: unexpected "&" (did you mean "&&"?)`},
+		{`provider = "openai"`, `column 10: unexpected "=" (did you mean "=="?)`},
+		{`(hour > 3`, `column 10: expected ")", found end of expression`},
+		{`"abc`, `column 1: unterminated string`},
+		{`hour > 3 && violations`, `column 10: operator && needs bool operands, got bool and violations`},
+		{`provider in roles || 1 in roles`, `column 24: cannot test int in list(string)`},
+		{`user.matches("(")`, `column 14: invalid pattern`},
+		{`user.matches(provider)`, `column 14: matches needs a string literal pattern`},
+		{`exec("rm")`, `column 1: unknown function "exec"`},
+		{`user.lower() == "a"`, `column 6: unknown method "lower"`},
+		{`hour.high > 1`, `column 5: int has no field "high"`},
+		{`[1, "a"] == []`, `column 5: list elements must all be int, got string`},
+		{`hour > 3 hour`, `column 10: unexpected "hour"`},
+		{``, `column 1: unexpected end of expression`},
+		{strings.Repeat("(", 100) + "true" + strings.Repeat(")", 100), `column 65: expression nested more than 64 levels deep`},
+		{strings.Repeat("!", 100) + "true", `column 65: expression nested more than 64 levels deep`},
+	}
+
+	for _, test := range tests {
+		_, err := CompileExpression(test.expr)
+		if err == nil || !strings.HasPrefix(err.Error(), test.want) {
+			t.Errorf("%s: expected error %q, got %v", test.expr, test.want, err)
+		}
+	}
+}
+
+func TestEvaluate_ConditionExpression(t *testing.T) {
+	set, err := ParseFile([]byte(`
+users:
+  alice:
+    roles: [contractors]
+roles:
+  contractors: {}
+conditions:
+  - name: contractors-off-azure
+    when: '"contractors" in roles && provider != "azure_openai"'
+    action: block
+  - name: broken
+    when: 'violations.high / violations.critical > 1'
+    action: allow_with_sanitization
+`), nil)
+	if err != nil {
+		t.Fatalf("ParseFile failed: %v", err)
+	}
+	engine := NewEngine()
+	engine.Load(set)
+
+	if decision := engine.Evaluate(PolicyContext{UserID: "alice", Provider: "openai"}); decision.Action != types.ActionBlock {
+		t.Errorf("Expected role from the policy file to reach the expression, got %s", decision.Action)
+	}
+	if decision := engine.Evaluate(PolicyContext{UserID: "alice", Provider: "azure_openai"}); decision.Action != types.ActionAllowWithSanitization {
+		t.Errorf("Expected failing expression to match, got %s", decision.Action)
+	}
+
+	_, err = ParseFile([]byte("conditions:\n  - name: typo\n    when: 'provder == \"openai\"'\n    action: block\n"), nil)
+	want := `conditions[0] (typo): when: column 1: unknown variable "provder" (did you mean "provider"?)`
+	if err == nil || !strings.Contains(err.Error(), want) {
+		t.Errorf("Expected %q, got %v", want, err)
+	}
+}
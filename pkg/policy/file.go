@@ -0,0 +1,332 @@
+// Package policy provides declarative policy file loading.
+package policy
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/enterprise/opencode-enterprise-shield/pkg/paths"
+	"github.com/enterprise/opencode-enterprise-shield/pkg/types"
+	"gopkg.in/yaml.v3"
+)
+
+// KnownProviders lists the LLM providers a policy file may reference without
+// declaring them. Files can declare additional providers under "providers".
+var KnownProviders = []string{"openai", "anthropic", "azure_openai", "google"}
+
+// File is the declarative policy file: a default policy, per-department
+// policies, per-user overrides and role policies. Users and departments hold
+// roles by listing them under "roles"; a user's policy is merged with those
+// of all their roles, with the most restrictive setting winning.
+//
+// Example:
+//
+//	default:
+//	  accessLevel: sanitized_only
+//	  allowedProviders: [openai, anthropic]
+//	departments:
+//	  contractors:
+//	    accessLevel: blocked
+//	users:
+//	  alice@example.com:
+//	    accessLevel: unrestricted
+//	    roles: [sre]
+//	roles:
+//	  sre:
+//	    allowedProviders: [anthropic]
+//	    allowedModels: [anthropic/claude-*]
+//
+// Conditions are described on Condition.
+type File struct {
+	Providers   []string               `yaml:"providers,omitempty"`
+	Default     *PolicySpec            `yaml:"default,omitempty"`
+	Departments map[string]*PolicySpec `yaml:"departments,omitempty"`
+	Users       map[string]*PolicySpec `yaml:"users,omitempty"`
+	Roles       map[string]*PolicySpec `yaml:"roles,omitempty"`
+	Conditions  []*Condition           `yaml:"conditions,omitempty"`
+}
+
+// PolicySpec is a policy as written in a policy file. Fields left unset in a
+// department or user policy fall back to the default policy; fields left
+// unset in a role policy impose no restriction. Roles are never inherited.
+type PolicySpec struct {
+	PolicyID           string            `yaml:"policyId,omitempty"`
+	AccessLevel        types.AccessLevel `yaml:"accessLevel,omitempty"`
+	AllowedProviders   []string          `yaml:"allowedProviders,omitempty"`
+	DailyRequestLimit  int               `yaml:"dailyRequestLimit,omitempty"`
+	HourlyRequestLimit int               `yaml:"hourlyRequestLimit,omitempty"`
+	RequiredRules      []string          `yaml:"requiredRules,omitempty"`
+	RuleProfile        string            `yaml:"ruleProfile,omitempty"`
+	Roles              []string          `yaml:"roles,omitempty"`
+	Enabled            *bool             `yaml:"enabled,omitempty"`
+
+	AllowedModels []string `yaml:"allowedModels,omitempty"` // "provider/model" globs
+	DeniedModels  []string `yaml:"deniedModels,omitempty"`
+
+	TokenBudget           *types.TokenBudget `yaml:"tokenBudget,omitempty"`           // Per user
+	DepartmentTokenBudget *types.TokenBudget `yaml:"departmentTokenBudget,omitempty"` // Department total, departments only
+
+	Department string `yaml:"department,omitempty"` // The user's department, users only
+}
+
+// PolicySet is a validated, resolved set of policies ready to load into an Engine.
+type PolicySet struct {
+	Default     *types.UserPolicy            `json:"default"`
+	Departments map[string]*types.UserPolicy `json:"departments"`
+	Users       map[string]*types.UserPolicy `json:"users"`
+	Roles       map[string]*types.UserPolicy `json:"roles"`
+	Conditions  []*Condition                 `json:"conditions,omitempty"`
+}
+
+// ParseFile parses and validates a policy file. Unset fields of the file's
+// default policy are taken from base, or from DefaultPolicy if base is nil.
+func ParseFile(data []byte, base *types.UserPolicy) (*PolicySet, error) {
+	var file File
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&file); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("failed to parse policy file: %w", err)
+	}
+	return file.Resolve(base)
+}
+
+// LoadFile reads, parses and validates a policy file from disk.
+func LoadFile(path string, base *types.UserPolicy) (*PolicySet, error) {
+	path, err := paths.ExpandHome(path)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read policy file: %w", err)
+	}
+	return ParseFile(data, base)
+}
+
+// Resolve validates the file and resolves every policy against the default.
+// All validation problems are reported together.
+func (f *File) Resolve(base *types.UserPolicy) (*PolicySet, error) {
+	var problems []string
+
+	if base == nil {
+		base = DefaultPolicy()
+	}
+
+	providers := make(map[string]bool)
+	for _, p := range KnownProviders {
+		providers[p] = true
+	}
+	for _, p := range f.Providers {
+		providers[p] = true
+	}
+
+	policyIDs := make(map[string]string) // policyID -> where it was defined
+	resolve := func(where, defaultID string, spec *PolicySpec, base *types.UserPolicy) *types.UserPolicy {
+		if spec == nil {
+			spec = &PolicySpec{}
+		}
+		policy := spec.resolve(defaultID, base)
+
+		if previous, ok := policyIDs[policy.PolicyID]; ok {
+			problems = append(problems, fmt.Sprintf("%s: duplicate policyId %q (also used by %s)", where, policy.PolicyID, previous))
+		} else {
+			policyIDs[policy.PolicyID] = where
+		}
+		// Inherited values are reported once, where they are defined
+		if (spec.AccessLevel != "" || where == "default") && !validAccessLevel(policy.AccessLevel) {
+			problems = append(problems, fmt.Sprintf("%s: unknown access level %q", where, policy.AccessLevel))
+		}
+		allowed := spec.AllowedProviders
+		if where == "default" {
+			allowed = policy.AllowedProviders
+		}
+		for _, p := range allowed {
+			if !providers[p] {
+				problems = append(problems, fmt.Sprintf("%s: unknown provider %q", where, p))
+			}
+		}
+		models := append(append([]string(nil), spec.AllowedModels...), spec.DeniedModels...)
+		if where == "default" {
+			models = append(append([]string(nil), policy.AllowedModels...), policy.DeniedModels...)
+		}
+		for _, glob := range models {
+			if problem := checkModelGlob(glob); problem != "" {
+				problems = append(problems, fmt.Sprintf("%s: %s", where, problem))
+			}
+		}
+		if spec.TokenBudget != nil || where == "default" {
+			for _, problem := range checkTokenBudget(policy.TokenBudget) {
+				problems = append(problems, fmt.Sprintf("%s: tokenBudget: %s", where, problem))
+			}
+		}
+		if spec.Department != "" && !strings.HasPrefix(where, "users.") {
+			problems = append(problems, fmt.Sprintf("%s: department only applies to users", where))
+		}
+		if spec.DepartmentTokenBudget != nil && !strings.HasPrefix(where, "departments.") {
+			problems = append(problems, fmt.Sprintf("%s: departmentTokenBudget only applies to departments", where))
+		}
+		for _, problem := range checkTokenBudget(spec.DepartmentTokenBudget) {
+			problems = append(problems, fmt.Sprintf("%s: departmentTokenBudget: %s", where, problem))
+		}
+		if policy.DailyRequestLimit < 0 || policy.HourlyRequestLimit < 0 {
+			problems = append(problems, fmt.Sprintf("%s: request limits must not be negative", where))
+		}
+		for _, role := range spec.Roles {
+			if _, ok := f.Roles[role]; !ok {
+				problems = append(problems, fmt.Sprintf("%s: unknown role %q", where, role))
+			}
+		}
+		return policy
+	}
+
+	set := &PolicySet{
+		Departments: make(map[string]*types.UserPolicy),
+		Users:       make(map[string]*types.UserPolicy),
+		Roles:       make(map[string]*types.UserPolicy),
+	}
+	set.Default = resolve("default", "default", f.Default, base)
+
+	for _, department := range sortedKeys(f.Departments) {
+		policy := resolve("departments."+department, "department:"+department, f.Departments[department], set.Default)
+		policy.Department = department
+		set.Departments[department] = policy
+	}
+	for _, userID := range sortedKeys(f.Users) {
+		policy := resolve("users."+userID, "user:"+userID, f.Users[userID], set.Default)
+		policy.UserID = userID
+		if spec := f.Users[userID]; spec != nil {
+			policy.Department = spec.Department
+		}
+		set.Users[userID] = policy
+	}
+
+	// Roles only narrow access, so they resolve against an unrestricted base
+	roleBase := &types.UserPolicy{AccessLevel: types.AccessUnrestricted, Enabled: true}
+	for _, role := range sortedKeys(f.Roles) {
+		set.Roles[role] = resolve("roles."+role, "role:"+role, f.Roles[role], roleBase)
+	}
+
+	names := make(map[string]bool)
+	for i, condition := range f.Conditions {
+		where := fmt.Sprintf("conditions[%d]", i)
+		if condition == nil {
+			problems = append(problems, where+": empty condition")
+			continue
+		}
+		if condition.Name != "" {
+			where += " (" + condition.Name + ")"
+		}
+		for _, problem := range condition.compile() {
+			problems = append(problems, where+": "+problem)
+		}
+		if names[condition.Name] {
+			problems = append(problems, fmt.Sprintf("%s: duplicate condition name %q", where, condition.Name))
+		}
+		names[condition.Name] = true
+	}
+	set.Conditions = f.Conditions
+
+	if len(problems) > 0 {
+		return nil, fmt.Errorf("invalid policy file:\n  %s", strings.Join(problems, "\n  "))
+	}
+	return set, nil
+}
+
+// resolve builds a policy from the spec, taking unset fields from base.
+func (s *PolicySpec) resolve(defaultID string, base *types.UserPolicy) *types.UserPolicy {
+	policy := &types.UserPolicy{
+		PolicyID:           s.PolicyID,
+		AccessLevel:        s.AccessLevel,
+		AllowedProviders:   s.AllowedProviders,
+		DailyRequestLimit:  s.DailyRequestLimit,
+		HourlyRequestLimit: s.HourlyRequestLimit,
+		RequiredRules:      s.RequiredRules,
+		RuleProfile:        s.RuleProfile,
+		Roles:              s.Roles,
+		Enabled:            true,
+		AllowedModels:      s.AllowedModels,
+		DeniedModels:       s.DeniedModels,
+
+		TokenBudget:           s.TokenBudget,
+		DepartmentTokenBudget: s.DepartmentTokenBudget,
+	}
+
+	if policy.PolicyID == "" {
+		policy.PolicyID = defaultID
+	}
+	if policy.AccessLevel == "" {
+		policy.AccessLevel = base.AccessLevel
+	}
+	if policy.AllowedProviders == nil {
+		policy.AllowedProviders = base.AllowedProviders
+	}
+	if policy.DailyRequestLimit == 0 {
+		policy.DailyRequestLimit = base.DailyRequestLimit
+	}
+	if policy.HourlyRequestLimit == 0 {
+		policy.HourlyRequestLimit = base.HourlyRequestLimit
+	}
+	if policy.RequiredRules == nil {
+		policy.RequiredRules = base.RequiredRules
+	}
+	if policy.RuleProfile == "" {
+		policy.RuleProfile = base.RuleProfile
+	}
+	if policy.AllowedModels == nil {
+		policy.AllowedModels = base.AllowedModels
+	}
+	if policy.DeniedModels == nil {
+		policy.DeniedModels = base.DeniedModels
+	}
+	if policy.TokenBudget == nil {
+		policy.TokenBudget = base.TokenBudget
+	}
+	if s.Enabled != nil {
+		policy.Enabled = *s.Enabled
+	}
+
+	return policy
+}
+
+// checkTokenBudget validates a token budget, returning every problem found.
+func checkTokenBudget(budget *types.TokenBudget) []string {
+	if budget == nil {
+		return nil
+	}
+	var problems []string
+	if budget.DailyWarning < 0 || budget.DailyLimit < 0 || budget.MonthlyWarning < 0 || budget.MonthlyLimit < 0 {
+		problems = append(problems, "thresholds must not be negative")
+	}
+	if budget.DailyLimit > 0 && budget.DailyWarning >= budget.DailyLimit {
+		problems = append(problems, "dailyWarning must be below dailyLimit")
+	}
+	if budget.MonthlyLimit > 0 && budget.MonthlyWarning >= budget.MonthlyLimit {
+		problems = append(problems, "monthlyWarning must be below monthlyLimit")
+	}
+	return problems
+}
+
+// validAccessLevel reports whether level is a known access level.
+func validAccessLevel(level types.AccessLevel) bool {
+	switch level {
+	case types.AccessUnrestricted, types.AccessSanitizedOnly, types.AccessBlocked:
+		return true
+	}
+	return false
+}
+
+// sortedKeys returns map keys in sorted order for deterministic validation output.
+func sortedKeys(m map[string]*PolicySpec) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,238 @@
+package policy
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/enterprise/opencode-enterprise-shield/pkg/types"
+)
+
+const testPolicyFile = `
+providers: [ollama]
+default:
+  accessLevel: sanitized_only
+  allowedProviders: [openai, anthropic]
+  dailyRequestLimit: 100
+departments:
+  contractors:
+    accessLevel: blocked
+  engineering:
+    allowedProviders: [openai, ollama]
+users:
+  alice@test.com:
+    policyId: alice
+    accessLevel: unrestricted
+`
+
+func TestParseFile_ResolvesAgainstDefault(t *testing.T) {
+	set, err := ParseFile([]byte(testPolicyFile), nil)
+	if err != nil {
+		t.Fatalf("ParseFile failed: %v", err)
+	}
+
+	if set.Default.DailyRequestLimit != 100 {
+		t.Errorf("Expected default daily limit 100, got %d", set.Default.DailyRequestLimit)
+	}
+	if set.Default.HourlyRequestLimit != DefaultPolicy().HourlyRequestLimit {
+		t.Errorf("Expected unset hourly limit to come from the built-in default")
+	}
+
+	eng := set.Departments["engineering"]
+	if eng.AccessLevel != types.AccessSanitizedOnly || eng.DailyRequestLimit != 100 {
+		t.Errorf("Expected engineering to inherit from default, got %+v", eng)
+	}
+	if eng.PolicyID != "department:engineering" {
+		t.Errorf("Expected generated policy ID, got %s", eng.PolicyID)
+	}
+
+	alice := set.Users["alice@test.com"]
+	if alice.PolicyID != "alice" || alice.AccessLevel != types.AccessUnrestricted || alice.UserID != "alice@test.com" {
+		t.Errorf("Unexpected user policy %+v", alice)
+	}
+}
+
+func TestParseFile_ValidationErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{"unknown access level", "users:\n  bob:\n    accessLevel: sometimes\n", `unknown access level "sometimes"`},
+		{"unknown provider", "departments:\n  eng:\n    allowedProviders: [mystery]\n", `unknown provider "mystery"`},
+		{"duplicate policy ID", "users:\n  a:\n    policyId: p1\n  b:\n    policyId: p1\n", `duplicate policyId "p1"`},
+		{"duplicate user", "users:\n  a: {}\n  a: {}\n", `already defined`},
+		{"unknown field", "default:\n  accesslevel: blocked\n", `not found`},
+		{"budget warning above limit", "default:\n  tokenBudget: {dailyWarning: 10, dailyLimit: 5}\n", `dailyWarning must be below dailyLimit`},
+		{"department budget on user", "users:\n  a:\n    departmentTokenBudget: {dailyLimit: 5}\n", `departmentTokenBudget only applies to departments`},
+		{"department on department", "departments:\n  eng:\n    department: finance\n", `department only applies to users`},
+	}
+
+	for _, test := range tests {
+		_, err := ParseFile([]byte(test.content), nil)
+		if err == nil || !strings.Contains(err.Error(), test.want) {
+			t.Errorf("%s: expected error containing %q, got %v", test.name, test.want, err)
+		}
+	}
+}
+
+func TestEngine_LoadAndReloadFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "policy.yaml")
+	if err := os.WriteFile(path, []byte(testPolicyFile), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	engine := NewEngine()
+	if err := engine.LoadFile(path); err != nil {
+		t.Fatalf("LoadFile failed: %v", err)
+	}
+
+	decision := engine.Evaluate(PolicyContext{UserID: "carol", Department: "contractors"})
+	if decision.Action != types.ActionBlock {
+		t.Errorf("Expected contractors to be blocked, got %s", decision.Action)
+	}
+
+	// An invalid edit keeps the current policies
+	writeLater(t, path, "users:\n  carol:\n    accessLevel: sometimes\n")
+	if _, err := engine.Reload(); err == nil {
+		t.Error("Expected invalid policy file to fail reload")
+	}
+	if engine.Evaluate(PolicyContext{UserID: "carol", Department: "contractors"}).Action != types.ActionBlock {
+		t.Error("Expected previous policies to stay in effect")
+	}
+
+	// A valid edit is applied
+	writeLater(t, path, "departments:\n  contractors:\n    accessLevel: unrestricted\n")
+	reloaded, err := engine.Reload()
+	if err != nil || !reloaded {
+		t.Fatalf("Expected reload to succeed, got %v, %v", reloaded, err)
+	}
+	if engine.Evaluate(PolicyContext{UserID: "carol", Department: "contractors"}).Action != types.ActionAllow {
+		t.Error("Expected reloaded policy to allow contractors")
+	}
+}
+
+func TestEngine_ReloadKeepsRuntimePolicies(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "policy.yaml")
+	if err := os.WriteFile(path, []byte("users:\n  carol:\n    accessLevel: blocked\n  dave:\n    accessLevel: blocked\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	engine := NewEngine()
+	if err := engine.LoadFile(path); err != nil {
+		t.Fatalf("LoadFile failed: %v", err)
+	}
+	engine.SetUserPolicy("carol", &types.UserPolicy{AccessLevel: types.AccessUnrestricted, Enabled: true})
+	engine.SetUserPolicy("erin", &types.UserPolicy{AccessLevel: types.AccessUnrestricted, Enabled: true})
+
+	writeLater(t, path, "users:\n  carol:\n    accessLevel: blocked\n  dave:\n    accessLevel: unrestricted\n")
+	if reloaded, err := engine.Reload(); err != nil || !reloaded {
+		t.Fatalf("Expected reload to succeed, got %v, %v", reloaded, err)
+	}
+
+	// Runtime policies survive the reload and win over the file
+	for _, user := range []string{"carol", "erin", "dave"} {
+		if action := engine.Evaluate(PolicyContext{UserID: user}).Action; action != types.ActionAllow {
+			t.Errorf("Expected %s to be allowed after reload, got %s", user, action)
+		}
+	}
+
+	// Deleting a runtime policy lets the file's apply again
+	engine.DeleteUserPolicy("carol")
+	if err := engine.LoadFile(path); err != nil {
+		t.Fatalf("LoadFile failed: %v", err)
+	}
+	if action := engine.Evaluate(PolicyContext{UserID: "carol"}).Action; action != types.ActionBlock {
+		t.Errorf("Expected the file's policy for carol, got %s", action)
+	}
+}
+
+func TestEngine_DefaultAccessLevel(t *testing.T) {
+	engine := NewEngine()
+	if err := engine.SetDefaultAccessLevel(types.AccessBlocked); err != nil {
+		t.Fatal(err)
+	}
+	if engine.Evaluate(PolicyContext{UserID: "anyone"}).Action != types.ActionBlock {
+		t.Error("Expected configured default access level to apply")
+	}
+	if err := engine.SetDefaultAccessLevel("sometimes"); err == nil {
+		t.Error("Expected unknown access level to be rejected")
+	}
+}
+
+// writeLater writes a file with a modification time strictly after its
+// previous one, so reloads detect the change on coarse-grained filesystems.
+func writeLater(t *testing.T, path, content string) {
+	t.Helper()
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	later := info.ModTime().Add(time.Second)
+	if err := os.Chtimes(path, later, later); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestEngine_TokenBudgets(t *testing.T) {
+	engine := loadEngine(t, `
+default:
+  tokenBudget: {dailyLimit: 1000, monthlyLimit: 20000}
+departments:
+  engineering:
+    tokenBudget: {dailyWarning: 1500, dailyLimit: 2000}
+    departmentTokenBudget: {monthlyLimit: 500000}
+roles:
+  trial:
+    tokenBudget: {dailyWarning: 600, dailyLimit: 900}
+users:
+  alice:
+    tokenBudget: {dailyLimit: 800}
+    roles: [trial]
+`)
+
+	// The user's own budget replaces the department's; roles merge lowest
+	decision := engine.Evaluate(PolicyContext{UserID: "alice", Department: "engineering", Provider: "openai"})
+	want := types.TokenBudget{DailyWarning: 600, DailyLimit: 800}
+	if decision.TokenBudget == nil || *decision.TokenBudget != want {
+		t.Errorf("Expected budget %+v, got %+v", want, decision.TokenBudget)
+	}
+	if decision.DepartmentTokenBudget == nil || decision.DepartmentTokenBudget.MonthlyLimit != 500000 {
+		t.Errorf("Expected department budget, got %+v", decision.DepartmentTokenBudget)
+	}
+
+	decision = engine.Evaluate(PolicyContext{UserID: "bob", Department: "engineering", Provider: "openai"})
+	want = types.TokenBudget{DailyWarning: 1500, DailyLimit: 2000}
+	if decision.TokenBudget == nil || *decision.TokenBudget != want {
+		t.Errorf("Expected department budget %+v, got %+v", want, decision.TokenBudget)
+	}
+
+	decision = engine.Evaluate(PolicyContext{UserID: "carol", Provider: "openai"})
+	want = types.TokenBudget{DailyLimit: 1000, MonthlyLimit: 20000}
+	if decision.TokenBudget == nil || *decision.TokenBudget != want {
+		t.Errorf("Expected default budget %+v, got %+v", want, decision.TokenBudget)
+	}
+	if decision.DepartmentTokenBudget != nil {
+		t.Errorf("Expected no department budget without a department, got %+v", decision.DepartmentTokenBudget)
+	}
+}
+
+func TestEngine_UserDepartment(t *testing.T) {
+	set, err := ParseFile([]byte("users:\n  alice:\n    department: engineering\n  bob: {}\n"), nil)
+	if err != nil {
+		t.Fatalf("ParseFile failed: %v", err)
+	}
+	engine := NewEngine()
+	engine.Load(set)
+
+	for user, want := range map[string]string{"alice": "engineering", "bob": "", "unknown": ""} {
+		if got := engine.UserDepartment(user); got != want {
+			t.Errorf("UserDepartment(%q) = %q, want %q", user, got, want)
+		}
+	}
+}
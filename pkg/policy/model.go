@@ -0,0 +1,62 @@
+// Package policy provides provider and model allowlist matching.
+package policy
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// modelName returns the "provider/model" name that model globs match. A
+// request that does not name its model matches as "provider/", so only
+// globs such as "openai/*" allow it.
+func modelName(provider, model string) string {
+	return provider + "/" + model
+}
+
+// matchesModel reports whether a "provider/model" name matches any glob.
+// Globs use path.Match syntax and were validated when loaded, so a malformed
+// glob never matches.
+func matchesModel(globs []string, name string) bool {
+	for _, glob := range globs {
+		if matched, err := path.Match(glob, name); err == nil && matched {
+			return true
+		}
+	}
+	return false
+}
+
+// checkModelGlob validates a model glob, returning a problem or "".
+func checkModelGlob(glob string) string {
+	provider, model, ok := strings.Cut(glob, "/")
+	if !ok || provider == "" || model == "" {
+		return fmt.Sprintf("model pattern %q must be provider/model, e.g. openai/gpt-4o*", glob)
+	}
+	if _, err := path.Match(glob, ""); err != nil {
+		return fmt.Sprintf("invalid model pattern %q: %v", glob, err)
+	}
+	return ""
+}
+
+// modelDenial returns why the effective policy refuses the request's
+// provider or model, or "" if both are allowed. A request that does not name
+// its provider is not checked against the provider allowlist.
+func (p *effectivePolicy) modelDenial(ctx PolicyContext) string {
+	if p.restrictProviders && ctx.Provider != "" && !contains(p.AllowedProviders, ctx.Provider) {
+		return "Provider not in allowed list"
+	}
+
+	name := modelName(ctx.Provider, ctx.Model)
+	if matchesModel(p.DeniedModels, name) {
+		return fmt.Sprintf("Model %s is denied by policy", name)
+	}
+	for _, allowed := range p.modelAllowlists {
+		if !matchesModel(allowed, name) {
+			if ctx.Model == "" {
+				return "Policy restricts models but the request did not name one"
+			}
+			return fmt.Sprintf("Model %s not in allowed list", name)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,133 @@
+package policy
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/enterprise/opencode-enterprise-shield/pkg/types"
+)
+
+const testModelsFile = `
+default:
+  accessLevel: sanitized_only
+  allowedModels: [openai/gpt-4o*, anthropic/*]
+  deniedModels: [openai/gpt-4o-audio*]
+users:
+  alice:
+    accessLevel: unrestricted
+    allowedProviders: [openai]
+  bob:
+    accessLevel: unrestricted
+    roles: [interns]
+roles:
+  interns:
+    allowedModels: [openai/gpt-4o-mini]
+`
+
+func TestEvaluate_ModelAllowlists(t *testing.T) {
+	set, err := ParseFile([]byte(testModelsFile), nil)
+	if err != nil {
+		t.Fatalf("ParseFile failed: %v", err)
+	}
+	engine := NewEngine()
+	engine.Load(set)
+
+	tests := []struct {
+		name   string
+		ctx    PolicyContext
+		action types.Action
+		reason string
+	}{
+		{
+			name:   "glob allows model",
+			ctx:    PolicyContext{UserID: "carol", Provider: "openai", Model: "gpt-4o-2024-08-06"},
+			action: types.ActionAllowWithSanitization,
+		},
+		{
+			name:   "model outside allowlist",
+			ctx:    PolicyContext{UserID: "carol", Provider: "openai", Model: "o1-preview"},
+			action: types.ActionBlock,
+			reason: "Model openai/o1-preview not in allowed list",
+		},
+		{
+			name:   "denylist wins over allowlist",
+			ctx:    PolicyContext{UserID: "carol", Provider: "openai", Model: "gpt-4o-audio-preview"},
+			action: types.ActionBlock,
+			reason: "Model openai/gpt-4o-audio-preview is denied by policy",
+		},
+		{
+			name:   "provider glob allows unnamed model",
+			ctx:    PolicyContext{UserID: "carol", Provider: "anthropic"},
+			action: types.ActionAllowWithSanitization,
+		},
+		{
+			name:   "unnamed model fails closed",
+			ctx:    PolicyContext{UserID: "carol", Provider: "openai"},
+			action: types.ActionBlock,
+			reason: "Policy restricts models but the request did not name one",
+		},
+		{
+			name:   "unrestricted user keeps model allowlist",
+			ctx:    PolicyContext{UserID: "alice", Provider: "openai", Model: "o1-preview"},
+			action: types.ActionBlock,
+			reason: "Model openai/o1-preview not in allowed list",
+		},
+		{
+			name:   "unrestricted user keeps provider allowlist",
+			ctx:    PolicyContext{UserID: "alice", Provider: "anthropic", Model: "claude-3-5-sonnet"},
+			action: types.ActionBlock,
+			reason: "Provider not in allowed list",
+		},
+		{
+			name:   "unrestricted user allowed model",
+			ctx:    PolicyContext{UserID: "alice", Provider: "openai", Model: "gpt-4o"},
+			action: types.ActionAllow,
+		},
+		{
+			name:   "role allowlist must also match",
+			ctx:    PolicyContext{UserID: "bob", Provider: "openai", Model: "gpt-4o"},
+			action: types.ActionBlock,
+			reason: "Model openai/gpt-4o not in allowed list",
+		},
+		{
+			name:   "model in every allowlist",
+			ctx:    PolicyContext{UserID: "bob", Provider: "openai", Model: "gpt-4o-mini"},
+			action: types.ActionAllow,
+		},
+	}
+
+	for _, test := range tests {
+		decision := engine.Evaluate(test.ctx)
+		if decision.Action != test.action {
+			t.Errorf("%s: expected %s, got %s (%s)", test.name, test.action, decision.Action, decision.Reason)
+		}
+		if test.reason != "" && decision.Reason != test.reason {
+			t.Errorf("%s: expected reason %q, got %q", test.name, test.reason, decision.Reason)
+		}
+	}
+}
+
+func TestParseFile_ModelGlobErrors(t *testing.T) {
+	_, err := ParseFile([]byte(`
+default:
+  allowedModels: [gpt-4o]
+users:
+  a:
+    deniedModels: ["openai/gpt-[4"]
+`), nil)
+	if err == nil {
+		t.Fatal("Expected invalid model globs to be rejected")
+	}
+	for _, want := range []string{
+		`default: model pattern "gpt-4o" must be provider/model`,
+		`users.a: invalid model pattern "openai/gpt-[4"`,
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Expected %q in %v", want, err)
+		}
+	}
+	// The inherited default glob is reported once, where it is defined
+	if strings.Count(err.Error(), `"gpt-4o"`) != 1 {
+		t.Errorf("Expected inherited glob to be reported once, got %v", err)
+	}
+}
@@ -0,0 +1,298 @@
+// Package policy provides break-glass overrides that temporarily elevate access.
+package policy
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/enterprise/opencode-enterprise-shield/pkg/crypto"
+	"github.com/enterprise/opencode-enterprise-shield/pkg/paths"
+	"github.com/enterprise/opencode-enterprise-shield/pkg/types"
+	"github.com/google/uuid"
+)
+
+// AnyScope as an override's scope grants it in every conversation and workspace.
+const AnyScope = "*"
+
+// MaxOverrideTTL bounds how long a break-glass override can stay valid.
+const MaxOverrideTTL = 24 * time.Hour
+
+// overrideTokenPrefix versions the token format and separates override
+// signatures from anything else signed with the same key.
+const overrideTokenPrefix = "bg1."
+
+var (
+	// ErrOverrideInvalid is returned for malformed tokens and bad signatures.
+	ErrOverrideInvalid = errors.New("override token is invalid or untrusted")
+	// ErrOverrideExpired is returned for overrides outside their validity window.
+	ErrOverrideExpired = errors.New("override has expired")
+	// ErrOverrideRevoked is returned for overrides revoked before expiry.
+	ErrOverrideRevoked = errors.New("override has been revoked")
+	// ErrOverrideMismatch is returned when an override is presented by
+	// another user or in another scope than it was issued for.
+	ErrOverrideMismatch = errors.New("override was issued for another user or scope")
+)
+
+// IssueOverride validates a break-glass grant, fills in its ID and validity
+// window, and signs it. The issuer is the fingerprint of the signing key,
+// whatever the grant says. It returns the completed grant and its token.
+func IssueOverride(grant types.Override, ttl time.Duration, key ed25519.PrivateKey) (*types.Override, string, error) {
+	var problems []string
+	if grant.UserID == "" {
+		problems = append(problems, "user is required")
+	}
+	if grant.Scope == "" {
+		problems = append(problems, `scope is required (use "*" for any scope)`)
+	}
+	if strings.TrimSpace(grant.Justification) == "" {
+		problems = append(problems, "justification is required")
+	}
+	if grant.AccessLevel == "" {
+		grant.AccessLevel = types.AccessUnrestricted
+	}
+	if grant.AccessLevel != types.AccessUnrestricted && grant.AccessLevel != types.AccessSanitizedOnly {
+		problems = append(problems, fmt.Sprintf("access level %q cannot be granted", grant.AccessLevel))
+	}
+	if ttl <= 0 || ttl > MaxOverrideTTL {
+		problems = append(problems, fmt.Sprintf("duration must be between 0 and %s", MaxOverrideTTL))
+	}
+	if len(problems) > 0 {
+		return nil, "", fmt.Errorf("invalid override: %s", strings.Join(problems, "; "))
+	}
+
+	grant.IssuedBy = crypto.KeyFingerprint(key.Public().(ed25519.PublicKey))
+	grant.ID = "bg_" + uuid.New().String()[:12]
+	grant.IssuedAt = time.Now().UTC().Truncate(time.Second)
+	grant.ExpiresAt = grant.IssuedAt.Add(ttl)
+
+	payload, err := json.Marshal(grant)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to marshal override: %w", err)
+	}
+	message := overrideTokenPrefix + base64.RawURLEncoding.EncodeToString(payload)
+	signature := ed25519.Sign(key, []byte(message))
+
+	return &grant, message + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+// ParseOverride verifies a token's signature against the trusted keys and
+// returns its grant, with the fingerprint of the key that signed it as the
+// issuer. It does not check expiry, revocation or the holder.
+func ParseOverride(token string, trusted ...ed25519.PublicKey) (*types.Override, error) {
+	cut := strings.LastIndexByte(token, '.')
+	if !strings.HasPrefix(token, overrideTokenPrefix) || cut < len(overrideTokenPrefix) {
+		return nil, ErrOverrideInvalid
+	}
+	message := token[:cut]
+	signature, err := base64.RawURLEncoding.DecodeString(token[cut+1:])
+	if err != nil {
+		return nil, ErrOverrideInvalid
+	}
+
+	var signer ed25519.PublicKey
+	for _, key := range trusted {
+		if len(key) == ed25519.PublicKeySize && ed25519.Verify(key, []byte(message), signature) {
+			signer = key
+			break
+		}
+	}
+	if signer == nil {
+		return nil, ErrOverrideInvalid
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(message[len(overrideTokenPrefix):])
+	if err != nil {
+		return nil, ErrOverrideInvalid
+	}
+	var grant types.Override
+	if err := json.Unmarshal(payload, &grant); err != nil {
+		return nil, ErrOverrideInvalid
+	}
+	grant.IssuedBy = crypto.KeyFingerprint(signer)
+	return &grant, nil
+}
+
+// SetOverrideKeys enables break-glass overrides signed by any of the keys.
+// With no keys, every override is refused. Overrides revoked in revocations
+// are refused too; revocations may be nil.
+func (e *Engine) SetOverrideKeys(keys []ed25519.PublicKey, revocations *RevocationList) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.overrideKeys = keys
+	e.revocations = revocations
+}
+
+// verifyOverride checks the override presented with a request.
+func (e *Engine) verifyOverride(ctx PolicyContext) (*types.Override, error) {
+	grant, err := ParseOverride(ctx.Override, e.overrideKeys...)
+	if err != nil {
+		return nil, err
+	}
+	if grant.UserID != ctx.UserID || (grant.Scope != AnyScope && grant.Scope != ctx.Scope) {
+		return nil, ErrOverrideMismatch
+	}
+
+	now := ctx.Time
+	if now.IsZero() {
+		now = time.Now()
+	}
+	if now.Before(grant.IssuedAt.Add(-time.Minute)) || !now.Before(grant.ExpiresAt) {
+		return nil, ErrOverrideExpired
+	}
+
+	if e.revocations != nil && e.revocations.IsRevoked(grant.ID) {
+		return nil, ErrOverrideRevoked
+	}
+	return grant, nil
+}
+
+// Revocation records an override revoked before it expired.
+type Revocation struct {
+	ID        string    `json:"id"`
+	RevokedBy string    `json:"revokedBy"`
+	Reason    string    `json:"reason,omitempty"`
+	RevokedAt time.Time `json:"revokedAt"`
+}
+
+// RevocationList is a file of revoked override IDs shared by every process
+// on the machine, so an override revoked from the CLI stops working in a
+// running shield. The file is re-read whenever it changes.
+type RevocationList struct {
+	path    string
+	mu      sync.Mutex
+	modTime time.Time
+	revoked map[string]Revocation
+	err     error
+}
+
+// NewRevocationList opens the revocation list at path. The file need not exist.
+func NewRevocationList(path string) (*RevocationList, error) {
+	path, err := paths.ExpandHome(path)
+	if err != nil {
+		return nil, err
+	}
+	return &RevocationList{path: path, revoked: make(map[string]Revocation)}, nil
+}
+
+// IsRevoked reports whether an override has been revoked. If the list
+// cannot be read, every override counts as revoked.
+func (r *RevocationList) IsRevoked(id string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.refresh(); err != nil {
+		return true
+	}
+	_, ok := r.revoked[id]
+	return ok
+}
+
+// Revoke adds an override to the list. The list's lock file is held while
+// it is re-read and saved, so concurrent revocations are never lost.
+func (r *RevocationList) Revoke(revocation Revocation) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	unlock, err := paths.Lock(r.path + ".lock")
+	if err != nil {
+		return fmt.Errorf("failed to lock revocation list: %w", err)
+	}
+	defer unlock()
+
+	// Two quick writes may leave the same modification time
+	r.modTime = time.Time{}
+	if err := r.refresh(); err != nil {
+		return err
+	}
+	if revocation.RevokedAt.IsZero() {
+		revocation.RevokedAt = time.Now().UTC()
+	}
+	r.revoked[revocation.ID] = revocation
+	return r.save()
+}
+
+// List returns the revocations sorted by ID.
+func (r *RevocationList) List() ([]Revocation, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.refresh(); err != nil {
+		return nil, err
+	}
+	list := make([]Revocation, 0, len(r.revoked))
+	for _, revocation := range r.revoked {
+		list = append(list, revocation)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].ID < list[j].ID })
+	return list, nil
+}
+
+// refresh re-reads the file if it changed since it was last read.
+func (r *RevocationList) refresh() error {
+	info, err := os.Stat(r.path)
+	if errors.Is(err, os.ErrNotExist) {
+		r.revoked = make(map[string]Revocation)
+		r.modTime = time.Time{}
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read revocation list: %w", err)
+	}
+	if info.ModTime().Equal(r.modTime) && r.err == nil {
+		return nil
+	}
+
+	r.err = r.load()
+	if r.err == nil {
+		r.modTime = info.ModTime()
+	}
+	return r.err
+}
+
+// load reads the file into memory.
+func (r *RevocationList) load() error {
+	data, err := os.ReadFile(r.path)
+	if err != nil {
+		return fmt.Errorf("failed to read revocation list: %w", err)
+	}
+	var list []Revocation
+	if err := json.Unmarshal(data, &list); err != nil {
+		return fmt.Errorf("failed to parse revocation list: %w", err)
+	}
+	r.revoked = make(map[string]Revocation, len(list))
+	for _, revocation := range list {
+		r.revoked[revocation.ID] = revocation
+	}
+	return nil
+}
+
+// save writes the list atomically so readers never see a partial file.
+func (r *RevocationList) save() error {
+	list := make([]Revocation, 0, len(r.revoked))
+	for _, revocation := range r.revoked {
+		list = append(list, revocation)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].ID < list[j].ID })
+	data, err := json.MarshalIndent(list, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal revocation list: %w", err)
+	}
+
+	if err := paths.WriteFileAtomic(r.path, data); err != nil {
+		return fmt.Errorf("failed to save revocation list: %w", err)
+	}
+
+	// Our own write need not be re-read
+	if info, err := os.Stat(r.path); err == nil {
+		r.modTime = info.ModTime()
+	}
+	return nil
+}
@@ -0,0 +1,210 @@
+package policy
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/enterprise/opencode-enterprise-shield/pkg/crypto"
+	"github.com/enterprise/opencode-enterprise-shield/pkg/types"
+)
+
+func newOverrideEngine(t *testing.T) (*Engine, ed25519.PrivateKey, *RevocationList) {
+	t.Helper()
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey failed: %v", err)
+	}
+	revocations, err := NewRevocationList(filepath.Join(t.TempDir(), "revoked.json"))
+	if err != nil {
+		t.Fatalf("NewRevocationList failed: %v", err)
+	}
+
+	set, err := ParseFile([]byte(`
+default:
+  accessLevel: sanitized_only
+  allowedProviders: [openai, anthropic]
+departments:
+  contractors:
+    accessLevel: blocked
+conditions:
+  - name: payroll
+    keywords: [payroll]
+    action: block
+`), nil)
+	if err != nil {
+		t.Fatalf("ParseFile failed: %v", err)
+	}
+	engine := NewEngine()
+	engine.Load(set)
+	engine.SetOverrideKeys([]ed25519.PublicKey{public}, revocations)
+	return engine, private, revocations
+}
+
+func issue(t *testing.T, key ed25519.PrivateKey, grant types.Override) (*types.Override, string) {
+	t.Helper()
+	grant.Justification = "debugging on-prem model"
+	grant.IssuedBy = "security-lead"
+	issued, token, err := IssueOverride(grant, time.Hour, key)
+	if err != nil {
+		t.Fatalf("IssueOverride failed: %v", err)
+	}
+	return issued, token
+}
+
+func TestEvaluate_Override(t *testing.T) {
+	engine, key, revocations := newOverrideEngine(t)
+	issued, token := issue(t, key, types.Override{UserID: "alice", Scope: "repo"})
+
+	decision := engine.Evaluate(PolicyContext{UserID: "alice", Scope: "repo", Provider: "openai", Content: "invoices", Override: token})
+	if decision.Action != types.ActionAllow {
+		t.Fatalf("Expected override to elevate access, got %s (%s)", decision.Action, decision.Reason)
+	}
+	if decision.Override == nil || decision.Override.ID != issued.ID {
+		t.Errorf("Expected decision to carry the override, got %+v", decision.Override)
+	}
+	if decision.Reason != "Break-glass override: debugging on-prem model" {
+		t.Errorf("Unexpected reason %q", decision.Reason)
+	}
+	if got := decision.PolicyApplied[len(decision.PolicyApplied)-1]; got != "override:"+issued.ID {
+		t.Errorf("Expected override in PolicyApplied, got %v", decision.PolicyApplied)
+	}
+
+	// Block conditions still apply
+	decision = engine.Evaluate(PolicyContext{UserID: "alice", Scope: "repo", Provider: "openai", Content: "payroll", Override: token})
+	if decision.Action != types.ActionBlock || decision.Override == nil {
+		t.Errorf("Expected the payroll condition to block under override, got %s (%s)", decision.Action, decision.Reason)
+	}
+	if got := decision.PolicyApplied[len(decision.PolicyApplied)-1]; got != "condition:payroll" {
+		t.Errorf("Expected the condition in PolicyApplied, got %v", decision.PolicyApplied)
+	}
+
+	// Allowlists still apply
+	decision = engine.Evaluate(PolicyContext{UserID: "alice", Scope: "repo", Provider: "google", Override: token})
+	if decision.Action != types.ActionBlock {
+		t.Errorf("Expected provider allowlist to hold under override, got %s", decision.Action)
+	}
+
+	// Blocked departments are elevated too
+	_, contractorToken := issue(t, key, types.Override{UserID: "bob", Scope: AnyScope, AccessLevel: types.AccessSanitizedOnly})
+	decision = engine.Evaluate(PolicyContext{UserID: "bob", Department: "contractors", Scope: "anything", Override: contractorToken})
+	if decision.Action != types.ActionAllowWithSanitization {
+		t.Errorf("Expected sanitized access for blocked user, got %s", decision.Action)
+	}
+
+	if err := revocations.Revoke(Revocation{ID: issued.ID, RevokedBy: "security-lead"}); err != nil {
+		t.Fatalf("Revoke failed: %v", err)
+	}
+	decision = engine.Evaluate(PolicyContext{UserID: "alice", Scope: "repo", Provider: "openai", Override: token})
+	if decision.Action != types.ActionAllowWithSanitization || decision.OverrideError != ErrOverrideRevoked.Error() {
+		t.Errorf("Expected revoked override to be ignored, got %s (%s)", decision.Action, decision.OverrideError)
+	}
+	if len(decision.Warnings) != 1 || decision.Warnings[0].Rule != "override" {
+		t.Errorf("Expected an override warning, got %+v", decision.Warnings)
+	}
+}
+
+func TestEvaluate_OverrideRejected(t *testing.T) {
+	engine, key, _ := newOverrideEngine(t)
+	issued, token := issue(t, key, types.Override{UserID: "alice", Scope: "repo"})
+	_, otherKey, _ := ed25519.GenerateKey(rand.Reader)
+	_, forged := issue(t, otherKey, types.Override{UserID: "alice", Scope: "repo"})
+
+	tests := []struct {
+		name string
+		ctx  PolicyContext
+		err  error
+	}{
+		{"other user", PolicyContext{UserID: "mallory", Scope: "repo", Override: token}, ErrOverrideMismatch},
+		{"other scope", PolicyContext{UserID: "alice", Scope: "other", Override: token}, ErrOverrideMismatch},
+		{"expired", PolicyContext{UserID: "alice", Scope: "repo", Override: token, Time: issued.ExpiresAt}, ErrOverrideExpired},
+		{"untrusted key", PolicyContext{UserID: "alice", Scope: "repo", Override: forged}, ErrOverrideInvalid},
+		{"tampered", PolicyContext{UserID: "alice", Scope: "repo", Override: strings.Replace(token, "bg1.e", "bg1.f", 1)}, ErrOverrideInvalid},
+		{"garbage", PolicyContext{UserID: "alice", Scope: "repo", Override: "not-a-token"}, ErrOverrideInvalid},
+	}
+
+	for _, test := range tests {
+		decision := engine.Evaluate(test.ctx)
+		if decision.Override != nil || decision.Action == types.ActionAllow {
+			t.Errorf("%s: expected override to be refused, got %s", test.name, decision.Action)
+		}
+		if decision.OverrideError != test.err.Error() {
+			t.Errorf("%s: expected %v, got %q", test.name, test.err, decision.OverrideError)
+		}
+	}
+}
+
+func TestIssueOverride_Validation(t *testing.T) {
+	_, key, _ := ed25519.GenerateKey(rand.Reader)
+
+	_, _, err := IssueOverride(types.Override{AccessLevel: types.AccessBlocked}, 48*time.Hour, key)
+	if err == nil {
+		t.Fatal("Expected invalid override to be rejected")
+	}
+	for _, want := range []string{"user is required", "scope is required", "justification is required", `access level "blocked"`, "duration"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Expected %q in %v", want, err)
+		}
+	}
+
+	issued, token, err := IssueOverride(types.Override{UserID: "a", Scope: "*", Justification: "j", IssuedBy: "b"}, time.Hour, key)
+	if err != nil {
+		t.Fatalf("IssueOverride failed: %v", err)
+	}
+	parsed, err := ParseOverride(token, key.Public().(ed25519.PublicKey))
+	if err != nil || *parsed != *issued {
+		t.Errorf("Expected token to round-trip, got %+v, %v", parsed, err)
+	}
+
+	// The issuer is the signing key, not whoever the grant claims
+	if want := crypto.KeyFingerprint(key.Public().(ed25519.PublicKey)); issued.IssuedBy != want || parsed.IssuedBy != want {
+		t.Errorf("Expected issuer %s, got %q and %q", want, issued.IssuedBy, parsed.IssuedBy)
+	}
+	if _, err := ParseOverride(token); !errors.Is(err, ErrOverrideInvalid) {
+		t.Errorf("Expected no trusted keys to refuse the token, got %v", err)
+	}
+}
+
+func TestRevocationList_ConcurrentRevokes(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "revoked.json")
+	// Two lists on one file stand in for two processes
+	lists := make([]*RevocationList, 2)
+	for i := range lists {
+		list, err := NewRevocationList(path)
+		if err != nil {
+			t.Fatalf("NewRevocationList failed: %v", err)
+		}
+		lists[i] = list
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if err := lists[i%2].Revoke(Revocation{ID: fmt.Sprintf("ovr_%02d", i)}); err != nil {
+				t.Errorf("Revoke failed: %v", err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	list, err := lists[0].List()
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if len(list) != 20 {
+		t.Errorf("Expected all 20 revocations to be kept, got %d", len(list))
+	}
+	for i := 0; i < 20; i++ {
+		if !lists[1].IsRevoked(fmt.Sprintf("ovr_%02d", i)) {
+			t.Errorf("Expected ovr_%02d to be revoked", i)
+		}
+	}
+}
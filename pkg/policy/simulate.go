@@ -0,0 +1,150 @@
+// Package policy provides replay of audited requests through candidate policies.
+package policy
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/enterprise/opencode-enterprise-shield/pkg/types"
+)
+
+// SimulationReport summarizes how a candidate policy set would have decided
+// audited requests differently from the baseline.
+type SimulationReport struct {
+	Replayed     int               `json:"replayed"`
+	Skipped      int               `json:"skipped"` // Entries that are not requests
+	Unchanged    int               `json:"unchanged"`
+	AllowToBlock int               `json:"allowToBlock"`
+	BlockToAllow int               `json:"blockToAllow"`
+	Users        []SimulationCount `json:"users,omitempty"`
+	Rules        []SimulationCount `json:"rules,omitempty"`
+	Caveats      []string          `json:"caveats,omitempty"`
+}
+
+// SimulationCount counts changed decisions for one user or rule.
+type SimulationCount struct {
+	Name         string `json:"name"`
+	AllowToBlock int    `json:"allowToBlock"`
+	BlockToAllow int    `json:"blockToAllow"`
+}
+
+// Simulate replays audited requests through the baseline and candidate
+// engines and counts decisions that change between allowed and blocked.
+// A request newly blocked is attributed to the rule that blocks it under the
+// candidate; a request newly allowed to the rule that blocked it under the
+// baseline.
+//
+// The audit log records who made a request, to which provider and model,
+// when, and what was found in it, but not the content, source IP or roles
+// asserted at request time. Conditions that depend on those are evaluated
+// as if they were empty and listed in the report's caveats.
+func Simulate(baseline, candidate *Engine, entries []types.AuditEntry) *SimulationReport {
+	report := &SimulationReport{}
+	users := make(map[string]*SimulationCount)
+	rules := make(map[string]*SimulationCount)
+	count := func(counts map[string]*SimulationCount, name string) *SimulationCount {
+		if counts[name] == nil {
+			counts[name] = &SimulationCount{Name: name}
+		}
+		return counts[name]
+	}
+
+	for _, entry := range entries {
+		switch entry.Event {
+		case "", types.AuditEventRequest, types.AuditEventOverrideUsed, types.AuditEventOverrideRejected:
+		default:
+			report.Skipped++
+			continue
+		}
+		report.Replayed++
+
+		ctx := PolicyContext{
+			UserID:     entry.UserID,
+			Department: entry.Department,
+			Provider:   entry.Provider,
+			Model:      entry.Model,
+			Violations: entry.Violations,
+			Time:       entry.Timestamp,
+		}
+		before := baseline.Evaluate(ctx)
+		after := candidate.Evaluate(ctx)
+
+		wasBlocked := before.Action == types.ActionBlock
+		isBlocked := after.Action == types.ActionBlock
+		switch {
+		case !wasBlocked && isBlocked:
+			report.AllowToBlock++
+			count(users, entry.UserID).AllowToBlock++
+			count(rules, decidingRule(after)).AllowToBlock++
+		case wasBlocked && !isBlocked:
+			report.BlockToAllow++
+			count(users, entry.UserID).BlockToAllow++
+			count(rules, decidingRule(before)).BlockToAllow++
+		default:
+			report.Unchanged++
+		}
+	}
+
+	report.Users = sortCounts(users)
+	report.Rules = sortCounts(rules)
+	report.Caveats = append(simulationCaveats("baseline", baseline), simulationCaveats("candidate", candidate)...)
+	return report
+}
+
+// decidingRule names what decided a blocking decision: the condition that
+// matched, or the contributing policies and the reason.
+func decidingRule(decision types.PolicyDecision) string {
+	// The deciding condition is listed last
+	for i := len(decision.PolicyApplied) - 1; i >= 0; i-- {
+		if id := decision.PolicyApplied[i]; strings.HasPrefix(id, "condition:") {
+			return id
+		}
+	}
+	return fmt.Sprintf("%s: %s", strings.Join(decision.PolicyApplied, ", "), decision.Reason)
+}
+
+// sortCounts orders counts by number of changes, most first, then by name.
+func sortCounts(counts map[string]*SimulationCount) []SimulationCount {
+	list := make([]SimulationCount, 0, len(counts))
+	for _, c := range counts {
+		list = append(list, *c)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		a, b := list[i].AllowToBlock+list[i].BlockToAllow, list[j].AllowToBlock+list[j].BlockToAllow
+		if a != b {
+			return a > b
+		}
+		return list[i].Name < list[j].Name
+	})
+	return list
+}
+
+// simulationCaveats lists an engine's conditions that depend on request
+// data the audit log does not record.
+func simulationCaveats(name string, engine *Engine) []string {
+	engine.mu.RLock()
+	defer engine.mu.RUnlock()
+
+	var caveats []string
+	for _, condition := range engine.conditions {
+		var missing []string
+		if condition.usesContent() {
+			missing = append(missing, "content")
+		}
+		if len(condition.SourceCIDRs) > 0 || len(condition.OutsideCIDRs) > 0 {
+			missing = append(missing, "source IP")
+		}
+		if len(missing) > 0 {
+			caveats = append(caveats, fmt.Sprintf("%s condition %s depends on request %s, which the audit log does not record",
+				name, condition.Name, strings.Join(missing, " and ")))
+		}
+	}
+	return caveats
+}
+
+// usesContent reports whether the condition looks at request content.
+func (c *Condition) usesContent() bool {
+	return len(c.Keywords) > 0 || len(c.Patterns) > 0 || c.MinLength > 0 || c.MaxLength > 0 ||
+		(c.when != nil && c.when.References("content_length"))
+}
@@ -0,0 +1,87 @@
+package policy
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/enterprise/opencode-enterprise-shield/pkg/types"
+)
+
+func loadEngine(t *testing.T, file string) *Engine {
+	t.Helper()
+	set, err := ParseFile([]byte(file), nil)
+	if err != nil {
+		t.Fatalf("ParseFile failed: %v", err)
+	}
+	engine := NewEngine()
+	engine.Load(set)
+	return engine
+}
+
+func TestSimulate(t *testing.T) {
+	baseline := loadEngine(t, `
+default:
+  accessLevel: sanitized_only
+departments:
+  contractors:
+    accessLevel: blocked
+`)
+	candidate := loadEngine(t, `
+default:
+  accessLevel: sanitized_only
+  allowedProviders: [openai, anthropic]
+departments:
+  contractors:
+    accessLevel: sanitized_only
+conditions:
+  - name: secrets
+    violations: [API_KEY]
+    action: block
+  - name: payroll
+    keywords: [payroll]
+    action: block
+`)
+
+	at := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	secret := []types.Violation{{Type: "API_KEY", Severity: types.SeverityCritical}}
+	entries := []types.AuditEntry{
+		{UserID: "alice", Provider: "openai", Timestamp: at, Event: types.AuditEventRequest},
+		{UserID: "alice", Provider: "google", Timestamp: at, Event: types.AuditEventRequest},
+		{UserID: "bob", Provider: "openai", Violations: secret, Timestamp: at},
+		{UserID: "carol", Department: "contractors", Provider: "openai", Timestamp: at},
+		{UserID: "carol", Department: "contractors", Provider: "google", Timestamp: at},
+		{UserID: "carol", Event: types.AuditEventSessionExpired, Timestamp: at},
+	}
+
+	report := Simulate(baseline, candidate, entries)
+
+	if report.Replayed != 5 || report.Skipped != 1 {
+		t.Errorf("Expected 5 replayed and 1 skipped, got %d and %d", report.Replayed, report.Skipped)
+	}
+	if report.AllowToBlock != 2 || report.BlockToAllow != 1 || report.Unchanged != 2 {
+		t.Errorf("Unexpected totals %+v", report)
+	}
+
+	wantUsers := []SimulationCount{
+		{Name: "alice", AllowToBlock: 1},
+		{Name: "bob", AllowToBlock: 1},
+		{Name: "carol", BlockToAllow: 1},
+	}
+	if !reflect.DeepEqual(report.Users, wantUsers) {
+		t.Errorf("Expected users %+v, got %+v", wantUsers, report.Users)
+	}
+	wantRules := []SimulationCount{
+		{Name: "condition:secrets", AllowToBlock: 1},
+		{Name: "default: Provider not in allowed list", AllowToBlock: 1},
+		{Name: "department:contractors: User access is blocked", BlockToAllow: 1},
+	}
+	if !reflect.DeepEqual(report.Rules, wantRules) {
+		t.Errorf("Expected rules %+v, got %+v", wantRules, report.Rules)
+	}
+
+	wantCaveats := []string{"candidate condition payroll depends on request content, which the audit log does not record"}
+	if !reflect.DeepEqual(report.Caveats, wantCaveats) {
+		t.Errorf("Expected caveats %v, got %v", wantCaveats, report.Caveats)
+	}
+}
@@ -19,6 +19,11 @@ func NewAliasGenerator() *AliasGenerator {
 // Examples: SERVER_0, TABLE_0, IP_1
 func (g *AliasGenerator) Generate(session *types.Session, prefix string) string {
 	counter := session.GetNextCounter(prefix)
+	return g.Format(prefix, counter)
+}
+
+// Format builds an alias from a prefix and counter value.
+func (g *AliasGenerator) Format(prefix string, counter int) string {
 	return fmt.Sprintf("%s_%d", prefix, counter)
 }
 
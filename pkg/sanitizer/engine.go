@@ -8,6 +8,8 @@ import (
 	"sync"
 	"time"
 
+	"github.com/enterprise/opencode-enterprise-shield/pkg/decode"
+	"github.com/enterprise/opencode-enterprise-shield/pkg/normalize"
 	"github.com/enterprise/opencode-enterprise-shield/pkg/types"
 )
 
@@ -18,6 +20,8 @@ type Engine struct {
 	mu            sync.RWMutex
 	aliasGen      *AliasGenerator
 	regexTimeout  time.Duration
+	profiles      map[string][]string // profile name -> rule IDs
+	decodeLimits  decode.Limits
 }
 
 // NewEngine creates a new sanitization engine with the given rules.
@@ -27,13 +31,16 @@ func NewEngine(rules []types.SanitizationRule) *Engine {
 		compiledRules: make(map[string]*regexp.Regexp),
 		aliasGen:      NewAliasGenerator(),
 		regexTimeout:  50 * time.Millisecond,
+		profiles:      DefaultProfiles(),
+		decodeLimits:  decode.DefaultLimits(),
 	}
 	// Load rules, ignoring errors for default rules (they're pre-validated)
 	_ = e.LoadRules(rules)
 	return e
 }
 
-// LoadRules loads and compiles sanitization rules.
+// LoadRules loads and compiles sanitization rules. Rules that are disabled
+// are compiled too, so that a request can still require them.
 func (e *Engine) LoadRules(rules []types.SanitizationRule) error {
 	e.mu.Lock()
 	defer e.mu.Unlock()
@@ -42,10 +49,6 @@ func (e *Engine) LoadRules(rules []types.SanitizationRule) error {
 	e.compiledRules = make(map[string]*regexp.Regexp)
 
 	for _, rule := range rules {
-		if !rule.Enabled {
-			continue
-		}
-
 		compiled, err := regexp.Compile(rule.Pattern)
 		if err != nil {
 			return fmt.Errorf("failed to compile pattern for rule %s: %w", rule.Name, err)
@@ -63,8 +66,27 @@ func (e *Engine) LoadRules(rules []types.SanitizationRule) error {
 	return nil
 }
 
-// Sanitize processes content and replaces sensitive data with aliases.
+// Sanitize processes content and replaces sensitive data with aliases,
+// applying the rules enabled by default.
 func (e *Engine) Sanitize(content string, session *types.Session) types.SanitizationResult {
+	// An empty selection cannot fail
+	result, _ := e.SanitizeWithRules(content, session, RuleSelection{})
+	return result
+}
+
+// SanitizeWithRules sanitizes content with the rules enabled by default plus
+// those the selection requires. It fails closed: if a required rule or
+// profile is not defined, nothing is sanitized and an error is returned.
+//
+// Base64, URL and hex encoded segments are decoded within the decode limits
+// and sanitized too. A segment with matches is re-encoded the same way, and
+// its violations are reported at the position of the encoded text. In URL
+// segments only the changed bytes are re-encoded.
+//
+// Rules match the normalized shadow of the text (see package normalize), so
+// full-width characters, zero-width characters and look-alike letters do
+// not hide a value. The original text of each match is what is aliased.
+func (e *Engine) SanitizeWithRules(content string, session *types.Session, selection RuleSelection) (types.SanitizationResult, error) {
 	startTime := time.Now()
 
 	result := types.SanitizationResult{
@@ -75,42 +97,103 @@ func (e *Engine) Sanitize(content string, session *types.Session) types.Sanitiza
 	}
 
 	e.mu.RLock()
-	rules := e.rules
-	compiledRules := e.compiledRules
+	pass := sanitizePass{
+		engine:        e,
+		session:       session,
+		rules:         e.rules,
+		compiledRules: e.compiledRules,
+		result:        &result,
+	}
+	selected, err := e.resolveSelection(selection)
+	limits := e.decodeLimits
 	e.mu.RUnlock()
 
-	workingContent := content
+	if err != nil {
+		return result, err
+	}
+	pass.selected = selected
 
-	for _, rule := range rules {
-		compiled, ok := compiledRules[rule.RuleID]
+	result.SanitizedContent = pass.sanitize(content, decode.Find(content, limits))
+	result.Warnings = aliasWarnings(result.Violations)
+	result.ProcessingTimeMs = time.Since(startTime).Milliseconds()
+
+	return result, nil
+}
+
+// sanitizePass holds the state of one sanitization.
+type sanitizePass struct {
+	engine        *Engine
+	session       *types.Session
+	rules         []types.SanitizationRule
+	compiledRules map[string]*regexp.Regexp
+	selected      map[string]bool
+	result        *types.SanitizationResult
+}
+
+// sanitize applies the rules to the decoded text of the encoded segments in
+// text, then to text itself, and returns the sanitized text.
+func (p *sanitizePass) sanitize(text string, segments []decode.Segment) string {
+	// From the end so earlier positions stay valid
+	for i := len(segments) - 1; i >= 0; i-- {
+		segment := segments[i]
+		before := len(p.result.Violations)
+		decoded := p.sanitize(segment.Decoded, segment.Nested)
+		if len(p.result.Violations) == before {
+			continue
+		}
+
+		text = text[:segment.Start] + segment.Encode(decoded) + text[segment.End:]
+		for j := before; j < len(p.result.Violations); j++ {
+			v := &p.result.Violations[j]
+			start, end := segment.Span(v.Position, v.Position+v.Length)
+			v.Position, v.Length = start, end-start
+			v.Encoding = decode.JoinEncodings(segment.Encoding, v.Encoding)
+		}
+	}
+
+	return p.applyRules(text)
+}
+
+// applyRules replaces the matches of the enabled and selected rules in text
+// with aliases.
+func (p *sanitizePass) applyRules(workingContent string) string {
+	for _, rule := range p.rules {
+		if !rule.Enabled && !p.selected[rule.RuleID] {
+			continue
+		}
+
+		compiled, ok := p.compiledRules[rule.RuleID]
 		if !ok {
 			continue
 		}
 
-		// Find all matches
-		matches := compiled.FindAllStringIndex(workingContent, -1)
+		// Find all matches in the normalized shadow
+		shadow := normalize.New(workingContent)
+		matches := compiled.FindAllStringIndex(shadow.Text, -1)
 		if len(matches) == 0 {
 			continue
 		}
 
 		// Process matches in reverse order to preserve positions
 		for i := len(matches) - 1; i >= 0; i-- {
-			match := matches[i]
+			normalizedValue := shadow.Text[matches[i][0]:matches[i][1]]
+			start, end := shadow.Span(matches[i][0], matches[i][1])
+			match := []int{start, end}
 			matchedValue := workingContent[match[0]:match[1]]
 
 			// Check exceptions
-			if e.isException(matchedValue, rule.Exceptions) {
+			if p.engine.isException(normalizedValue, rule.Exceptions) {
 				continue
 			}
 
 			// Check if critical severity should block
 			if rule.Severity == types.SeverityCritical {
-				result.ShouldBlock = true
-				result.BlockReason = fmt.Sprintf("Critical violation detected: %s", rule.Name)
+				p.result.ShouldBlock = true
+				p.result.BlockReason = fmt.Sprintf("Critical violation detected: %s", rule.Name)
 			}
 
 			// Get or create alias
-			alias, isNew := e.getOrCreateAlias(session, matchedValue, rule.Prefix)
+			alias, isNew := p.engine.getOrCreateAlias(p.session, matchedValue, rule.Prefix)
 
 			// Replace in content
 			workingContent = workingContent[:match[0]] + alias + workingContent[match[1]:]
@@ -121,39 +204,72 @@ func (e *Engine) Sanitize(content string, session *types.Session) types.Sanitiza
 				RuleName:      rule.Name,
 				Type:          rule.Prefix,
 				Severity:      rule.Severity,
-				RedactedValue: redactValue(matchedValue),
+				RedactedValue: RedactValue(normalizedValue),
 				Position:      match[0],
 				Length:        len(matchedValue),
+				Normalized:    matchedValue != normalizedValue,
 			}
-			result.Violations = append(result.Violations, violation)
+			p.result.Violations = append(p.result.Violations, violation)
 
 			// Track new mappings
 			if isNew {
-				result.MappingsCreated[matchedValue] = alias
+				p.result.MappingsCreated[matchedValue] = alias
 			}
 
-			result.WasSanitized = true
+			p.result.WasSanitized = true
 		}
 	}
 
-	result.SanitizedContent = workingContent
-	result.ProcessingTimeMs = time.Since(startTime).Milliseconds()
+	return workingContent
+}
 
-	return result
+// SetDecodeLimits bounds the decoding of encoded segments before
+// sanitizing.
+func (e *Engine) SetDecodeLimits(limits decode.Limits) error {
+	limits, err := limits.WithDefaults()
+	if err != nil {
+		return err
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.decodeLimits = limits
+	return nil
 }
 
-// getOrCreateAlias retrieves existing alias or creates a new one.
-func (e *Engine) getOrCreateAlias(session *types.Session, original, prefix string) (string, bool) {
-	// Check if alias already exists
-	if alias, ok := session.GetAlias(original); ok {
-		return alias, false
+// aliasWarnings summarizes aliased values per rule, in rule order, so the
+// developer can see what was replaced before the prompt was sent.
+func aliasWarnings(violations []types.Violation) []types.Warning {
+	var warnings []types.Warning
+	counts := make(map[string]int)
+	for _, v := range violations {
+		if counts[v.RuleID] == 0 {
+			warnings = append(warnings, types.Warning{Rule: v.RuleID, Reason: v.RuleName})
+		}
+		counts[v.RuleID]++
 	}
 
-	// Generate new alias
-	alias := e.aliasGen.Generate(session, prefix)
-	session.AddMapping(original, alias)
+	for i := range warnings {
+		noun := "values"
+		if counts[warnings[i].Rule] == 1 {
+			noun = "value"
+		}
+		warnings[i].Reason = fmt.Sprintf("%s: %d %s aliased", warnings[i].Reason, counts[warnings[i].Rule], noun)
+		warnings[i].Remediation = "Aliases are restored in the response; remove the values from the prompt if they should not be shared even in aliased form"
+	}
+	return warnings
+}
+
+// Alias returns the session's alias for a value, creating one with the
+// given prefix if none exists. The boolean reports whether it was created.
+func (e *Engine) Alias(session *types.Session, original, prefix string) (string, bool) {
+	return e.getOrCreateAlias(session, original, prefix)
+}
 
-	return alias, true
+// getOrCreateAlias retrieves existing alias or creates a new one.
+func (e *Engine) getOrCreateAlias(session *types.Session, original, prefix string) (string, bool) {
+	// Lookup and creation happen atomically on the session
+	return session.GetOrCreateAlias(original, prefix, e.aliasGen.Format)
 }
 
 // isException checks if a value matches any exception pattern.
@@ -167,8 +283,8 @@ func (e *Engine) isException(value string, exceptions []string) bool {
 	return false
 }
 
-// redactValue creates a redacted version of a value for logging.
-func redactValue(value string) string {
+// RedactValue creates a redacted version of a value for logging.
+func RedactValue(value string) string {
 	if len(value) <= 3 {
 		return "***"
 	}
@@ -1,9 +1,15 @@
 package sanitizer
 
 import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
+	"github.com/enterprise/opencode-enterprise-shield/pkg/desanitizer"
 	"github.com/enterprise/opencode-enterprise-shield/pkg/types"
 )
 
@@ -145,3 +151,246 @@ func TestAliasGenerator(t *testing.T) {
 	}
 }
 
+
+func TestSanitize_ConcurrentSanitizeDesanitize(t *testing.T) {
+	engine := NewEngine(DefaultRules())
+	desanitizerEngine := desanitizer.NewEngine()
+	session := types.NewSession("test-session", "user@test.com", "engineering", 8*time.Hour)
+
+	const workers = 16
+	const iterations = 50
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < iterations; i++ {
+				// Half the values are shared across workers, half are unique
+				content := fmt.Sprintf("Query ServerDB%d and 10.0.%d.%d", i%10, w, i)
+				result := engine.Sanitize(content, session)
+
+				restored := desanitizerEngine.Desanitize(result.SanitizedContent, session)
+				if restored.DesanitizedContent != content {
+					t.Errorf("Round trip mismatch: %q -> %q -> %q", content, result.SanitizedContent, restored.DesanitizedContent)
+					return
+				}
+				session.Touch()
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	// Every original must map to exactly one alias and back again
+	reverse := session.ReverseMappingsSnapshot()
+	if len(reverse) != session.MappingCount() {
+		t.Fatalf("Expected %d reverse mappings, got %d", session.MappingCount(), len(reverse))
+	}
+	for alias, original := range reverse {
+		got, ok := session.GetAlias(original)
+		if !ok || got != alias {
+			t.Errorf("Mapping for %s is %s, reverse says %s", original, got, alias)
+		}
+	}
+
+	// 10 shared server names plus one IP per worker and iteration
+	expected := 10 + workers*iterations
+	if session.MappingCount() != expected {
+		t.Errorf("Expected %d mappings, got %d", expected, session.MappingCount())
+	}
+}
+
+func TestSession_ConcurrentGetOrCreateAlias(t *testing.T) {
+	gen := NewAliasGenerator()
+	session := types.NewSession("test-session", "user@test.com", "engineering", 8*time.Hour)
+
+	const workers = 32
+	aliases := make([]string, workers)
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			aliases[w], _ = session.GetOrCreateAlias("ServerDB01", "SERVER", gen.Format)
+		}(w)
+	}
+	wg.Wait()
+
+	for _, alias := range aliases {
+		if alias != "SERVER_0" {
+			t.Errorf("Expected every caller to get SERVER_0, got %s", alias)
+		}
+	}
+	if next := session.GetNextCounter("SERVER"); next != 1 {
+		t.Errorf("Expected counter to advance once, got next value %d", next)
+	}
+}
+
+func TestSanitize_Warnings(t *testing.T) {
+	engine := NewEngine(DefaultRules())
+	session := types.NewSession("test-session", "user@test.com", "engineering", 8*time.Hour)
+
+	result := engine.Sanitize("Compare db-01.internal.company.com with db-02.internal.company.com on 10.0.0.5", session)
+
+	want := map[string]string{
+		"internal_hostname": "Internal Hostnames: 2 values aliased",
+		"private_ip_10":     "Private IP (10.x.x.x): 1 value aliased",
+	}
+	if len(result.Warnings) != len(want) {
+		t.Fatalf("Expected %d warnings, got %+v", len(want), result.Warnings)
+	}
+	for _, warning := range result.Warnings {
+		if want[warning.Rule] != warning.Reason {
+			t.Errorf("Unexpected warning %+v", warning)
+		}
+		if warning.Remediation == "" {
+			t.Errorf("Expected remediation for %s", warning.Rule)
+		}
+	}
+
+	if result := engine.Sanitize("Nothing to see here", session); result.Warnings != nil {
+		t.Errorf("Expected no warnings, got %+v", result.Warnings)
+	}
+}
+
+func TestSanitizeWithRules_RequiredRules(t *testing.T) {
+	engine := NewEngine(DefaultRules())
+	content := "Ask alice@company.com about db-01.internal.company.com"
+
+	// internal_email is disabled by default
+	result := engine.Sanitize(content, types.NewSession("s1", "user@test.com", "", time.Hour))
+	if strings.Contains(result.SanitizedContent, "EMAIL_") {
+		t.Errorf("Expected internal_email to be off by default: %s", result.SanitizedContent)
+	}
+
+	for _, selection := range []RuleSelection{
+		{Rules: []string{"internal_email"}},
+		{Profiles: []string{"contractor"}},
+		{Profiles: []string{"strict"}},
+	} {
+		session := types.NewSession("s2", "user@test.com", "", time.Hour)
+		result, err := engine.SanitizeWithRules(content, session, selection)
+		if err != nil {
+			t.Fatalf("%+v: unexpected error %v", selection, err)
+		}
+		if strings.Contains(result.SanitizedContent, "alice@company.com") || !strings.Contains(result.SanitizedContent, "HOST_") {
+			t.Errorf("%+v: expected email and hostname to be aliased: %s", selection, result.SanitizedContent)
+		}
+	}
+}
+
+func TestSanitizeWithRules_FailsClosed(t *testing.T) {
+	engine := NewEngine(DefaultRules())
+	session := types.NewSession("s1", "user@test.com", "", time.Hour)
+
+	result, err := engine.SanitizeWithRules("db-01.internal.company.com", session, RuleSelection{Rules: []string{"no_such_rule"}})
+	if !errors.Is(err, ErrUnknownRule) {
+		t.Errorf("Expected ErrUnknownRule, got %v", err)
+	}
+	if result.WasSanitized || session.MappingCount() != 0 {
+		t.Error("Expected nothing to be sanitized when a required rule is missing")
+	}
+
+	if _, err := engine.SanitizeWithRules("x", session, RuleSelection{Profiles: []string{"ghost"}}); !errors.Is(err, ErrUnknownProfile) {
+		t.Errorf("Expected ErrUnknownProfile, got %v", err)
+	}
+	if err := engine.SetProfile("bad", []string{"no_such_rule"}); !errors.Is(err, ErrUnknownRule) {
+		t.Errorf("Expected SetProfile to reject unknown rules, got %v", err)
+	}
+	if err := engine.SetProfile("support", []string{"internal_email"}); err != nil {
+		t.Errorf("Expected valid profile, got %v", err)
+	}
+	if err := engine.CheckSelection(RuleSelection{Profiles: []string{"support"}}); err != nil {
+		t.Errorf("Expected custom profile to resolve, got %v", err)
+	}
+}
+
+func TestSanitize_EncodedContent(t *testing.T) {
+	engine := NewEngine(DefaultRules())
+	session := types.NewSession("test-session", "user@test.com", "engineering", 8*time.Hour)
+
+	encoded := base64.StdEncoding.EncodeToString([]byte("host=db01.internal"))
+	content := "config: " + encoded + " plain"
+	result := engine.Sanitize(content, session)
+
+	alias, ok := result.MappingsCreated["db01.internal"]
+	if !ok {
+		t.Fatalf("Expected the decoded hostname to be aliased, got %+v", result.MappingsCreated)
+	}
+	want := "config: " + base64.StdEncoding.EncodeToString([]byte("host="+alias)) + " plain"
+	if result.SanitizedContent != want {
+		t.Errorf("Expected %q, got %q", want, result.SanitizedContent)
+	}
+
+	if len(result.Violations) != 1 {
+		t.Fatalf("Expected one violation, got %+v", result.Violations)
+	}
+	v := result.Violations[0]
+	if v.Position != len("config: ") || v.Length != len(encoded) || v.Encoding != "base64" {
+		t.Errorf("Expected the violation at the encoded span, got %+v", v)
+	}
+
+	// Segments without matches are left as they are
+	untouched := "config: " + base64.StdEncoding.EncodeToString([]byte("nothing to see here"))
+	if result := engine.Sanitize(untouched, session); result.SanitizedContent != untouched {
+		t.Errorf("Expected %q unchanged, got %q", untouched, result.SanitizedContent)
+	}
+}
+
+func TestSanitize_URLRoundTrip(t *testing.T) {
+	engine := NewEngine(DefaultRules())
+	session := types.NewSession("test-session", "user@test.com", "engineering", 8*time.Hour)
+
+	content := "fetch https://api.example.com/v1/query?host=10.0.0.1%20x&y=1 please"
+	result := engine.Sanitize(content, session)
+
+	alias, ok := result.MappingsCreated["10.0.0.1"]
+	if !ok {
+		t.Fatalf("Expected the address to be aliased, got %+v", result.MappingsCreated)
+	}
+	want := "fetch https://api.example.com/v1/query?host=" + alias + "%20x&y=1 please"
+	if result.SanitizedContent != want {
+		t.Errorf("Expected only the address replaced, got %q", result.SanitizedContent)
+	}
+	if v := result.Violations[0]; content[v.Position:v.Position+v.Length] != "10.0.0.1" {
+		t.Errorf("Expected the violation at the address, got %q", content[v.Position:v.Position+v.Length])
+	}
+
+	restored := desanitizer.NewEngine().Desanitize(result.SanitizedContent, session)
+	if restored.DesanitizedContent != content {
+		t.Errorf("Round trip mismatch: %q -> %q -> %q", content, result.SanitizedContent, restored.DesanitizedContent)
+	}
+}
+
+func TestSanitize_NormalizedContent(t *testing.T) {
+	engine := NewEngine(DefaultRules())
+	session := types.NewSession("test-session", "user@test.com", "engineering", 8*time.Hour)
+
+	fullWidth := "１０.１.２.３"
+	joined := "10.\u200b4.5.6"
+	content := "hosts " + fullWidth + " and " + joined
+	result := engine.Sanitize(content, session)
+
+	// The original text is aliased, so desanitization restores it as typed
+	first, ok := result.MappingsCreated[fullWidth]
+	if !ok {
+		t.Fatalf("Expected the full-width address to be aliased, got %+v", result.MappingsCreated)
+	}
+	second, ok := result.MappingsCreated[joined]
+	if !ok {
+		t.Fatalf("Expected the zero-width split address to be aliased, got %+v", result.MappingsCreated)
+	}
+	if want := "hosts " + first + " and " + second; result.SanitizedContent != want {
+		t.Errorf("Expected %q, got %q", want, result.SanitizedContent)
+	}
+
+	for _, v := range result.Violations {
+		if !v.Normalized || v.Length != len(fullWidth) && v.Length != len(joined) {
+			t.Errorf("Expected a normalized violation over the original text, got %+v", v)
+		}
+	}
+	if len(result.Violations) != 2 || result.Violations[0].RedactedValue != RedactValue("10.4.5.6") {
+		t.Errorf("Expected the normalized values redacted, got %+v", result.Violations)
+	}
+}
@@ -0,0 +1,102 @@
+// Package sanitizer provides per-request rule selection and rule profiles.
+package sanitizer
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// AllRules is a profile entry that selects every loaded rule.
+const AllRules = "*"
+
+// ErrUnknownRule is returned when a request requires a rule that is not loaded.
+var ErrUnknownRule = errors.New("unknown sanitization rule")
+
+// ErrUnknownProfile is returned when a request requires an undefined profile.
+var ErrUnknownProfile = errors.New("unknown rule profile")
+
+// RuleSelection selects rules to apply to one request in addition to the
+// rules enabled by default, including rules that are disabled by default.
+type RuleSelection struct {
+	Rules    []string // Rule IDs
+	Profiles []string // Rule profile names
+}
+
+// DefaultProfiles returns the built-in rule profiles.
+func DefaultProfiles() map[string][]string {
+	return map[string][]string{
+		// Every rule, including those disabled by default
+		"strict": {AllRules},
+		// Hide internal contacts from people outside the company
+		"contractor": {"internal_email"},
+	}
+}
+
+// SetProfile defines or replaces a rule profile. Every rule ID must be loaded.
+func (e *Engine) SetProfile(name string, ruleIDs []string) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	for _, id := range ruleIDs {
+		if _, ok := e.compiledRules[id]; !ok && id != AllRules {
+			return fmt.Errorf("profile %s: %w %q", name, ErrUnknownRule, id)
+		}
+	}
+
+	e.profiles[name] = append([]string(nil), ruleIDs...)
+	return nil
+}
+
+// Profiles returns the names of the defined rule profiles.
+func (e *Engine) Profiles() []string {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	names := make([]string, 0, len(e.profiles))
+	for name := range e.profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// CheckSelection reports the first rule or profile in a selection that is not
+// defined, without sanitizing anything.
+func (e *Engine) CheckSelection(selection RuleSelection) error {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	_, err := e.resolveSelection(selection)
+	return err
+}
+
+// resolveSelection returns the set of rule IDs a selection adds.
+// Caller must hold e.mu.
+func (e *Engine) resolveSelection(selection RuleSelection) (map[string]bool, error) {
+	selected := make(map[string]bool)
+
+	ids := append([]string(nil), selection.Rules...)
+	for _, name := range selection.Profiles {
+		profile, ok := e.profiles[name]
+		if !ok {
+			return nil, fmt.Errorf("%w %q", ErrUnknownProfile, name)
+		}
+		ids = append(ids, profile...)
+	}
+
+	for _, id := range ids {
+		if id == AllRules {
+			for _, rule := range e.rules {
+				selected[rule.RuleID] = true
+			}
+			continue
+		}
+		if _, ok := e.compiledRules[id]; !ok {
+			return nil, fmt.Errorf("%w %q", ErrUnknownRule, id)
+		}
+		selected[id] = true
+	}
+
+	return selected, nil
+}
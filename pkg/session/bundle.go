@@ -0,0 +1,195 @@
+// Package session provides encrypted export bundles for Enterprise Shield.
+package session
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/enterprise/opencode-enterprise-shield/pkg/crypto"
+	"github.com/enterprise/opencode-enterprise-shield/pkg/types"
+)
+
+// BundleVersion is the current export bundle format version.
+const BundleVersion = 1
+
+// Bundle is an encrypted, signed export of a session's mappings.
+// The header fields are signed in the clear so the importer can apply policy
+// before decrypting; the mappings themselves are only in Ciphertext.
+type Bundle struct {
+	Version    int       `json:"version"`
+	SessionID  string    `json:"sessionId"`
+	UserID     string    `json:"userId"`
+	Department string    `json:"department,omitempty"`
+	ExportedBy string    `json:"exportedBy"`
+	ExportedAt time.Time `json:"exportedAt"`
+	Salt       []byte    `json:"salt"`
+	Ciphertext []byte    `json:"ciphertext"`
+	SignerKey  []byte    `json:"signerKey"`
+	Signature  []byte    `json:"signature"`
+}
+
+// bundlePayload is the encrypted content of a bundle.
+type bundlePayload struct {
+	ReverseMappings map[string]string `json:"reverseMappings"`
+	Counters        map[string]int    `json:"counters"`
+}
+
+var (
+	// ErrBundleSignature is returned when a bundle's signature does not
+	// verify or was made by an untrusted key.
+	ErrBundleSignature = errors.New("bundle signature is invalid or untrusted")
+	// ErrNoTrustedKeys is returned when a bundle is verified without trusted
+	// keys. A bundle carries its signer's key, so only a key trusted in
+	// advance makes the signature mean anything.
+	ErrNoTrustedKeys = errors.New("no trusted keys to verify the bundle against")
+)
+
+// ExportBundle encrypts a session's mappings with a key derived from
+// passphrase and signs the result with signingKey.
+func ExportBundle(session *types.Session, exportedBy, passphrase string, signingKey ed25519.PrivateKey) (*Bundle, error) {
+	if passphrase == "" {
+		return nil, errors.New("passphrase is required")
+	}
+
+	snapshot := session.Clone()
+	payload, err := json.Marshal(bundlePayload{
+		ReverseMappings: snapshot.ReverseMappings,
+		Counters:        snapshot.Counters,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal bundle payload: %w", err)
+	}
+
+	salt := make([]byte, 16)
+	if _, err := io.ReadFull(rand.Reader, salt); err != nil {
+		return nil, fmt.Errorf("failed to generate salt: %w", err)
+	}
+
+	encryptor, err := crypto.NewAESEncryptor(crypto.DeriveKey([]byte(passphrase), salt))
+	if err != nil {
+		return nil, err
+	}
+	ciphertext, err := encryptor.Encrypt(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt bundle: %w", err)
+	}
+
+	bundle := &Bundle{
+		Version:    BundleVersion,
+		SessionID:  snapshot.SessionID,
+		UserID:     snapshot.UserID,
+		Department: snapshot.Department,
+		ExportedBy: exportedBy,
+		ExportedAt: time.Now().UTC(),
+		Salt:       salt,
+		Ciphertext: ciphertext,
+		SignerKey:  signingKey.Public().(ed25519.PublicKey),
+	}
+
+	message, err := bundle.signedMessage()
+	if err != nil {
+		return nil, err
+	}
+	bundle.Signature = ed25519.Sign(signingKey, message)
+
+	return bundle, nil
+}
+
+// Verify checks that the bundle was signed by one of the trusted keys,
+// which must not be empty.
+func (b *Bundle) Verify(trusted ...ed25519.PublicKey) error {
+	if b.Version != BundleVersion {
+		return fmt.Errorf("unsupported bundle version %d", b.Version)
+	}
+	if len(trusted) == 0 {
+		return ErrNoTrustedKeys
+	}
+	if len(b.SignerKey) != ed25519.PublicKeySize {
+		return ErrBundleSignature
+	}
+
+	message, err := b.signedMessage()
+	if err != nil {
+		return err
+	}
+	if !ed25519.Verify(ed25519.PublicKey(b.SignerKey), message, b.Signature) {
+		return ErrBundleSignature
+	}
+
+	for _, key := range trusted {
+		if key.Equal(ed25519.PublicKey(b.SignerKey)) {
+			return nil
+		}
+	}
+	return ErrBundleSignature
+}
+
+// Open verifies and decrypts a bundle into a new session owned by userID.
+// The session gets a fresh ID and a scope naming the source session, so it
+// never collides with the importer's own sessions.
+func (b *Bundle) Open(passphrase, userID, department string, ttl time.Duration, trusted ...ed25519.PublicKey) (*types.Session, error) {
+	if err := b.Verify(trusted...); err != nil {
+		return nil, err
+	}
+
+	encryptor, err := crypto.NewAESEncryptor(crypto.DeriveKey([]byte(passphrase), b.Salt))
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := encryptor.Decrypt(b.Ciphertext)
+	if err != nil {
+		return nil, errors.New("failed to decrypt bundle: wrong passphrase or corrupted data")
+	}
+
+	var payload bundlePayload
+	if err := json.Unmarshal(plaintext, &payload); err != nil {
+		return nil, fmt.Errorf("failed to parse bundle payload: %w", err)
+	}
+
+	session := types.NewSession(newSessionID(), userID, department, ttl)
+	session.Scope = "import:" + b.SessionID
+	for alias, original := range payload.ReverseMappings {
+		session.AddMapping(original, alias)
+	}
+	for prefix, count := range payload.Counters {
+		session.Counters[prefix] = count
+	}
+
+	return session, nil
+}
+
+// signedMessage returns the canonical bytes covered by the signature.
+func (b *Bundle) signedMessage() ([]byte, error) {
+	canonical := struct {
+		Version    int    `json:"version"`
+		SessionID  string `json:"sessionId"`
+		UserID     string `json:"userId"`
+		Department string `json:"department"`
+		ExportedBy string `json:"exportedBy"`
+		ExportedAt string `json:"exportedAt"`
+		Salt       []byte `json:"salt"`
+		Ciphertext []byte `json:"ciphertext"`
+		SignerKey  []byte `json:"signerKey"`
+	}{
+		Version:    b.Version,
+		SessionID:  b.SessionID,
+		UserID:     b.UserID,
+		Department: b.Department,
+		ExportedBy: b.ExportedBy,
+		ExportedAt: b.ExportedAt.UTC().Format(time.RFC3339Nano),
+		Salt:       b.Salt,
+		Ciphertext: b.Ciphertext,
+		SignerKey:  b.SignerKey,
+	}
+
+	data, err := json.Marshal(canonical)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal bundle header: %w", err)
+	}
+	return data, nil
+}
@@ -0,0 +1,71 @@
+package session
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/enterprise/opencode-enterprise-shield/pkg/types"
+)
+
+func TestBundle_RoundTrip(t *testing.T) {
+	_, signingKey, _ := ed25519.GenerateKey(rand.Reader)
+
+	source := types.NewSession("sess_source", "alice@test.com", "engineering", 8*time.Hour)
+	source.AddMapping("ServerDB01", "SERVER_0")
+	source.AddMapping("10.0.0.5", "IP_0")
+
+	bundle, err := ExportBundle(source, "alice@test.com", "correct horse", signingKey)
+	if err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+
+	imported, err := bundle.Open("correct horse", "bob@test.com", "engineering", time.Hour, signingKey.Public().(ed25519.PublicKey))
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+
+	if imported.UserID != "bob@test.com" || imported.SessionID == source.SessionID {
+		t.Error("Expected a new session owned by the importer")
+	}
+	if original, ok := imported.GetOriginal("SERVER_0"); !ok || original != "ServerDB01" {
+		t.Errorf("Expected SERVER_0 to restore ServerDB01, got %q", original)
+	}
+	if imported.MappingCount() != 2 {
+		t.Errorf("Expected 2 mappings, got %d", imported.MappingCount())
+	}
+}
+
+func TestBundle_RejectsTamperingAndWrongPassphrase(t *testing.T) {
+	_, signingKey, _ := ed25519.GenerateKey(rand.Reader)
+	otherKey, _, _ := ed25519.GenerateKey(rand.Reader)
+
+	source := types.NewSession("sess_source", "alice@test.com", "engineering", 8*time.Hour)
+	source.AddMapping("ServerDB01", "SERVER_0")
+
+	bundle, err := ExportBundle(source, "alice@test.com", "correct horse", signingKey)
+	if err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+
+	trusted := signingKey.Public().(ed25519.PublicKey)
+	if _, err := bundle.Open("wrong", "bob@test.com", "", time.Hour, trusted); err == nil || errors.Is(err, ErrBundleSignature) {
+		t.Errorf("Expected wrong passphrase to fail decryption, got %v", err)
+	}
+
+	// The key in the bundle proves nothing by itself
+	if _, err := bundle.Open("correct horse", "bob@test.com", "", time.Hour); !errors.Is(err, ErrNoTrustedKeys) {
+		t.Errorf("Expected no trusted keys to fail, got %v", err)
+	}
+
+	if _, err := bundle.Open("correct horse", "bob@test.com", "", time.Hour, otherKey); !errors.Is(err, ErrBundleSignature) {
+		t.Errorf("Expected untrusted signer to fail, got %v", err)
+	}
+
+	bundle.Department = "finance"
+	if err := bundle.Verify(trusted); !errors.Is(err, ErrBundleSignature) {
+		t.Errorf("Expected tampered header to fail verification, got %v", err)
+	}
+}
@@ -0,0 +1,185 @@
+// Package session provides on-disk session persistence for Enterprise Shield.
+package session
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/enterprise/opencode-enterprise-shield/pkg/crypto"
+	"github.com/enterprise/opencode-enterprise-shield/pkg/paths"
+	"github.com/enterprise/opencode-enterprise-shield/pkg/types"
+)
+
+// sessionFileExt is the file extension for persisted sessions.
+const sessionFileExt = ".session"
+
+// FileStore persists sessions as one file per session so that other
+// processes (such as the CLI) can read what a running shield has aliased.
+type FileStore struct {
+	dir       string
+	encryptor *crypto.AESEncryptor
+}
+
+// NewFileStore creates a file store in dir. If encryptor is non-nil, session
+// files are encrypted with AES-256-GCM.
+func NewFileStore(dir string, encryptor *crypto.AESEncryptor) (*FileStore, error) {
+	dir, err := paths.ExpandHome(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create session directory: %w", err)
+	}
+
+	return &FileStore{
+		dir:       dir,
+		encryptor: encryptor,
+	}, nil
+}
+
+// Save writes a session to disk, replacing any previous version.
+func (f *FileStore) Save(session *types.Session) error {
+	path, err := f.path(session.SessionID)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(session.Clone())
+	if err != nil {
+		return fmt.Errorf("failed to marshal session: %w", err)
+	}
+
+	if f.encryptor != nil {
+		data, err = f.encryptor.Encrypt(data)
+		if err != nil {
+			return fmt.Errorf("failed to encrypt session: %w", err)
+		}
+	}
+
+	if err := paths.WriteFileAtomic(path, data); err != nil {
+		return fmt.Errorf("failed to save session file: %w", err)
+	}
+
+	return nil
+}
+
+// Load reads a session from disk.
+func (f *FileStore) Load(sessionID string) (*types.Session, error) {
+	path, err := f.path(sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if f.encryptor != nil {
+		data, err = f.encryptor.Decrypt(data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decrypt session %s: %w", sessionID, err)
+		}
+	}
+
+	var session types.Session
+	if err := json.Unmarshal(data, &session); err != nil {
+		return nil, fmt.Errorf("failed to parse session %s: %w", sessionID, err)
+	}
+	if session.Mappings == nil {
+		session.Mappings = make(map[string]string)
+	}
+	if session.ReverseMappings == nil {
+		session.ReverseMappings = make(map[string]string)
+	}
+	if session.Counters == nil {
+		session.Counters = make(map[string]int)
+	}
+
+	return &session, nil
+}
+
+// LoadAll reads every session on disk. Files that cannot be read are skipped.
+func (f *FileStore) LoadAll() ([]*types.Session, error) {
+	ids, err := f.List()
+	if err != nil {
+		return nil, err
+	}
+
+	sessions := make([]*types.Session, 0, len(ids))
+	for _, id := range ids {
+		session, err := f.Load(id)
+		if err != nil {
+			continue
+		}
+		sessions = append(sessions, session)
+	}
+	return sessions, nil
+}
+
+// List returns the IDs of all persisted sessions.
+func (f *FileStore) List() ([]string, error) {
+	entries, err := os.ReadDir(f.dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read session directory: %w", err)
+	}
+
+	ids := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, sessionFileExt) {
+			continue
+		}
+		ids = append(ids, strings.TrimSuffix(name, sessionFileExt))
+	}
+	return ids, nil
+}
+
+// Version identifies one written state of a session file. Modification
+// times alone are too coarse on some filesystems, so the size is included.
+type Version struct {
+	ModTime time.Time
+	Size    int64
+}
+
+// Version returns the current version of a session's file.
+func (f *FileStore) Version(sessionID string) (Version, error) {
+	path, err := f.path(sessionID)
+	if err != nil {
+		return Version{}, err
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return Version{}, err
+	}
+	return Version{ModTime: info.ModTime(), Size: info.Size()}, nil
+}
+
+// Delete removes a session from disk. Deleting a missing session is not an error.
+func (f *FileStore) Delete(sessionID string) error {
+	path, err := f.path(sessionID)
+	if err != nil {
+		return err
+	}
+
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to delete session file: %w", err)
+	}
+	return nil
+}
+
+// path returns the file path for a session, rejecting IDs that could escape
+// the store directory.
+func (f *FileStore) path(sessionID string) (string, error) {
+	if sessionID == "" || sessionID != filepath.Base(sessionID) || strings.HasPrefix(sessionID, ".") {
+		return "", fmt.Errorf("invalid session ID %q", sessionID)
+	}
+	return filepath.Join(f.dir, sessionID+sessionFileExt), nil
+}
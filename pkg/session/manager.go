@@ -2,6 +2,7 @@
 package session
 
 import (
+	"sort"
 	"sync"
 	"time"
 
@@ -15,6 +16,15 @@ type Manager struct {
 	defaultTTL time.Duration
 	maxMappings int
 	mu         sync.RWMutex
+
+	files  *FileStore
+	synced map[string]Version // sessionID -> file version we last saw
+
+	slidingTTL  bool
+	maxLifetime time.Duration
+	onExpire    func(*types.Session)
+	stopJanitor chan struct{}
+	janitorDone chan struct{}
 }
 
 // NewManager creates a new session manager.
@@ -23,78 +33,377 @@ func NewManager(defaultTTL time.Duration, maxMappings int) *Manager {
 		store:       NewStore(),
 		defaultTTL:  defaultTTL,
 		maxMappings: maxMappings,
+		synced:      make(map[string]Version),
 	}
 }
 
 // GetOrCreate retrieves an existing session or creates a new one.
-func (m *Manager) GetOrCreate(userID, department string, sessionID string) (*types.Session, bool) {
+// Sessions are keyed by user and scope, where scope is a caller-supplied
+// conversation ID or workspace path; the empty scope is the user's default
+// session. An explicit sessionID takes precedence when it belongs to the
+// user and the same scope.
+func (m *Manager) GetOrCreate(userID, department, scope, sessionID string) (*types.Session, bool) {
 	m.mu.Lock()
-	defer m.mu.Unlock()
+	var expired []*types.Session
 
 	// Try to get existing session
 	if sessionID != "" {
 		session, ok := m.store.Get(sessionID)
-		if ok && !session.IsExpired() && session.UserID == userID {
-			session.Touch()
+		if ok && session.IsExpired() {
+			expired = append(expired, m.takeExpired(sessionID)...)
+		} else if ok && session.UserID == userID && session.Scope == scope {
+			m.refresh(session)
+			m.touch(session)
+			m.mu.Unlock()
 			return session, false
 		}
 	}
 
-	// Try to find by user ID
-	existingID, ok := m.store.GetByUserID(userID)
+	// Try to find by user ID and scope
+	existingID, ok := m.store.GetByScope(userID, scope)
 	if ok {
 		session, ok := m.store.Get(existingID)
-		if ok && !session.IsExpired() {
-			session.Touch()
+		if ok && session.IsExpired() {
+			expired = append(expired, m.takeExpired(existingID)...)
+		} else if ok {
+			m.refresh(session)
+			m.touch(session)
+			m.mu.Unlock()
 			return session, false
 		}
 	}
 
 	// Create new session
-	newSessionID := "sess_" + uuid.New().String()[:12]
-	session := types.NewSession(newSessionID, userID, department, m.defaultTTL)
+	session := types.NewSession(newSessionID(), userID, department, m.defaultTTL)
+	session.Scope = scope
 	m.store.Set(session)
+	onExpire, files := m.onExpire, m.files
+	m.mu.Unlock()
 
+	// A replaced session expires the same way as one the janitor removes
+	expire(expired, files, onExpire)
 	return session, true
 }
 
-// Get retrieves a session by ID.
-func (m *Manager) Get(sessionID string) (*types.Session, bool) {
+// SetPersistence attaches a file store. Live sessions already on disk are
+// loaded and expired ones deleted, and from then on Save writes sessions
+// through to the store and removals delete their files.
+func (m *Manager) SetPersistence(files *FileStore) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	sessions, err := files.LoadAll()
+	if err != nil {
+		return err
+	}
+
+	// Oldest first, so the newest session wins for each user and scope
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].CreatedAt.Before(sessions[j].CreatedAt)
+	})
+	m.files = files
+	for _, session := range sessions {
+		if session.IsExpired() {
+			_ = files.Delete(session.SessionID)
+			session.Wipe()
+			continue
+		}
+		m.store.Set(session)
+		m.markSynced(session.SessionID)
+	}
+
+	return nil
+}
+
+// Save writes a session through to the file store, if one is attached.
+func (m *Manager) Save(session *types.Session) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.save(session)
+}
+
+// Import adds an externally created session, such as one restored from an
+// export bundle, and persists it.
+func (m *Manager) Import(session *types.Session) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.store.Set(session)
+	return m.save(session)
+}
+
+// List returns all live sessions.
+func (m *Manager) List() []*types.Session {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
 
+	sessions := make([]*types.Session, 0)
+	for _, sessionID := range m.store.ListAll() {
+		if session, ok := m.store.Get(sessionID); ok && !session.IsExpired() {
+			sessions = append(sessions, session)
+		}
+	}
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].CreatedAt.Before(sessions[j].CreatedAt)
+	})
+	return sessions
+}
+
+// Forget revokes a single alias from a session and persists the change, so
+// the original value can no longer be restored from it.
+func (m *Manager) Forget(sessionID, alias string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	session, ok := m.store.Get(sessionID)
+	if !ok {
+		return false
+	}
+	m.refresh(session)
+	if !session.RemoveMapping(alias) {
+		return false
+	}
+	_ = m.save(session)
+	return true
+}
+
+// save writes a session to the file store and records the file's version.
+// Caller must hold m.mu.
+func (m *Manager) save(session *types.Session) error {
+	if m.files == nil {
+		return nil
+	}
+	if err := m.files.Save(session); err != nil {
+		return err
+	}
+	m.markSynced(session.SessionID)
+	return nil
+}
+
+// markSynced records the current version of a session's file.
+// Caller must hold m.mu.
+func (m *Manager) markSynced(sessionID string) {
+	if version, err := m.files.Version(sessionID); err == nil {
+		m.synced[sessionID] = version
+	}
+}
+
+// refresh reloads a session's mappings if another process (such as the
+// "session forget" CLI command) rewrote its file since we last saw it.
+// Caller must hold m.mu.
+func (m *Manager) refresh(session *types.Session) {
+	if m.files == nil {
+		return
+	}
+	version, err := m.files.Version(session.SessionID)
+	if err != nil || version == m.synced[session.SessionID] {
+		return
+	}
+	if loaded, err := m.files.Load(session.SessionID); err == nil {
+		session.ReplaceMappings(loaded)
+		m.synced[session.SessionID] = version
+	}
+}
+
+// remove deletes a session from memory and disk and wipes it.
+// Caller must hold m.mu.
+func (m *Manager) remove(sessionID string) {
+	if session, ok := m.store.Delete(sessionID); ok {
+		session.Wipe()
+	}
+	if m.files != nil {
+		_ = m.files.Delete(sessionID)
+		delete(m.synced, sessionID)
+	}
+}
+
+// touch records an access and, with sliding expiry, pushes ExpiresAt out.
+func (m *Manager) touch(session *types.Session) {
+	session.Touch()
+	if m.slidingTTL {
+		session.Extend(m.defaultTTL, m.maxLifetime)
+	}
+}
+
+// SetSlidingExpiry enables sliding expiry: every access extends a session's
+// ExpiresAt to the default TTL from now, but never beyond maxLifetime after
+// the session was created. A zero maxLifetime disables the cap.
+func (m *Manager) SetSlidingExpiry(maxLifetime time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.slidingTTL = true
+	m.maxLifetime = maxLifetime
+}
+
+// SetExpiryCallback registers a function called for each expired session
+// removed by CleanupExpired or replaced by GetOrCreate, before its mappings
+// are wiped.
+func (m *Manager) SetExpiryCallback(fn func(*types.Session)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.onExpire = fn
+}
+
+// StartJanitor runs CleanupExpired every interval in a background goroutine
+// until Stop is called. Calling it again while a janitor runs is a no-op.
+func (m *Manager) StartJanitor(interval time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if interval <= 0 || m.stopJanitor != nil {
+		return
+	}
+
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	m.stopJanitor = stop
+	m.janitorDone = done
+
+	go func() {
+		defer close(done)
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				m.CleanupExpired()
+			case <-stop:
+				return
+			}
+		}
+	}()
+}
+
+// Stop stops the background janitor, if running, and waits for it to exit.
+func (m *Manager) Stop() {
+	m.mu.Lock()
+	stop, done := m.stopJanitor, m.janitorDone
+	m.stopJanitor, m.janitorDone = nil, nil
+	m.mu.Unlock()
+
+	if stop != nil {
+		close(stop)
+		<-done
+	}
+}
+
+// Get retrieves a session by ID.
+func (m *Manager) Get(sessionID string) (*types.Session, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
 	session, ok := m.store.Get(sessionID)
+	if ok {
+		m.refresh(session)
+	} else if m.files != nil {
+		// Created by another process after we loaded the store. It is kept
+		// by ID only, so a stale file never displaces the session in use
+		// for its user and scope.
+		loaded, err := m.files.Load(sessionID)
+		if err != nil || loaded.IsExpired() {
+			return nil, false
+		}
+		m.store.Add(loaded)
+		m.markSynced(sessionID)
+		session, ok = loaded, true
+	}
 	if !ok || session.IsExpired() {
 		return nil, false
 	}
 	return session, true
 }
 
-// Delete removes a session.
+// Delete removes a session and wipes its mappings.
 func (m *Manager) Delete(sessionID string) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	m.store.Delete(sessionID)
+	m.remove(sessionID)
+}
+
+// Clear removes all sessions for a user, across every scope.
+func (m *Manager) Clear(userID string) int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	sessionIDs := m.store.GetByUserID(userID)
+	for _, sessionID := range sessionIDs {
+		m.remove(sessionID)
+	}
+	return len(sessionIDs)
 }
 
-// Clear removes all sessions for a user.
-func (m *Manager) Clear(userID string) {
+// ClearScope removes a user's session for a single scope.
+func (m *Manager) ClearScope(userID, scope string) bool {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	sessionID, ok := m.store.GetByUserID(userID)
+	sessionID, ok := m.store.GetByScope(userID, scope)
 	if ok {
-		m.store.Delete(sessionID)
+		m.remove(sessionID)
+	}
+	return ok
+}
+
+// ListForUser returns all live sessions for a user.
+func (m *Manager) ListForUser(userID string) []*types.Session {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	sessions := make([]*types.Session, 0)
+	for _, sessionID := range m.store.GetByUserID(userID) {
+		if session, ok := m.store.Get(sessionID); ok && !session.IsExpired() {
+			sessions = append(sessions, session)
+		}
 	}
+	return sessions
 }
 
-// CleanupExpired removes all expired sessions.
+// CleanupExpired removes all expired sessions, notifies the expiry callback
+// for each and wipes their mappings.
 func (m *Manager) CleanupExpired() int {
 	m.mu.Lock()
-	defer m.mu.Unlock()
+	removed := m.store.CleanupExpired()
+	onExpire := m.onExpire
+	files := m.files
+	for _, session := range removed {
+		delete(m.synced, session.SessionID)
+	}
+	m.mu.Unlock()
+
+	expire(removed, files, onExpire)
+	return len(removed)
+}
+
+// takeExpired removes an expired session from the store and returns it for
+// expire. Caller must hold m.mu.
+func (m *Manager) takeExpired(sessionID string) []*types.Session {
+	session, ok := m.store.Delete(sessionID)
+	if !ok {
+		return nil
+	}
+	delete(m.synced, sessionID)
+	return []*types.Session{session}
+}
 
-	return m.store.CleanupExpired()
+// expire deletes the files of sessions already removed from the store,
+// passes each to the expiry callback and then wipes it. It must be called
+// without holding m.mu, since the callback may use the manager.
+func expire(removed []*types.Session, files *FileStore, onExpire func(*types.Session)) {
+	for _, session := range removed {
+		if files != nil {
+			_ = files.Delete(session.SessionID)
+		}
+		if onExpire != nil {
+			onExpire(session)
+		}
+		session.Wipe()
+	}
 }
 
 // GetStats returns session statistics.
@@ -105,14 +414,25 @@ func (m *Manager) GetStats() SessionStats {
 	return m.store.GetStats()
 }
 
-// Export exports session data for backup.
+// Export returns a consistent copy of a session's data for backup or
+// hand-off. The copy is detached from the live session.
 func (m *Manager) Export(sessionID string) (*types.Session, bool) {
-	return m.Get(sessionID)
+	session, ok := m.Get(sessionID)
+	if !ok {
+		return nil, false
+	}
+	return session.Clone(), true
+}
+
+// newSessionID generates a new session identifier.
+func newSessionID() string {
+	return "sess_" + uuid.New().String()[:12]
 }
 
 // SessionStats contains session statistics.
 type SessionStats struct {
 	TotalSessions   int `json:"totalSessions"`
+	TotalUsers      int `json:"totalUsers"`
 	ActiveSessions  int `json:"activeSessions"`
 	ExpiredSessions int `json:"expiredSessions"`
 	TotalMappings   int `json:"totalMappings"`
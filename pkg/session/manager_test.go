@@ -0,0 +1,267 @@
+package session
+
+import (
+	"testing"
+	"time"
+
+	"github.com/enterprise/opencode-enterprise-shield/pkg/types"
+)
+
+func TestManager_SessionsPerScope(t *testing.T) {
+	manager := NewManager(8*time.Hour, 10000)
+
+	repoA, created := manager.GetOrCreate("user@test.com", "engineering", "/src/repo-a", "")
+	if !created {
+		t.Fatal("Expected a new session for repo-a")
+	}
+	repoB, created := manager.GetOrCreate("user@test.com", "engineering", "/src/repo-b", "")
+	if !created {
+		t.Fatal("Expected a new session for repo-b")
+	}
+	if repoA.SessionID == repoB.SessionID {
+		t.Fatal("Expected different sessions for different scopes")
+	}
+
+	repoA.AddMapping("ServerDB01", "SERVER_0")
+
+	// The first scope must survive creation of the second
+	again, created := manager.GetOrCreate("user@test.com", "engineering", "/src/repo-a", "")
+	if created || again.SessionID != repoA.SessionID {
+		t.Error("Expected repo-a session to be reused")
+	}
+	if _, ok := repoB.GetAlias("ServerDB01"); ok {
+		t.Error("Mappings must not leak between scopes")
+	}
+
+	// A session ID from another scope is not honored
+	other, _ := manager.GetOrCreate("user@test.com", "engineering", "/src/repo-b", repoA.SessionID)
+	if other.SessionID != repoB.SessionID {
+		t.Error("Expected scope to take precedence over a foreign session ID")
+	}
+
+	if n := len(manager.ListForUser("user@test.com")); n != 2 {
+		t.Errorf("Expected 2 sessions for user, got %d", n)
+	}
+}
+
+func TestManager_ClearScope(t *testing.T) {
+	manager := NewManager(8*time.Hour, 10000)
+
+	repoA, _ := manager.GetOrCreate("user@test.com", "", "conv-1", "")
+	repoB, _ := manager.GetOrCreate("user@test.com", "", "conv-2", "")
+	manager.GetOrCreate("other@test.com", "", "conv-1", "")
+
+	if !manager.ClearScope("user@test.com", "conv-1") {
+		t.Fatal("Expected conv-1 to be cleared")
+	}
+	if _, ok := manager.Get(repoA.SessionID); ok {
+		t.Error("Expected conv-1 session to be removed")
+	}
+	if _, ok := manager.Get(repoB.SessionID); !ok {
+		t.Error("Expected conv-2 session to survive")
+	}
+
+	if n := manager.Clear("user@test.com"); n != 1 {
+		t.Errorf("Expected 1 remaining session to be cleared, got %d", n)
+	}
+
+	stats := manager.GetStats()
+	if stats.TotalSessions != 1 || stats.TotalUsers != 1 {
+		t.Errorf("Expected only the other user's session to remain, got %+v", stats)
+	}
+}
+
+func TestManager_SlidingExpiry(t *testing.T) {
+	manager := NewManager(time.Hour, 10000)
+	manager.SetSlidingExpiry(90 * time.Minute)
+
+	session, _ := manager.GetOrCreate("user@test.com", "", "", "")
+
+	// Pretend the session was created 50 minutes ago
+	session.CreatedAt = session.CreatedAt.Add(-50 * time.Minute)
+	session.ExpiresAt = session.ExpiresAt.Add(-50 * time.Minute)
+
+	manager.GetOrCreate("user@test.com", "", "", session.SessionID)
+
+	// Extended to an hour from now, capped at 90 minutes after creation
+	expected := session.CreatedAt.Add(90 * time.Minute)
+	if !session.ExpiresAt.Equal(expected) {
+		t.Errorf("Expected expiry capped at %v, got %v", expected, session.ExpiresAt)
+	}
+}
+
+func TestManager_JanitorExpiresAndWipes(t *testing.T) {
+	manager := NewManager(time.Millisecond, 10000)
+
+	expired := make(chan string, 1)
+	manager.SetExpiryCallback(func(s *types.Session) {
+		if s.MappingCount() != 1 {
+			t.Errorf("Expected mappings to be intact in callback, got %d", s.MappingCount())
+		}
+		expired <- s.SessionID
+	})
+
+	session, _ := manager.GetOrCreate("user@test.com", "", "", "")
+	session.AddMapping("ServerDB01", "SERVER_0")
+
+	manager.StartJanitor(5 * time.Millisecond)
+	defer manager.Stop()
+
+	select {
+	case id := <-expired:
+		if id != session.SessionID {
+			t.Errorf("Expected %s to expire, got %s", session.SessionID, id)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Janitor did not expire the session")
+	}
+
+	manager.Stop()
+	if session.MappingCount() != 0 {
+		t.Error("Expected mappings to be wiped after expiry")
+	}
+	if _, ok := session.GetOriginal("SERVER_0"); ok {
+		t.Error("Expected reverse mappings to be wiped after expiry")
+	}
+}
+
+func TestManager_ReplacingExpiredSessionExpiresIt(t *testing.T) {
+	files, err := NewFileStore(t.TempDir(), nil)
+	if err != nil {
+		t.Fatalf("NewFileStore failed: %v", err)
+	}
+	manager := NewManager(time.Hour, 10000)
+	if err := manager.SetPersistence(files); err != nil {
+		t.Fatalf("SetPersistence failed: %v", err)
+	}
+
+	var expired []string
+	manager.SetExpiryCallback(func(s *types.Session) {
+		if s.MappingCount() != 1 {
+			t.Errorf("Expected mappings to be intact in callback, got %d", s.MappingCount())
+		}
+		expired = append(expired, s.SessionID)
+	})
+
+	old, _ := manager.GetOrCreate("user@test.com", "", "", "")
+	old.AddMapping("ServerDB01", "SERVER_0")
+	if err := manager.Save(old); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	old.ExpiresAt = time.Now().Add(-time.Minute)
+
+	// Asking for the expired session by ID replaces it like the janitor would
+	replacement, created := manager.GetOrCreate("user@test.com", "", "", old.SessionID)
+	if !created || replacement.SessionID == old.SessionID {
+		t.Fatal("Expected a new session to replace the expired one")
+	}
+	if len(expired) != 1 || expired[0] != old.SessionID {
+		t.Errorf("Expected the expiry callback for %s, got %v", old.SessionID, expired)
+	}
+	if old.MappingCount() != 0 {
+		t.Error("Expected the expired session to be wiped")
+	}
+	if _, err := files.Load(old.SessionID); err == nil {
+		t.Error("Expected the expired session's file to be deleted")
+	}
+	if n := len(manager.ListForUser("user@test.com")); n != 1 {
+		t.Errorf("Expected only the replacement to remain, got %d sessions", n)
+	}
+}
+
+func TestManager_StaleSessionFileKeepsLiveSession(t *testing.T) {
+	files, err := NewFileStore(t.TempDir(), nil)
+	if err != nil {
+		t.Fatalf("NewFileStore failed: %v", err)
+	}
+
+	// An expired session left on disk is deleted when persistence attaches
+	stale := types.NewSession("sess_stale", "user@test.com", "", time.Hour)
+	stale.ExpiresAt = time.Now().Add(-time.Minute)
+	if err := files.Save(stale); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	manager := NewManager(time.Hour, 10000)
+	if err := manager.SetPersistence(files); err != nil {
+		t.Fatalf("SetPersistence failed: %v", err)
+	}
+	if _, err := files.Load(stale.SessionID); err == nil {
+		t.Error("Expected the expired session file to be deleted")
+	}
+
+	live, _ := manager.GetOrCreate("user@test.com", "", "", "")
+	live.AddMapping("ServerDB01", "SERVER_0")
+
+	// A stale file written later, as by another process, is not returned
+	// and does not displace the live session
+	stale = types.NewSession("sess_stale", "user@test.com", "", time.Hour)
+	stale.ExpiresAt = time.Now().Add(-time.Minute)
+	if err := files.Save(stale); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	if _, ok := manager.Get(stale.SessionID); ok {
+		t.Error("Expected an expired session not to be returned")
+	}
+
+	// Nor does another process's live session for the same user and scope
+	other := types.NewSession("sess_other", "user@test.com", "", time.Hour)
+	if err := files.Save(other); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	if _, ok := manager.Get(other.SessionID); !ok {
+		t.Error("Expected the other process's session to be found by ID")
+	}
+
+	again, created := manager.GetOrCreate("user@test.com", "", "", "")
+	if created || again.SessionID != live.SessionID {
+		t.Fatalf("Expected the live session to be kept, got %s", again.SessionID)
+	}
+	if _, ok := again.GetOriginal("SERVER_0"); !ok {
+		t.Error("Expected the live session's mappings to survive")
+	}
+}
+
+func TestManager_ForgetVisibleAcrossProcesses(t *testing.T) {
+	dir := t.TempDir()
+
+	// The running shield
+	files, err := NewFileStore(dir, nil)
+	if err != nil {
+		t.Fatalf("NewFileStore failed: %v", err)
+	}
+	server := NewManager(8*time.Hour, 10000)
+	if err := server.SetPersistence(files); err != nil {
+		t.Fatalf("SetPersistence failed: %v", err)
+	}
+
+	session, _ := server.GetOrCreate("user@test.com", "", "", "")
+	session.AddMapping("ServerDB01", "SERVER_0")
+	session.AddMapping("10.0.0.5", "IP_0")
+	if err := server.Save(session); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	// A CLI invocation in another terminal
+	cli := NewManager(8*time.Hour, 10000)
+	if err := cli.SetPersistence(files); err != nil {
+		t.Fatalf("SetPersistence failed: %v", err)
+	}
+	if n := len(cli.List()); n != 1 {
+		t.Fatalf("Expected CLI to see 1 session, got %d", n)
+	}
+	if !cli.Forget(session.SessionID, "IP_0") {
+		t.Fatal("Expected IP_0 to be forgotten")
+	}
+
+	// The server picks up the change on next access
+	again, ok := server.Get(session.SessionID)
+	if !ok {
+		t.Fatal("Expected session to still exist")
+	}
+	if _, ok := again.GetOriginal("IP_0"); ok {
+		t.Error("Expected IP_0 to be forgotten in the running server")
+	}
+	if _, ok := again.GetOriginal("SERVER_0"); !ok {
+		t.Error("Expected SERVER_0 to remain")
+	}
+}
@@ -3,23 +3,22 @@ package session
 
 import (
 	"sync"
-	"time"
 
 	"github.com/enterprise/opencode-enterprise-shield/pkg/types"
 )
 
 // Store is an in-memory session store.
 type Store struct {
-	sessions map[string]*types.Session
-	userIndex map[string]string // userID -> sessionID
-	mu       sync.RWMutex
+	sessions  map[string]*types.Session
+	userIndex map[string]map[string]string // userID -> scope -> sessionID
+	mu        sync.RWMutex
 }
 
 // NewStore creates a new session store.
 func NewStore() *Store {
 	return &Store{
 		sessions:  make(map[string]*types.Session),
-		userIndex: make(map[string]string),
+		userIndex: make(map[string]map[string]string),
 	}
 }
 
@@ -32,57 +31,114 @@ func (s *Store) Get(sessionID string) (*types.Session, bool) {
 	return session, ok
 }
 
-// GetByUserID retrieves a session ID by user ID.
-func (s *Store) GetByUserID(userID string) (string, bool) {
+// GetByScope retrieves a session ID by user ID and scope.
+// The empty scope is a valid scope of its own.
+func (s *Store) GetByScope(userID, scope string) (string, bool) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
-	sessionID, ok := s.userIndex[userID]
+	sessionID, ok := s.userIndex[userID][scope]
 	return sessionID, ok
 }
 
+// GetByUserID retrieves all session IDs for a user, across every scope.
+func (s *Store) GetByUserID(userID string) []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	scopes := s.userIndex[userID]
+	ids := make([]string, 0, len(scopes))
+	for _, sessionID := range scopes {
+		ids = append(ids, sessionID)
+	}
+	return ids
+}
+
 // Set stores a session.
+// A session already stored for the same user and scope is replaced; sessions
+// for the user's other scopes are left untouched.
 func (s *Store) Set(session *types.Session) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	// Remove old session for this user if exists
-	if oldSessionID, ok := s.userIndex[session.UserID]; ok {
+	scopes, ok := s.userIndex[session.UserID]
+	if !ok {
+		scopes = make(map[string]string)
+		s.userIndex[session.UserID] = scopes
+	}
+
+	// Remove old session for this user and scope if exists
+	if oldSessionID, ok := scopes[session.Scope]; ok && oldSessionID != session.SessionID {
+		if old, ok := s.sessions[oldSessionID]; ok {
+			old.Wipe()
+		}
 		delete(s.sessions, oldSessionID)
 	}
 
 	s.sessions[session.SessionID] = session
-	s.userIndex[session.UserID] = session.SessionID
+	scopes[session.Scope] = session.SessionID
 }
 
-// Delete removes a session.
-func (s *Store) Delete(sessionID string) {
+// Add stores a session by ID without replacing the session stored for the
+// same user and scope. It is indexed for its scope only if that scope has
+// no session yet.
+func (s *Store) Add(session *types.Session) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	if session, ok := s.sessions[sessionID]; ok {
-		delete(s.userIndex, session.UserID)
-		delete(s.sessions, sessionID)
+	s.sessions[session.SessionID] = session
+	scopes, ok := s.userIndex[session.UserID]
+	if !ok {
+		scopes = make(map[string]string)
+		s.userIndex[session.UserID] = scopes
+	}
+	if _, ok := scopes[session.Scope]; !ok {
+		scopes[session.Scope] = session.SessionID
 	}
 }
 
-// CleanupExpired removes all expired sessions.
-func (s *Store) CleanupExpired() int {
+// Delete removes a session and returns it, if it was stored.
+func (s *Store) Delete(sessionID string) (*types.Session, bool) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	count := 0
-	now := time.Now()
+	return s.deleteLocked(sessionID)
+}
 
-	for sessionID, session := range s.sessions {
-		if now.After(session.ExpiresAt) {
+// deleteLocked removes a session and its index entry. Caller must hold s.mu.
+func (s *Store) deleteLocked(sessionID string) (*types.Session, bool) {
+	session, ok := s.sessions[sessionID]
+	if !ok {
+		return nil, false
+	}
+
+	if scopes, ok := s.userIndex[session.UserID]; ok {
+		if scopes[session.Scope] == sessionID {
+			delete(scopes, session.Scope)
+		}
+		if len(scopes) == 0 {
 			delete(s.userIndex, session.UserID)
-			delete(s.sessions, sessionID)
-			count++
+		}
+	}
+	delete(s.sessions, sessionID)
+	return session, true
+}
+
+// CleanupExpired removes all expired sessions and returns them.
+func (s *Store) CleanupExpired() []*types.Session {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	removed := make([]*types.Session, 0)
+
+	for sessionID, session := range s.sessions {
+		if session.IsExpired() {
+			s.deleteLocked(sessionID)
+			removed = append(removed, session)
 		}
 	}
 
-	return count
+	return removed
 }
 
 // GetStats returns store statistics.
@@ -92,16 +148,16 @@ func (s *Store) GetStats() SessionStats {
 
 	stats := SessionStats{
 		TotalSessions: len(s.sessions),
+		TotalUsers:    len(s.userIndex),
 	}
 
-	now := time.Now()
 	for _, session := range s.sessions {
-		if now.Before(session.ExpiresAt) && session.Status == types.SessionActive {
+		if !session.IsExpired() {
 			stats.ActiveSessions++
 		} else {
 			stats.ExpiredSessions++
 		}
-		stats.TotalMappings += len(session.Mappings)
+		stats.TotalMappings += session.MappingCount()
 	}
 
 	return stats
@@ -118,4 +174,3 @@ func (s *Store) ListAll() []string {
 	}
 	return ids
 }
-
@@ -1,7 +1,10 @@
 // Package types contains shared type definitions for Enterprise Shield.
 package types
 
-import "time"
+import (
+	"sync"
+	"time"
+)
 
 // Severity represents the severity level of a violation or rule.
 type Severity string
@@ -24,6 +27,16 @@ const (
 	ActionRateLimited           Action = "rate_limited"
 )
 
+// FindingAction is what happens to content matched by a compliance detector.
+type FindingAction string
+
+const (
+	FindingBlock  FindingAction = "block"  // Block the whole request
+	FindingRedact FindingAction = "redact" // Replace with an irreversible placeholder
+	FindingAlias  FindingAction = "alias"  // Replace with an alias restored in the response
+	FindingWarn   FindingAction = "warn"   // Send unchanged with a warning
+)
+
 // AccessLevel represents user access level for RBAC.
 type AccessLevel string
 
@@ -43,9 +56,15 @@ const (
 )
 
 // Session represents a user session with mappings.
+//
+// Mappings, counters and access bookkeeping are guarded by an internal lock,
+// so a session may be shared between concurrent sanitize and desanitize
+// calls. Callers must use the accessor methods rather than reading the maps
+// directly while the session is in use.
 type Session struct {
 	SessionID       string            `json:"sessionId"`
 	UserID          string            `json:"userId"`
+	Scope           string            `json:"scope,omitempty"` // Conversation ID or workspace path
 	Department      string            `json:"department,omitempty"`
 	CreatedAt       time.Time         `json:"createdAt"`
 	ExpiresAt       time.Time         `json:"expiresAt"`
@@ -55,6 +74,8 @@ type Session struct {
 	ReverseMappings map[string]string `json:"reverseMappings"` // Alias → Original
 	RequestCount    int               `json:"requestCount"`
 	Counters        map[string]int    `json:"counters"` // Per-prefix counters
+
+	mu sync.RWMutex
 }
 
 // NewSession creates a new session for a user.
@@ -77,40 +98,191 @@ func NewSession(sessionID, userID, department string, ttl time.Duration) *Sessio
 
 // IsExpired checks if the session has expired.
 func (s *Session) IsExpired() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
 	return time.Now().After(s.ExpiresAt) || s.Status != SessionActive
 }
 
 // AddMapping adds a mapping and its reverse to the session.
 func (s *Session) AddMapping(original, alias string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
 	s.Mappings[original] = alias
 	s.ReverseMappings[alias] = original
 }
 
 // GetAlias returns the alias for an original value.
 func (s *Session) GetAlias(original string) (string, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
 	alias, ok := s.Mappings[original]
 	return alias, ok
 }
 
 // GetOriginal returns the original value for an alias.
 func (s *Session) GetOriginal(alias string) (string, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
 	original, ok := s.ReverseMappings[alias]
 	return original, ok
 }
 
+// GetOrCreateAlias returns the alias for an original value, creating one if
+// none exists. The lookup, counter increment and mapping insert happen under
+// a single lock, so concurrent callers never assign two aliases to the same
+// value or the same alias to two values. format builds the alias from the
+// prefix and the next counter value. The boolean reports whether the alias
+// was newly created.
+func (s *Session) GetOrCreateAlias(original, prefix string, format func(prefix string, counter int) string) (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if alias, ok := s.Mappings[original]; ok {
+		return alias, false
+	}
+
+	count := s.Counters[prefix]
+	s.Counters[prefix] = count + 1
+	alias := format(prefix, count)
+
+	s.Mappings[original] = alias
+	s.ReverseMappings[alias] = original
+	return alias, true
+}
+
+// RemoveMapping revokes a single alias and its original value.
+// The alias counter is not rewound, so the alias is never reissued.
+func (s *Session) RemoveMapping(alias string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	original, ok := s.ReverseMappings[alias]
+	if !ok {
+		return false
+	}
+	delete(s.ReverseMappings, alias)
+	delete(s.Mappings, original)
+	return true
+}
+
+// ReplaceMappings overwrites this session's mappings and counters with those
+// of other, for example after another process updated the persisted copy.
+func (s *Session) ReplaceMappings(other *Session) {
+	snapshot := other.Clone()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.Mappings = snapshot.Mappings
+	s.ReverseMappings = snapshot.ReverseMappings
+	s.Counters = snapshot.Counters
+}
+
 // GetNextCounter returns the next counter value for a prefix.
 func (s *Session) GetNextCounter(prefix string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
 	count := s.Counters[prefix]
 	s.Counters[prefix] = count + 1
 	return count
 }
 
+// MappingCount returns the number of mappings in the session.
+func (s *Session) MappingCount() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return len(s.Mappings)
+}
+
+// ReverseMappingsSnapshot returns a copy of the alias → original mappings.
+func (s *Session) ReverseMappingsSnapshot() map[string]string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	snapshot := make(map[string]string, len(s.ReverseMappings))
+	for alias, original := range s.ReverseMappings {
+		snapshot[alias] = original
+	}
+	return snapshot
+}
+
+// Clone returns a consistent copy of the session, safe to serialize while
+// the original continues to be used.
+func (s *Session) Clone() *Session {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	clone := &Session{
+		SessionID:       s.SessionID,
+		UserID:          s.UserID,
+		Scope:           s.Scope,
+		Department:      s.Department,
+		CreatedAt:       s.CreatedAt,
+		ExpiresAt:       s.ExpiresAt,
+		LastAccessedAt:  s.LastAccessedAt,
+		Status:          s.Status,
+		Mappings:        make(map[string]string, len(s.Mappings)),
+		ReverseMappings: make(map[string]string, len(s.ReverseMappings)),
+		RequestCount:    s.RequestCount,
+		Counters:        make(map[string]int, len(s.Counters)),
+	}
+	for original, alias := range s.Mappings {
+		clone.Mappings[original] = alias
+	}
+	for alias, original := range s.ReverseMappings {
+		clone.ReverseMappings[alias] = original
+	}
+	for prefix, count := range s.Counters {
+		clone.Counters[prefix] = count
+	}
+	return clone
+}
+
 // Touch updates the last accessed time.
 func (s *Session) Touch() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
 	s.LastAccessedAt = time.Now()
 	s.RequestCount++
 }
 
+// Extend slides the expiry to ttl from now, capped at maxLifetime after
+// creation. A zero maxLifetime means no cap. Expiry never moves backwards.
+func (s *Session) Extend(ttl, maxLifetime time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	expiresAt := time.Now().Add(ttl)
+	if maxLifetime > 0 {
+		if limit := s.CreatedAt.Add(maxLifetime); expiresAt.After(limit) {
+			expiresAt = limit
+		}
+	}
+	if expiresAt.After(s.ExpiresAt) {
+		s.ExpiresAt = expiresAt
+	}
+}
+
+// Wipe clears all mappings and counters and marks the session terminated.
+// Go strings are immutable, so this drops every reference to the original
+// values held by the session rather than overwriting their bytes in place.
+func (s *Session) Wipe() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	clear(s.Mappings)
+	clear(s.ReverseMappings)
+	clear(s.Counters)
+	s.Status = SessionTerminated
+}
+
 // SanitizationRule defines a pattern for detecting and masking sensitive data.
 type SanitizationRule struct {
 	RuleID      string   `json:"ruleId" yaml:"ruleId"`
@@ -124,17 +296,39 @@ type SanitizationRule struct {
 	Order       int      `json:"order,omitempty" yaml:"order"`
 }
 
-// UserPolicy defines access rules for a user.
+// UserPolicy defines access rules for a user, department or role. Roles
+// lists the roles that members of the user or department belong to.
 type UserPolicy struct {
-	PolicyID          string      `json:"policyId"`
-	UserID            string      `json:"userId"`
-	Department        string      `json:"department,omitempty"`
-	AccessLevel       AccessLevel `json:"accessLevel"`
-	AllowedProviders  []string    `json:"allowedProviders,omitempty"`
-	DailyRequestLimit int         `json:"dailyRequestLimit"`
-	HourlyRequestLimit int        `json:"hourlyRequestLimit"`
-	RequiredRules     []string    `json:"requiredRules,omitempty"`
-	Enabled           bool        `json:"enabled"`
+	PolicyID           string      `json:"policyId" yaml:"policyId"`
+	UserID             string      `json:"userId" yaml:"userId,omitempty"`
+	Department         string      `json:"department,omitempty" yaml:"department,omitempty"`
+	AccessLevel        AccessLevel `json:"accessLevel" yaml:"accessLevel"`
+	AllowedProviders   []string    `json:"allowedProviders,omitempty" yaml:"allowedProviders,omitempty"`
+	DailyRequestLimit  int         `json:"dailyRequestLimit" yaml:"dailyRequestLimit"`
+	HourlyRequestLimit int         `json:"hourlyRequestLimit" yaml:"hourlyRequestLimit"`
+	RequiredRules      []string    `json:"requiredRules,omitempty" yaml:"requiredRules,omitempty"`
+	RuleProfile        string      `json:"ruleProfile,omitempty" yaml:"ruleProfile,omitempty"`
+	Roles              []string    `json:"roles,omitempty" yaml:"roles,omitempty"`
+	Enabled            bool        `json:"enabled" yaml:"enabled"`
+
+	// Model globs match "provider/model", e.g. "openai/gpt-4o*"
+	AllowedModels []string `json:"allowedModels,omitempty" yaml:"allowedModels,omitempty"`
+	DeniedModels  []string `json:"deniedModels,omitempty" yaml:"deniedModels,omitempty"`
+
+	// TokenBudget limits each user's tokens. DepartmentTokenBudget, set on a
+	// department policy, limits the department's combined tokens.
+	TokenBudget           *TokenBudget `json:"tokenBudget,omitempty" yaml:"tokenBudget,omitempty"`
+	DepartmentTokenBudget *TokenBudget `json:"departmentTokenBudget,omitempty" yaml:"departmentTokenBudget,omitempty"`
+}
+
+// TokenBudget limits input plus output tokens per UTC day and month. Going
+// past a warning threshold adds a warning; a request that would go past a
+// limit is blocked. Zero means no threshold.
+type TokenBudget struct {
+	DailyWarning   int64 `json:"dailyWarning,omitempty" yaml:"dailyWarning,omitempty"`
+	DailyLimit     int64 `json:"dailyLimit,omitempty" yaml:"dailyLimit,omitempty"`
+	MonthlyWarning int64 `json:"monthlyWarning,omitempty" yaml:"monthlyWarning,omitempty"`
+	MonthlyLimit   int64 `json:"monthlyLimit,omitempty" yaml:"monthlyLimit,omitempty"`
 }
 
 // Violation represents a detected violation.
@@ -147,6 +341,26 @@ type Violation struct {
 	RedactedValue  string   `json:"redactedValue"`
 	Position       int      `json:"position"`
 	Length         int      `json:"length"`
+
+	Action   FindingAction       `json:"action,omitempty"`   // Set for compliance findings
+	Controls []ComplianceControl `json:"controls,omitempty"` // Controls of enabled frameworks
+
+	Encoding   string `json:"encoding,omitempty"`   // Set when found in decoded text, e.g. "base64"
+	Normalized bool   `json:"normalized,omitempty"` // Found only after Unicode normalization, e.g. in full-width digits
+	CardBrand  string `json:"cardBrand,omitempty"`  // For card numbers, e.g. "visa"
+	TestData   bool   `json:"testData,omitempty"`   // A well-known test value, such as a test card number
+}
+
+// ComplianceControl identifies a control of a compliance framework that a
+// finding relates to, such as PCI-DSS 3.4.
+type ComplianceControl struct {
+	Framework string `json:"framework"` // e.g. "PCI-DSS"
+	Control   string `json:"control"`   // e.g. "3.4"
+}
+
+// String returns the control as it is usually cited, e.g. "PCI-DSS 3.4".
+func (c ComplianceControl) String() string {
+	return c.Framework + " " + c.Control
 }
 
 // SanitizationResult contains the result of sanitization.
@@ -158,6 +372,8 @@ type SanitizationResult struct {
 	ProcessingTimeMs int64             `json:"processingTimeMs"`
 	ShouldBlock      bool              `json:"shouldBlock"`
 	BlockReason      string            `json:"blockReason,omitempty"`
+
+	Warnings []Warning `json:"warnings,omitempty"` // Summary of what was aliased
 }
 
 // DesanitizationResult contains the result of desanitization.
@@ -173,29 +389,77 @@ type ComplianceResult struct {
 	HasViolations bool        `json:"hasViolations"`
 	ShouldBlock   bool        `json:"shouldBlock"`
 	Violations    []Violation `json:"violations"`
+
+	Warnings []Warning `json:"warnings,omitempty"` // Findings that did not block
 }
 
 // PolicyDecision contains the policy evaluation result.
 type PolicyDecision struct {
 	Action             Action   `json:"action"`
 	Reason             string   `json:"reason,omitempty"`
-	PolicyApplied      string   `json:"policyApplied,omitempty"`
+	PolicyApplied      []string `json:"policyApplied,omitempty"` // Every policy that contributed
 	RequiredSanitization []string `json:"requiredSanitization,omitempty"`
+	RuleProfiles         []string `json:"ruleProfiles,omitempty"`
+
+	Warnings []Warning `json:"warnings,omitempty"`
+
+	// Token budgets that apply to the request, if any
+	TokenBudget           *TokenBudget `json:"tokenBudget,omitempty"`
+	DepartmentTokenBudget *TokenBudget `json:"departmentTokenBudget,omitempty"`
+
+	// Override is the break-glass override that elevated access, if any.
+	// OverrideError explains why a presented override was not honored.
+	Override      *Override `json:"override,omitempty"`
+	OverrideError string    `json:"overrideError,omitempty"`
+}
+
+// Override is a break-glass grant that temporarily elevates one user's
+// access in one scope. It is issued as a signed token and every use is
+// audited with its justification.
+type Override struct {
+	ID            string      `json:"id"`
+	UserID        string      `json:"userId"`
+	Scope         string      `json:"scope"` // Conversation ID or workspace path, or "*" for any
+	AccessLevel   AccessLevel `json:"accessLevel"`
+	Justification string      `json:"justification"`
+	IssuedBy      string      `json:"issuedBy"` // Fingerprint of the signing key
+	IssuedAt      time.Time   `json:"issuedAt"`
+	ExpiresAt     time.Time   `json:"expiresAt"`
 }
 
+// AuditEvent identifies the kind of event an audit entry records.
+type AuditEvent string
+
+const (
+	AuditEventRequest         AuditEvent = "request"
+	AuditEventSessionExpired  AuditEvent = "session_expired"
+	AuditEventSessionExported AuditEvent = "session_exported"
+	AuditEventSessionImported AuditEvent = "session_imported"
+	AuditEventMappingRevoked  AuditEvent = "mapping_revoked"
+	AuditEventPolicyReloaded  AuditEvent = "policy_reloaded"
+
+	AuditEventOverrideIssued   AuditEvent = "override_issued"
+	AuditEventOverrideUsed     AuditEvent = "override_used"
+	AuditEventOverrideRejected AuditEvent = "override_rejected"
+	AuditEventOverrideRevoked  AuditEvent = "override_revoked"
+)
+
 // AuditEntry represents an audit log entry.
 type AuditEntry struct {
 	EntryID           string      `json:"entryId"`
 	Timestamp         time.Time   `json:"timestamp"`
+	Event             AuditEvent  `json:"event,omitempty"`
 	UserID            string      `json:"userId"`
 	SessionID         string      `json:"sessionId,omitempty"`
 	Department        string      `json:"department,omitempty"`
 	Provider          string      `json:"provider,omitempty"`
+	Model             string      `json:"model,omitempty"`
 	RequestHash       string      `json:"requestHash"`
 	ResponseHash      string      `json:"responseHash,omitempty"`
 	WasSanitized      bool        `json:"wasSanitized"`
 	Violations        []Violation `json:"violations,omitempty"`
 	Action            Action      `json:"action"`
+	Reason            string      `json:"reason,omitempty"`
 	ProcessingTimeMs  int64       `json:"processingTimeMs"`
 	Signature         string      `json:"signature,omitempty"`
 	PreviousEntryHash string      `json:"previousEntryHash,omitempty"`
@@ -205,10 +469,15 @@ type AuditEntry struct {
 type Request struct {
 	UserID     string            `json:"userId"`
 	SessionID  string            `json:"sessionId,omitempty"`
+	Scope      string            `json:"scope,omitempty"` // Conversation ID or workspace path
 	Department string            `json:"department,omitempty"`
 	Provider   string            `json:"provider,omitempty"`
 	Content    string            `json:"content"`
 	Headers    map[string]string `json:"headers,omitempty"`
+
+	SourceIP string `json:"sourceIp,omitempty"` // Client address, for policy conditions
+	Model    string `json:"model,omitempty"`    // Model name without the provider, e.g. "gpt-4o"
+	Override string `json:"override,omitempty"` // Break-glass override token
 }
 
 // Response represents the processed response.
@@ -221,5 +490,15 @@ type Response struct {
 	Blocked         bool              `json:"blocked"`
 	BlockReason     string            `json:"blockReason,omitempty"`
 	Violations      []Violation       `json:"violations,omitempty"`
+
+	Warnings []Warning `json:"warnings,omitempty"` // Shown to the developer; the request was allowed
+}
+
+// Warning tells the developer about something the shield noticed in an
+// allowed request, with a hint on how to avoid it.
+type Warning struct {
+	Rule        string `json:"rule"`
+	Reason      string `json:"reason"`
+	Remediation string `json:"remediation,omitempty"`
 }
 
@@ -0,0 +1,41 @@
+// Package usage provides token budget checks.
+package usage
+
+import (
+	"fmt"
+
+	"github.com/enterprise/opencode-enterprise-shield/pkg/types"
+)
+
+// Check compares usage plus a request's estimated tokens with a budget.
+// It returns a block reason if the request would go past a limit, and a
+// warning for each warning threshold it would pass. subject names whose
+// budget it is, e.g. "user alice".
+func Check(subject string, budget *types.TokenBudget, used Usage, tokens int64) (string, []string) {
+	if budget == nil {
+		return "", nil
+	}
+
+	periods := []struct {
+		name, title    string
+		used           int64
+		warning, limit int64
+	}{
+		{"daily", "Daily", used.Day, budget.DailyWarning, budget.DailyLimit},
+		{"monthly", "Monthly", used.Month, budget.MonthlyWarning, budget.MonthlyLimit},
+	}
+
+	var warnings []string
+	for _, period := range periods {
+		total := period.used + tokens
+		if period.limit > 0 && total > period.limit {
+			return fmt.Sprintf("%s token limit of %d exceeded for %s (%d used, request needs about %d)",
+				period.title, period.limit, subject, period.used, tokens), nil
+		}
+		if period.warning > 0 && total > period.warning {
+			warnings = append(warnings, fmt.Sprintf("%s has used %d tokens, past the %s warning threshold of %d",
+				subject, total, period.name, period.warning))
+		}
+	}
+	return "", warnings
+}
@@ -0,0 +1,43 @@
+// Package usage provides local token estimation.
+package usage
+
+import "unicode"
+
+// EstimateTokens approximates the number of tokens a BPE tokenizer such as
+// those used by OpenAI and Anthropic models produces for text, without a
+// vocabulary. Words count one token per four letters, numbers one per three
+// digits, and each punctuation mark or symbol one token. Characters in the
+// CJK ranges (U+2E80 to U+FFEF) count one token each. Whitespace is free.
+func EstimateTokens(text string) int64 {
+	var tokens int64
+	var letters, digits int64
+
+	flush := func() {
+		tokens += (letters + 3) / 4
+		tokens += (digits + 2) / 3
+		letters, digits = 0, 0
+	}
+
+	for _, r := range text {
+		switch {
+		case unicode.IsSpace(r):
+			flush()
+		case unicode.IsDigit(r):
+			if letters > 0 {
+				flush()
+			}
+			digits++
+		case unicode.IsLetter(r) && (r < 0x2E80 || r > 0xFFEF):
+			// Alphabetic scripts; CJK characters fall through
+			if digits > 0 {
+				flush()
+			}
+			letters++
+		default:
+			flush()
+			tokens++
+		}
+	}
+	flush()
+	return tokens
+}
@@ -0,0 +1,301 @@
+// Package usage provides persistent token counters for usage budgets.
+package usage
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/enterprise/opencode-enterprise-shield/pkg/paths"
+	"github.com/enterprise/opencode-enterprise-shield/pkg/types"
+)
+
+// Counter kinds.
+const (
+	KindUser       = "user"
+	KindDepartment = "department"
+)
+
+// Periods are UTC days and months.
+const (
+	dayFormat   = "2006-01-02"
+	monthFormat = "2006-01"
+)
+
+// Retention of period counters in the store.
+const (
+	keepDays   = 35
+	keepMonths = 13
+)
+
+// Usage is the number of tokens used in the current day and month.
+type Usage struct {
+	Day   int64 `json:"day"`
+	Month int64 `json:"month"`
+}
+
+// Store counts tokens per user and department, per UTC day and month. It is
+// persisted to a JSON file shared by every process on the machine, and
+// re-read whenever another process changed it. Updates hold a lock file next
+// to it. A Store with an empty path keeps counters in memory only.
+type Store struct {
+	path     string
+	mu       sync.Mutex
+	modTime  time.Time
+	counters map[string]int64 // "kind|name|period" -> tokens
+}
+
+// Open opens the counter store at path, creating it on first update.
+func Open(path string) (*Store, error) {
+	path, err := paths.ExpandHome(path)
+	if err != nil {
+		return nil, err
+	}
+
+	store := &Store{path: path, counters: make(map[string]int64)}
+	if err := store.refresh(); err != nil {
+		return nil, err
+	}
+	return store, nil
+}
+
+// Get returns a user's or department's usage in the periods containing now.
+func (s *Store) Get(kind, name string, now time.Time) (Usage, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.refresh(); err != nil {
+		return Usage{}, err
+	}
+	return s.usage(kind, name, now), nil
+}
+
+// Add adds tokens to a user's and, if department is not empty, their
+// department's counters for the periods containing now.
+func (s *Store) Add(userID, department string, tokens int64, now time.Time) error {
+	if tokens <= 0 {
+		return nil
+	}
+
+	return s.update(func() bool {
+		s.charge(userID, department, tokens, now)
+		return true
+	}, now)
+}
+
+// Charge checks tokens against a user's and their department's budgets and,
+// unless a limit would be exceeded, adds them to both. The check and the
+// update happen under one lock shared with other processes, so concurrent
+// requests cannot together go past a limit. It returns a block reason, or a
+// warning for each warning threshold passed. A nil budget is not checked.
+func (s *Store) Charge(userID, department string, userBudget, departmentBudget *types.TokenBudget, tokens int64, now time.Time) (string, []string, error) {
+	type budgetCheck struct {
+		kind, name string
+		budget     *types.TokenBudget
+	}
+	checks := []budgetCheck{{KindUser, userID, userBudget}}
+	if department != "" {
+		checks = append(checks, budgetCheck{KindDepartment, department, departmentBudget})
+	}
+
+	var blockReason string
+	var warnings []string
+	err := s.update(func() bool {
+		for _, check := range checks {
+			reason, messages := Check(check.kind+" "+check.name, check.budget, s.usage(check.kind, check.name, now), tokens)
+			if reason != "" {
+				blockReason, warnings = reason, nil
+				return false
+			}
+			warnings = append(warnings, messages...)
+		}
+		if tokens <= 0 {
+			return false
+		}
+		s.charge(userID, department, tokens, now)
+		return true
+	}, now)
+	if err != nil {
+		return "", nil, err
+	}
+	return blockReason, warnings, nil
+}
+
+// Stats is the usage of every user and department in the current periods.
+type Stats struct {
+	Day         string           `json:"day"`
+	Month       string           `json:"month"`
+	Users       map[string]Usage `json:"users,omitempty"`
+	Departments map[string]Usage `json:"departments,omitempty"`
+}
+
+// Stats returns the usage of everyone with tokens counted this month.
+func (s *Store) Stats(now time.Time) (Stats, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stats := Stats{
+		Day:         now.UTC().Format(dayFormat),
+		Month:       now.UTC().Format(monthFormat),
+		Users:       make(map[string]Usage),
+		Departments: make(map[string]Usage),
+	}
+	if err := s.refresh(); err != nil {
+		return stats, err
+	}
+
+	for key := range s.counters {
+		kind, name, period, ok := splitKey(key)
+		if !ok || period != stats.Month {
+			continue
+		}
+		switch kind {
+		case KindUser:
+			stats.Users[name] = s.usage(kind, name, now)
+		case KindDepartment:
+			stats.Departments[name] = s.usage(kind, name, now)
+		}
+	}
+	return stats, nil
+}
+
+// usage reads the counters for the periods containing now.
+func (s *Store) usage(kind, name string, now time.Time) Usage {
+	now = now.UTC()
+	return Usage{
+		Day:   s.counters[counterKey(kind, name, now.Format(dayFormat))],
+		Month: s.counters[counterKey(kind, name, now.Format(monthFormat))],
+	}
+}
+
+// charge adds tokens to a user's and, if department is not empty, their
+// department's counters.
+func (s *Store) charge(userID, department string, tokens int64, now time.Time) {
+	s.add(KindUser, userID, tokens, now)
+	if department != "" {
+		s.add(KindDepartment, department, tokens, now)
+	}
+}
+
+// add increments the counters for the periods containing now.
+func (s *Store) add(kind, name string, tokens int64, now time.Time) {
+	now = now.UTC()
+	s.counters[counterKey(kind, name, now.Format(dayFormat))] += tokens
+	s.counters[counterKey(kind, name, now.Format(monthFormat))] += tokens
+}
+
+// prune drops day and month counters past their retention.
+func (s *Store) prune(now time.Time) {
+	now = now.UTC()
+	oldestDay := now.AddDate(0, 0, -keepDays).Format(dayFormat)
+	oldestMonth := now.AddDate(0, -keepMonths, 0).Format(monthFormat)
+	for key := range s.counters {
+		_, _, period, ok := splitKey(key)
+		if !ok {
+			continue
+		}
+		if len(period) == len(dayFormat) && period < oldestDay {
+			delete(s.counters, key)
+		} else if len(period) == len(monthFormat) && period < oldestMonth {
+			delete(s.counters, key)
+		}
+	}
+}
+
+// counterKey builds the key of one counter. Names may contain the
+// separator, since kinds and periods never do.
+func counterKey(kind, name, period string) string {
+	return kind + "|" + name + "|" + period
+}
+
+// splitKey splits a counter key into its parts.
+func splitKey(key string) (kind, name, period string, ok bool) {
+	first := strings.IndexByte(key, '|')
+	last := strings.LastIndexByte(key, '|')
+	if first < 0 || first == last {
+		return "", "", "", false
+	}
+	return key[:first], key[first+1 : last], key[last+1:], true
+}
+
+// update runs fn on the latest counters and, if it returns true, saves them.
+// The store's lock file is held throughout, so no other process can update
+// the file in between.
+func (s *Store) update(fn func() bool, now time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.path != "" {
+		unlock, err := paths.Lock(s.path + ".lock")
+		if err != nil {
+			return fmt.Errorf("failed to lock usage store: %w", err)
+		}
+		defer unlock()
+
+		// Two quick writes may leave the same modification time
+		s.modTime = time.Time{}
+	}
+	if err := s.refresh(); err != nil {
+		return err
+	}
+	if !fn() {
+		return nil
+	}
+	s.prune(now)
+	return s.save()
+}
+
+// refresh re-reads the file if another process changed it.
+func (s *Store) refresh() error {
+	if s.path == "" {
+		return nil
+	}
+
+	info, err := os.Stat(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read usage store: %w", err)
+	}
+	if info.ModTime().Equal(s.modTime) {
+		return nil
+	}
+
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		return fmt.Errorf("failed to read usage store: %w", err)
+	}
+	counters := make(map[string]int64)
+	if err := json.Unmarshal(data, &counters); err != nil {
+		return fmt.Errorf("failed to parse usage store: %w", err)
+	}
+	s.counters = counters
+	s.modTime = info.ModTime()
+	return nil
+}
+
+// save writes the counters atomically so readers never see a partial file.
+func (s *Store) save() error {
+	if s.path == "" {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(s.counters, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal usage store: %w", err)
+	}
+
+	if err := paths.WriteFileAtomic(s.path, data); err != nil {
+		return fmt.Errorf("failed to save usage store: %w", err)
+	}
+
+	if info, err := os.Stat(s.path); err == nil {
+		s.modTime = info.ModTime()
+	}
+	return nil
+}
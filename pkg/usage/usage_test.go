@@ -0,0 +1,181 @@
+package usage
+
+import (
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/enterprise/opencode-enterprise-shield/pkg/types"
+)
+
+func TestEstimateTokens(t *testing.T) {
+	tests := []struct {
+		text string
+		want int64
+	}{
+		{"", 0},
+		{"hello world", 4},
+		{"call 555-1234 now", 6},
+		{"你好", 2},
+		{"  \n\t ", 0},
+	}
+
+	for _, test := range tests {
+		if got := EstimateTokens(test.text); got != test.want {
+			t.Errorf("EstimateTokens(%q) = %d, want %d", test.text, got, test.want)
+		}
+	}
+}
+
+func TestStore_AddAndPersist(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "usage.json")
+	store, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+
+	day1 := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	day2 := day1.AddDate(0, 0, 1)
+	if err := store.Add("alice", "engineering", 100, day1); err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+	if err := store.Add("alice", "", 50, day2); err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+
+	// A second process sees the same counters
+	other, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	used, err := other.Get(KindUser, "alice", day2)
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if used != (Usage{Day: 50, Month: 150}) {
+		t.Errorf("Expected 50 today and 150 this month, got %+v", used)
+	}
+	used, _ = other.Get(KindDepartment, "engineering", day2)
+	if used != (Usage{Day: 0, Month: 100}) {
+		t.Errorf("Expected department usage only from day one, got %+v", used)
+	}
+
+	stats, err := other.Stats(day2)
+	if err != nil {
+		t.Fatalf("Stats failed: %v", err)
+	}
+	if stats.Month != "2026-10" || stats.Users["alice"].Month != 150 || stats.Departments["engineering"].Month != 100 {
+		t.Errorf("Unexpected stats %+v", stats)
+	}
+
+	// A new month starts from zero
+	used, _ = other.Get(KindUser, "alice", time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC))
+	if used != (Usage{}) {
+		t.Errorf("Expected no usage in a new month, got %+v", used)
+	}
+}
+
+func TestStore_ChargeConcurrent(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "usage.json")
+	// Two stores on one file stand in for two processes
+	stores := make([]*Store, 2)
+	for i := range stores {
+		store, err := Open(path)
+		if err != nil {
+			t.Fatalf("Open failed: %v", err)
+		}
+		stores[i] = store
+	}
+
+	now := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	budget := &types.TokenBudget{DailyLimit: 100}
+	var mu sync.Mutex
+	var allowed, blocked int
+	var wg sync.WaitGroup
+	for i := 0; i < 40; i++ {
+		wg.Add(1)
+		go func(store *Store) {
+			defer wg.Done()
+			reason, _, err := store.Charge("alice", "engineering", budget, nil, 10, now)
+			if err != nil {
+				t.Errorf("Charge failed: %v", err)
+				return
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			if reason == "" {
+				allowed++
+			} else {
+				blocked++
+			}
+		}(stores[i%2])
+	}
+	wg.Wait()
+
+	if allowed != 10 || blocked != 30 {
+		t.Errorf("Expected 10 charges allowed and 30 blocked, got %d and %d", allowed, blocked)
+	}
+	used, err := stores[0].Get(KindUser, "alice", now)
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if used.Day != 100 {
+		t.Errorf("Expected exactly the limit used, got %d", used.Day)
+	}
+	if used, _ := stores[1].Get(KindDepartment, "engineering", now); used.Day != 100 {
+		t.Errorf("Expected the department charged too, got %d", used.Day)
+	}
+}
+
+func TestStore_ChargeWarnings(t *testing.T) {
+	store, err := Open("")
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	now := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	department := &types.TokenBudget{DailyWarning: 50, DailyLimit: 80}
+
+	reason, warnings, err := store.Charge("alice", "engineering", nil, department, 60, now)
+	if err != nil || reason != "" || len(warnings) != 1 {
+		t.Fatalf("Expected a warning, got %q %v %v", reason, warnings, err)
+	}
+
+	// A blocked charge is not counted
+	reason, _, _ = store.Charge("bob", "engineering", nil, department, 30, now)
+	if !strings.Contains(reason, "department engineering") {
+		t.Errorf("Expected the department limit to block, got %q", reason)
+	}
+	if used, _ := store.Get(KindUser, "bob", now); used.Day != 0 {
+		t.Errorf("Expected a blocked charge not to count, got %d", used.Day)
+	}
+}
+
+func TestCheck(t *testing.T) {
+	budget := &types.TokenBudget{DailyWarning: 80, DailyLimit: 100, MonthlyLimit: 1000}
+
+	block, warnings := Check("user alice", budget, Usage{Day: 10, Month: 10}, 20)
+	if block != "" || len(warnings) != 0 {
+		t.Errorf("Expected no block or warning, got %q %v", block, warnings)
+	}
+
+	block, warnings = Check("user alice", budget, Usage{Day: 70, Month: 70}, 20)
+	if block != "" || len(warnings) != 1 || !strings.Contains(warnings[0], "daily warning threshold of 80") {
+		t.Errorf("Expected a daily warning, got %q %v", block, warnings)
+	}
+
+	block, _ = Check("user alice", budget, Usage{Day: 90, Month: 90}, 20)
+	if !strings.Contains(block, "Daily token limit of 100 exceeded for user alice") {
+		t.Errorf("Expected the daily limit to block, got %q", block)
+	}
+
+	block, _ = Check("user alice", budget, Usage{Day: 0, Month: 990}, 20)
+	if !strings.Contains(block, "Monthly token limit of 1000") {
+		t.Errorf("Expected the monthly limit to block, got %q", block)
+	}
+
+	if block, warnings := Check("user alice", nil, Usage{Day: 1 << 40}, 1); block != "" || warnings != nil {
+		t.Errorf("Expected no budget to allow everything")
+	}
+}